@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"ccui/backend/acp"
+)
+
+const (
+	agentTerminalCols        = 120
+	agentTerminalRows        = 30
+	agentTerminalOutputLimit = 1 << 20 // 1MB when the agent sets no limit
+)
+
+// agentTerminalHost runs ACP terminal commands as PTY sessions, so their
+// output streams to the UI like a user terminal
+type agentTerminalHost struct {
+	ptys   *PTYManager
+	nextID atomic.Int64
+}
+
+func newAgentTerminalHost(ptys *PTYManager) *agentTerminalHost {
+	return &agentTerminalHost{ptys: ptys}
+}
+
+// Create starts the command in a new PTY and returns its terminal ID
+func (h *agentTerminalHost) Create(req acp.TerminalCreateParams) (string, error) {
+	id := fmt.Sprintf("agent-terminal-%d", h.nextID.Add(1))
+
+	cmd := exec.Command(req.Command, req.Args...)
+	cmd.Dir = req.CWD
+	cmd.Env = os.Environ()
+	for _, env := range req.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+
+	limit := agentTerminalOutputLimit
+	if req.OutputByteLimit != nil && *req.OutputByteLimit > 0 {
+		limit = *req.OutputByteLimit
+	}
+	if _, err := h.ptys.StartCommand(id, cmd, agentTerminalCols, agentTerminalRows, newTailBuffer(limit)); err != nil {
+		return "", fmt.Errorf("start %s: %w", req.Command, err)
+	}
+	return id, nil
+}
+
+// Output returns the captured output and the exit status once exited
+func (h *agentTerminalHost) Output(terminalID string) (acp.TerminalOutputResult, error) {
+	s, err := h.lookup(terminalID)
+	if err != nil {
+		return acp.TerminalOutputResult{}, err
+	}
+	output, truncated := s.output.Snapshot()
+	result := acp.TerminalOutputResult{Output: output, Truncated: truncated}
+	select {
+	case <-s.exited:
+		result.ExitStatus = exitStatus(s)
+	default:
+	}
+	return result, nil
+}
+
+// WaitForExit blocks until the command exits
+func (h *agentTerminalHost) WaitForExit(terminalID string) (acp.TerminalExitStatus, error) {
+	s, err := h.lookup(terminalID)
+	if err != nil {
+		return acp.TerminalExitStatus{}, err
+	}
+	<-s.exited
+	return *exitStatus(s), nil
+}
+
+// Kill stops the command but keeps its output available
+func (h *agentTerminalHost) Kill(terminalID string) error {
+	s, err := h.lookup(terminalID)
+	if err != nil {
+		return err
+	}
+	if err := s.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("kill %s: %w", terminalID, err)
+	}
+	return nil
+}
+
+// Release kills the command if needed and frees the terminal
+func (h *agentTerminalHost) Release(terminalID string) error {
+	if _, err := h.lookup(terminalID); err != nil {
+		return err
+	}
+	h.ptys.Stop(terminalID)
+	return nil
+}
+
+func (h *agentTerminalHost) lookup(terminalID string) (*PTYSession, error) {
+	s := h.ptys.session(terminalID)
+	if s == nil || s.output == nil {
+		return nil, fmt.Errorf("terminal %s not found", terminalID)
+	}
+	return s, nil
+}
+
+func exitStatus(s *PTYSession) *acp.TerminalExitStatus {
+	return &acp.TerminalExitStatus{ExitCode: s.exitCode, Signal: s.signal}
+}
+
+// tailBuffer keeps the most recent limit bytes written to it
+type tailBuffer struct {
+	mu        sync.Mutex
+	limit     int
+	data      []byte
+	truncated bool
+}
+
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+// Write appends p, dropping the oldest bytes past the limit at a rune boundary
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if over := len(b.data) - b.limit; over > 0 {
+		for over < len(b.data) && !utf8.RuneStart(b.data[over]) {
+			over++
+		}
+		b.data = append([]byte(nil), b.data[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// Snapshot returns the buffered output and whether any was dropped
+func (b *tailBuffer) Snapshot() (string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data), b.truncated
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestTailBuffer_KeepsMostRecentOutput(t *testing.T) {
+	b := newTailBuffer(8)
+
+	b.Write([]byte("hello "))
+	if out, truncated := b.Snapshot(); out != "hello " || truncated {
+		t.Fatalf("got %q truncated=%v", out, truncated)
+	}
+
+	b.Write([]byte("world"))
+	if out, truncated := b.Snapshot(); out != "lo world" || !truncated {
+		t.Fatalf("got %q truncated=%v", out, truncated)
+	}
+}
+
+func TestTailBuffer_TruncatesAtRuneBoundary(t *testing.T) {
+	b := newTailBuffer(4)
+
+	// "é" is two bytes; dropping 1 byte would split it
+	b.Write([]byte("aébc"))
+	out, _ := b.Snapshot()
+	if out != "ébc" {
+		t.Fatalf("got %q, want %q", out, "ébc")
+	}
+
+	b.Write([]byte("d"))
+	out, _ = b.Snapshot()
+	if out != "bcd" {
+		t.Fatalf("got %q, want %q", out, "bcd")
+	}
+}
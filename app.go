@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,13 +26,27 @@ import (
 
 type SessionMode = backend.SessionMode // Wails binding compatibility
 
-type SessionInfo struct{ ID, Name, CreatedAt, ModeID string }
+type SessionInfo struct {
+	ID, Name, CreatedAt, ModeID string
+	Busy                        bool // a prompt is running
+}
 
 type SessionState struct {
 	ID, Name  string
+	CWD       string // working directory of the agent
 	CreatedAt time.Time
 	Session   backend.Session // unified session interface
 	EventChan chan backend.Event
+
+	// prompts sent while a turn is running, sent in order once it ends
+	promptMu sync.Mutex
+	prompts  []string
+	busy     bool
+	closed   bool
+
+	holdsSlot bool // counted in App.liveSessions until the agent exits
+
+	watcher *backend.FileWatcher // nil unless file watching is on; guarded by App.sessionMu
 }
 
 // BackendType selects which agent backend to use
@@ -44,7 +64,13 @@ type App struct {
 	sessions        map[string]*SessionState
 	activeSessionID string
 	sessionMu       sync.RWMutex
+	maxSessions     int // 0 is unlimited
+	liveSessions    int // sessions whose agent is still running, guarded by sessionMu
 	ptyManager      *PTYManager
+	reviews         map[string]*reviewRun // session ID -> running review agent
+	reviewMu        sync.Mutex
+	emitter         EventEmitter   // frontend events outside the session bridge
+	events          *eventRecorder // nil unless CCUI_EVENT_LOG_DIR is set
 
 	// backend infrastructure
 	backendType BackendType
@@ -61,150 +87,544 @@ func NewApp() *App {
 	}
 	return &App{
 		sessions:    make(map[string]*SessionState),
+		reviews:     make(map[string]*reviewRun),
 		backendType: bt,
+		maxSessions: maxSessions(),
+	}
+}
+
+// defaultMaxSessions caps concurrently running agents
+const defaultMaxSessions = 16
+
+// ErrSessionLimit is returned by CreateSession when every session slot is in use
+var ErrSessionLimit = errors.New("session limit reached")
+
+// maxSessions reads CCUI_MAX_SESSIONS; 0 disables the cap
+func maxSessions() int {
+	if n, err := strconv.Atoi(os.Getenv("CCUI_MAX_SESSIONS")); err == nil && n >= 0 {
+		return n
 	}
+	return defaultMaxSessions
+}
+
+// reserveSession claims a session slot, failing when all are in use
+func (a *App) reserveSession() error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.maxSessions > 0 && a.liveSessions >= a.maxSessions {
+		return fmt.Errorf("%w: %d sessions running; close one first", ErrSessionLimit, a.liveSessions)
+	}
+	a.liveSessions++
+	return nil
+}
+
+// releaseSession frees a slot claimed by reserveSession
+func (a *App) releaseSession() {
+	a.sessionMu.Lock()
+	a.liveSessions--
+	a.sessionMu.Unlock()
 }
 
+// NewAppWithEmitter creates an App that sends frontend events to emitter
+// instead of the Wails runtime, for headless use and tests
+func NewAppWithEmitter(emitter EventEmitter) *App {
+	a := NewApp()
+	a.emitter = emitter
+	return a
+}
+
+// startup is the Wails OnStartup hook: it wires the core to the Wails
+// runtime and subscribes to frontend events
 func (a *App) startup(ctx context.Context) {
+	if a.emitter == nil {
+		a.emitter = &wailsEmitter{ctx: ctx}
+	}
+	a.setup(ctx)
+
+	wailsRuntime.EventsOn(ctx, "send_message", a.handleSendMessage)
+	wailsRuntime.EventsOn(ctx, "permission_response", a.handlePermissionResponse)
+	wailsRuntime.EventsOn(ctx, "user_answer", a.handleUserAnswer)
+	wailsRuntime.EventsOn(ctx, "cancel", a.handleCancel)
+	wailsRuntime.EventsOn(ctx, "submit_review", a.handleSubmitReview)
+	a.StartTerminalListeners()
+}
+
+// setup initializes the MCP server, permission layer, tools and backend
+// without touching the Wails runtime; a.emitter must already be set
+func (a *App) setup(ctx context.Context) {
 	a.ctx = ctx
-	a.mcpServer = NewUserQuestionServer(ctx)
+	a.mcpServer = NewUserQuestionServer(a.emitter)
 	if url, err := a.mcpServer.Start(); err != nil {
 		slog.Error("failed to start MCP server", "error", err)
 	} else {
 		a.mcpServerURL = url
 	}
 
-	// init permission layer with wails emitter
-	a.permLayer = permission.NewLayer(permission.DefaultRules(), &wailsEmitter{ctx: ctx})
+	if dir := os.Getenv("CCUI_EVENT_LOG_DIR"); dir != "" {
+		if rec, err := newEventRecorder(dir); err != nil {
+			slog.Error("failed to open event log", "error", err)
+		} else {
+			a.events = rec
+		}
+	}
+
+	a.permLayer = permission.NewLayerWithDenylist(permission.DefaultRules(), permission.DefaultDenylist(), a.emitter)
+	a.permLayer.SetAutoApprove(autoApproveTools()...)
 
 	// init tool registry
 	a.toolReg = tools.NewRegistry()
+	a.toolReg.SetTimeouts(toolTimeouts())
+	a.toolReg.Use(tools.LoggingMiddleware(slog.Default()))
+	redact := secretRedactor()
+	if redact != nil {
+		a.toolReg.Use(tools.RedactMiddleware(redact))
+	}
 	a.toolReg.Register(tools.NewReadTool())
+	a.toolReg.Register(tools.NewReadManyTool())
 	a.toolReg.Register(tools.NewGlobTool())
 	a.toolReg.Register(tools.NewGrepTool())
-	a.toolReg.Register(tools.NewBashTool())
+	a.toolReg.Register(tools.NewOutlineTool())
+	a.toolReg.Register(tools.NewGitStatusTool())
+	a.toolReg.Register(tools.NewGitDiffTool())
+	a.toolReg.Register(tools.NewProjectInfoTool())
+	// background processes belong to the session that started them
+	a.toolReg.Register(tools.NewBashToolWithOptions(tools.BashOptions{Emitter: tools.ContextOutputEmitter{}}))
+	a.toolReg.Register(tools.NewListBackgroundTool(nil))
+	a.toolReg.Register(tools.NewReadBackgroundTool(nil))
+	a.toolReg.Register(tools.NewKillBackgroundTool(nil))
 	a.toolReg.Register(tools.NewWriteTool())
 	a.toolReg.Register(tools.NewEditTool())
+	a.toolReg.Register(tools.NewStructuredEditTool())
+	a.toolReg.Register(tools.NewApplyPatchTool())
 
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if a.backendType == BackendAnthropic && apiKey != "" {
-		a.backend = anthropic.NewAnthropicBackend(anthropic.BackendConfig{
-			APIKey:    apiKey,
-			BaseURL:   os.Getenv("ANTHROPIC_BASE_URL"),
-			Executor:  a.toolReg,
-			PermLayer: a.permLayer,
-		})
+	if a.backendType == BackendAnthropic {
+		credential, authMode := anthropicCredential(apiKey)
+		cfg := anthropic.BackendConfig{
+			APIKey:     credential,
+			AuthMode:   authMode,
+			BaseURL:    os.Getenv("ANTHROPIC_BASE_URL"),
+			APIVersion: os.Getenv("CCUI_ANTHROPIC_VERSION"),
+			Betas:      anthropicBetas(),
+			Prices:     anthropicPrices(),
+			Executor:   a.toolReg,
+			PermLayer:  a.permLayer,
+			Stream:     os.Getenv("CCUI_ANTHROPIC_STREAM") != "false",
+			AuditDir:   os.Getenv("CCUI_AUDIT_DIR"),
+			WebSearch:  os.Getenv("CCUI_ANTHROPIC_WEB_SEARCH") == "true",
+		}
+		// keep the backend so sessions fail with the reason and SetAPIKey can recover
+		if err := cfg.Validate(); err != nil {
+			slog.Error("anthropic backend misconfigured", "error", err)
+		}
+		a.backend = anthropic.NewAnthropicBackend(cfg)
 		slog.Info("anthropic backend initialized")
 	} else {
-		a.backend = acp.NewACPBackend(ctx, apiKey)
+		// agent commands run through ccui's PTYs so they show as terminals
+		a.ptyManager = NewPTYManager(a.emitter)
+		a.backend = acp.NewACPBackendWithConfig(ctx, acp.BackendConfig{
+			APIKey:   apiKey,
+			LogDir:   agentLogDir(),
+			Terminal: newAgentTerminalHost(a.ptyManager),
+
+			AutoApproveTools: autoApproveTools(),
+			Reconnect:        agentReconnect(),
+			PermissionMode:   os.Getenv("CCUI_ACP_PERMISSION_MODE"),
+		})
 		slog.Info("acp backend initialized")
 	}
+}
 
-	wailsRuntime.EventsOn(ctx, "send_message", a.handleSendMessage)
-	wailsRuntime.EventsOn(ctx, "permission_response", a.handlePermissionResponse)
-	wailsRuntime.EventsOn(ctx, "user_answer", a.handleUserAnswer)
-	wailsRuntime.EventsOn(ctx, "cancel", a.handleCancel)
-	wailsRuntime.EventsOn(ctx, "submit_review", a.handleSubmitReview)
-	a.StartTerminalListeners()
+// anthropicBetas reads the comma-separated CCUI_ANTHROPIC_BETAS
+func anthropicBetas() []string {
+	var betas []string
+	for _, beta := range strings.Split(os.Getenv("CCUI_ANTHROPIC_BETAS"), ",") {
+		if beta = strings.TrimSpace(beta); beta != "" {
+			betas = append(betas, beta)
+		}
+	}
+	return betas
+}
+
+// anthropicPrices reads CCUI_ANTHROPIC_PRICES, a JSON object of model prefix
+// to per-million-token prices, e.g. {"claude-sonnet-4":{"input":3,"output":15}}
+func anthropicPrices() anthropic.PriceTable {
+	raw := os.Getenv("CCUI_ANTHROPIC_PRICES")
+	if raw == "" {
+		return nil
+	}
+	var prices anthropic.PriceTable
+	if err := json.Unmarshal([]byte(raw), &prices); err != nil {
+		slog.Error("ignoring CCUI_ANTHROPIC_PRICES", "error", err)
+		return nil
+	}
+	return prices
+}
+
+// anthropicCredential picks the direct API credential: the API key, or a
+// Claude subscription OAuth token from ANTHROPIC_AUTH_TOKEN when no key is set
+func anthropicCredential(apiKey string) (string, anthropic.AuthMode) {
+	if apiKey == "" {
+		if token := os.Getenv("ANTHROPIC_AUTH_TOKEN"); token != "" {
+			return token, anthropic.AuthOAuth
+		}
+	}
+	return apiKey, anthropic.AuthAPIKey
 }
 
-// wailsEmitter adapts wails runtime to permission.EventEmitter
+// Tool deadlines when CCUI_TOOL_TIMEOUT and CCUI_TOOL_MAX_TIMEOUT are unset;
+// the ceiling leaves room for Bash's 10 minute maximum
+const (
+	defaultToolTimeout = 2 * time.Minute
+	maxToolTimeout     = 15 * time.Minute
+)
+
+// toolTimeouts returns the registry's default and maximum tool deadlines
+func toolTimeouts() (time.Duration, time.Duration) {
+	return envDuration("CCUI_TOOL_TIMEOUT", defaultToolTimeout), envDuration("CCUI_TOOL_MAX_TIMEOUT", maxToolTimeout)
+}
+
+// envDuration parses a Go duration such as "90s" from name; "0" disables,
+// and unset or invalid values use fallback
+func envDuration(name string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d < 0 {
+		return fallback
+	}
+	return d
+}
+
+// agentLogDir returns where ACP agent stderr is logged: CCUI_AGENT_LOG_DIR,
+// else the user cache dir
+func agentLogDir() string {
+	if dir := os.Getenv("CCUI_AGENT_LOG_DIR"); dir != "" {
+		return dir
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cache, "ccui", "agent-logs")
+}
+
+// EventEmitter sends events to the frontend
+type EventEmitter interface {
+	Emit(eventName string, data any)
+}
+
+// wailsEmitter adapts wails runtime to EventEmitter
 type wailsEmitter struct{ ctx context.Context }
 
 func (e *wailsEmitter) Emit(eventName string, data any) {
 	wailsRuntime.EventsEmit(e.ctx, eventName, data)
 }
 
-func (a *App) CreateSession(name string) (string, error) {
-	cwd, _ := os.Getwd()
+// CreateSession starts a session in cwd, or in ccui's working directory when
+// cwd is empty
+func (a *App) CreateSession(name, cwd string) (string, error) {
+	cwd, err := sessionDir(cwd)
+	if err != nil {
+		return "", err
+	}
+	if err := a.reserveSession(); err != nil {
+		return "", err
+	}
 	sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
 	eventPrefix := fmt.Sprintf("session:%s:", sessionID)
 	eventChan := make(chan backend.Event, 100)
 
 	sess, err := a.backend.NewSession(a.ctx, backend.SessionOpts{
-		CWD:        cwd,
-		MCPServers: a.getMCPServers(),
-		EventChan:  eventChan,
+		CWD:              cwd,
+		MCPServers:       a.getMCPServers(),
+		EventChan:        eventChan,
+		WorkspaceRoot:    workspaceRoot(cwd),
+		DedupChunks:      os.Getenv("CCUI_DEDUP_CHUNKS") == "true",
+		MaxTaskDepth:     maxTaskDepth(),
+		DiffContextLines: diffContextLines(),
 	})
 	if err != nil {
 		close(eventChan)
+		a.releaseSession()
 		return "", fmt.Errorf("create session: %w", err)
 	}
-	state := &SessionState{ID: sessionID, Name: name, CreatedAt: time.Now(), Session: sess, EventChan: eventChan}
+	state := &SessionState{ID: sessionID, Name: name, CWD: cwd, CreatedAt: time.Now(), Session: sess, EventChan: eventChan, holdsSlot: true}
 
-	go a.bridgeEvents(eventPrefix, eventChan, "chat_chunk")
+	go a.bridgeEvents(eventPrefix, eventChan, "chat_chunk", "chat_thought")
 	a.sessionMu.Lock()
 	a.sessions[sessionID], a.activeSessionID = state, sessionID
 	a.sessionMu.Unlock()
-	wailsRuntime.EventsEmit(a.ctx, "sessions_updated", a.GetSessions())
-	wailsRuntime.EventsEmit(a.ctx, "active_session_changed", sessionID)
+	if os.Getenv("CCUI_WATCH_FILES") == "true" {
+		if err := a.SetFileWatching(sessionID, true); err != nil {
+			slog.Error("file watching unavailable", "session", sessionID, "error", err)
+		}
+	}
+	a.emitter.Emit("sessions_updated", a.GetSessions())
+	a.emitter.Emit("active_session_changed", sessionID)
 	if modes := state.Session.AvailableModes(); len(modes) > 0 {
-		wailsRuntime.EventsEmit(a.ctx, eventPrefix+"modes_available", modes)
-		wailsRuntime.EventsEmit(a.ctx, eventPrefix+"mode_changed", state.Session.CurrentMode())
+		a.emitter.Emit(eventPrefix+"modes_available", modes)
+		a.emitter.Emit(eventPrefix+"mode_changed", state.Session.CurrentMode())
 	}
 	return sessionID, nil
 }
 
-func (a *App) bridgeEvents(prefix string, eventChan <-chan backend.Event, chunkEventName string) {
+// sessionDir resolves a session's working directory and checks that it is a
+// readable directory
+func sessionDir(cwd string) (string, error) {
+	if cwd == "" {
+		return os.Getwd()
+	}
+	abs, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", fmt.Errorf("session directory: %w", err)
+	}
+	dir, err := os.Open(abs)
+	if err != nil {
+		return "", fmt.Errorf("session directory: %w", err)
+	}
+	defer dir.Close()
+	info, err := dir.Stat()
+	if err != nil {
+		return "", fmt.Errorf("session directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("session directory: %s is not a directory", abs)
+	}
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return "", fmt.Errorf("session directory: %s is not readable: %w", abs, err)
+	}
+	return abs, nil
+}
+
+// autoApproveTools reads the comma-separated CCUI_AUTO_APPROVE_TOOLS list
+func autoApproveTools() []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv("CCUI_AUTO_APPROVE_TOOLS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// agentReconnect enables respawning dead ACP agents when CCUI_ACP_RECONNECT=true
+func agentReconnect() *acp.ReconnectOptions {
+	if os.Getenv("CCUI_ACP_RECONNECT") != "true" {
+		return nil
+	}
+	return &acp.ReconnectOptions{PingInterval: 30 * time.Second}
+}
+
+// secretRedactor scrubs secrets from tool output unless CCUI_REDACT_SECRETS=false
+func secretRedactor() backend.Redactor {
+	if os.Getenv("CCUI_REDACT_SECRETS") == "false" {
+		return nil
+	}
+	return backend.ScrubSecrets
+}
+
+// maxTaskDepth reads CCUI_MAX_TASK_DEPTH; 0 or unset uses the backend default
+func maxTaskDepth() int {
+	n, _ := strconv.Atoi(os.Getenv("CCUI_MAX_TASK_DEPTH"))
+	return n
+}
+
+// diffContextLines reads CCUI_DIFF_CONTEXT_LINES; 0 or unset uses the
+// backend default, negative keeps no context
+func diffContextLines() int {
+	n, _ := strconv.Atoi(os.Getenv("CCUI_DIFF_CONTEXT_LINES"))
+	return n
+}
+
+// workspaceRoot confines file tools to cwd when CCUI_CONFINE_WORKSPACE=true
+func workspaceRoot(cwd string) string {
+	if os.Getenv("CCUI_CONFINE_WORKSPACE") == "true" {
+		return cwd
+	}
+	return ""
+}
+
+// bridgeEvents forwards a session's events under prefix; message and thought
+// chunks use the given names so a review agent's output stays separate
+func (a *App) bridgeEvents(prefix string, eventChan <-chan backend.Event, chunkEventName, thoughtEventName string) {
 	for event := range eventChan {
+		var name string
 		switch event.Type {
 		case backend.EventMessageChunk:
-			wailsRuntime.EventsEmit(a.ctx, prefix+chunkEventName, event.Data)
+			name = chunkEventName
 		case backend.EventThoughtChunk:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"chat_thought", event.Data)
+			name = thoughtEventName
 		case backend.EventToolState:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"tool_state", event.Data)
+			name = "tool_state"
 		case backend.EventModeChanged:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"mode_changed", event.Data)
+			name = "mode_changed"
 		case backend.EventPlanUpdate:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"plan_update", event.Data)
+			name = "plan_update"
 		case backend.EventPromptComplete:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"prompt_complete", event.Data)
+			name = "prompt_complete"
 		case backend.EventFileChanges:
-			wailsRuntime.EventsEmit(a.ctx, prefix+"file_changes_updated", event.Data)
+			name = "file_changes_updated"
+		case backend.EventToolOutputChunk:
+			name = "tool_output_chunk"
+		case backend.EventToolProgress:
+			name = "tool_progress"
+		case backend.EventAgentTerminal:
+			name = "agent_terminal"
+		case backend.EventAuthRequired:
+			name = "auth_required"
+		case backend.EventAgentLog:
+			name = "agent_log"
+		case backend.EventHistoryCompacted:
+			name = "history_compacted"
+		case backend.EventReconnecting:
+			name = "reconnecting"
+		case backend.EventCancelled:
+			name = "cancelled"
+		case backend.EventUsage:
+			name = "usage"
+		default:
+			continue
 		}
+		a.emitter.Emit(prefix+name, event.Data)
+		a.events.record(prefix+name, event.Data)
 	}
 }
 
+// SnapshotSession re-emits a session's modes, tool states, plan and file
+// changes so a reloaded frontend can rebuild its view
+func (a *App) SnapshotSession(sessionID string) error {
+	a.sessionMu.RLock()
+	state := a.sessions[sessionID]
+	a.sessionMu.RUnlock()
+	if state == nil || state.Session == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	prefix := fmt.Sprintf("session:%s:", sessionID)
+	sess := state.Session
+
+	if modes := sess.AvailableModes(); len(modes) > 0 {
+		a.emitter.Emit(prefix+"modes_available", modes)
+		a.emitter.Emit(prefix+"mode_changed", sess.CurrentMode())
+	}
+	if snapshotter, ok := sess.(backend.Snapshotter); ok {
+		snap := snapshotter.Snapshot()
+		for _, tool := range snap.Tools {
+			a.emitter.Emit(prefix+"tool_state", tool)
+		}
+		if snap.Plan != nil {
+			a.emitter.Emit(prefix+"plan_update", snap.Plan)
+		}
+	}
+	if store := sess.FileChangeStore(); store != nil {
+		a.emitter.Emit(prefix+"file_changes_updated", store.GetAll())
+	}
+	return nil
+}
+
 func (a *App) SwitchSession(sessionID string) error {
 	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
 	if a.sessions[sessionID] == nil {
+		a.sessionMu.Unlock()
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 	a.activeSessionID = sessionID
-	wailsRuntime.EventsEmit(a.ctx, "active_session_changed", sessionID)
+	a.sessionMu.Unlock()
+	a.emitter.Emit("active_session_changed", sessionID)
 	return nil
 }
 
+// CloseSession closes a session and, if it was active, activates the newest
+// remaining one. Events are emitted after sessionMu is released.
 func (a *App) CloseSession(sessionID string) error {
 	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
 	state := a.sessions[sessionID]
 	if state == nil {
+		a.sessionMu.Unlock()
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
-	if state.Session != nil {
-		go state.Session.Close()
-	}
-	if state.EventChan != nil {
-		close(state.EventChan)
-	}
 	delete(a.sessions, sessionID)
 	if a.activeSessionID == sessionID {
-		for id := range a.sessions {
-			a.activeSessionID = id
-			break
+		a.activeSessionID = a.newestSessionLocked()
+	}
+	sessions, activeID := a.getSessionsLocked(), a.activeSessionID
+	watcher := state.watcher
+	state.watcher = nil
+	a.sessionMu.Unlock()
+
+	if watcher != nil {
+		watcher.Close()
+	}
+
+	a.CancelReview(sessionID)
+	state.promptMu.Lock()
+	state.closed, state.prompts = true, nil
+	state.promptMu.Unlock()
+	// the slot frees once the agent has exited; the agent can emit (stderr,
+	// reconnect status) until then, so its channel closes only after Close
+	go func() {
+		if state.Session != nil {
+			state.Session.Close()
 		}
-		if len(a.sessions) == 0 {
-			a.activeSessionID = ""
+		if state.EventChan != nil {
+			close(state.EventChan)
 		}
+		if state.holdsSlot {
+			a.releaseSession()
+		}
+	}()
+	a.emitter.Emit("sessions_updated", sessions)
+	a.emitter.Emit("active_session_changed", activeID)
+	return nil
+}
+
+// SetFileWatching turns watching of a session's changed files on or off;
+// while on, edits made outside the agent update the review diffs
+func (a *App) SetFileWatching(sessionID string, enabled bool) error {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	state := a.sessions[sessionID]
+	if state == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !enabled {
+		if state.watcher != nil {
+			err := state.watcher.Close()
+			state.watcher = nil
+			return err
+		}
+		return nil
+	}
+	if state.watcher != nil {
+		return nil
+	}
+	store := state.Session.FileChangeStore()
+	if store == nil {
+		return fmt.Errorf("session has no file change store")
 	}
-	wailsRuntime.EventsEmit(a.ctx, "sessions_updated", a.getSessionsLocked())
-	wailsRuntime.EventsEmit(a.ctx, "active_session_changed", a.activeSessionID)
+	eventName := fmt.Sprintf("session:%s:file_changes_updated", sessionID)
+	watcher, err := backend.NewFileWatcher(store, func(changes []backend.FileChange) {
+		a.emitter.Emit(eventName, changes)
+	})
+	if err != nil {
+		return err
+	}
+	state.watcher = watcher
 	return nil
 }
 
+// newestSessionLocked returns the most recently created session's ID, or ""
+func (a *App) newestSessionLocked() string {
+	var newest *SessionState
+	for _, s := range a.sessions {
+		if newest == nil || s.CreatedAt.After(newest.CreatedAt) {
+			newest = s
+		}
+	}
+	if newest == nil {
+		return ""
+	}
+	return newest.ID
+}
+
 func (a *App) GetSessions() []SessionInfo {
 	a.sessionMu.RLock()
 	defer a.sessionMu.RUnlock()
@@ -218,6 +638,9 @@ func (a *App) getSessionsLocked() []SessionInfo {
 		if s.Session != nil {
 			info.ModeID = s.Session.CurrentMode()
 		}
+		s.promptMu.Lock()
+		info.Busy = s.busy
+		s.promptMu.Unlock()
 		result = append(result, info)
 	}
 	return result
@@ -238,6 +661,40 @@ func (a *App) getActiveSession() backend.Session {
 	return nil
 }
 
+// sessionFor returns the session with sessionID, or the active session when
+// sessionID is empty; nil if there is none
+func (a *App) sessionFor(sessionID string) backend.Session {
+	if state := a.stateFor(sessionID); state != nil {
+		return state.Session
+	}
+	return nil
+}
+
+// stateFor returns the state of sessionID, or of the active session when
+// sessionID is empty; nil if there is none
+func (a *App) stateFor(sessionID string) *SessionState {
+	a.sessionMu.RLock()
+	defer a.sessionMu.RUnlock()
+	if sessionID == "" {
+		sessionID = a.activeSessionID
+	}
+	return a.sessions[sessionID]
+}
+
+// forEachSession calls fn for every session without holding sessionMu, so
+// fn may block or call back into the App
+func (a *App) forEachSession(fn func(*SessionState)) {
+	a.sessionMu.RLock()
+	states := make([]*SessionState, 0, len(a.sessions))
+	for _, s := range a.sessions {
+		states = append(states, s)
+	}
+	a.sessionMu.RUnlock()
+	for _, s := range states {
+		fn(s)
+	}
+}
+
 func (a *App) getActiveState() *SessionState {
 	a.sessionMu.RLock()
 	defer a.sessionMu.RUnlock()
@@ -256,40 +713,78 @@ func (a *App) handleSendMessage(data ...interface{}) {
 	if !ok {
 		return
 	}
-	go func() {
-		state := a.getActiveState()
-		if state == nil || state.Session == nil {
-			wailsRuntime.EventsEmit(a.ctx, "error", "No active session")
-			return
-		}
-		eventPrefix := fmt.Sprintf("session:%s:", state.ID)
+	state := a.getActiveState()
+	if state == nil || state.Session == nil {
+		a.emitter.Emit("error", "No active session")
+		return
+	}
+	a.enqueuePrompt(state, input)
+}
+
+// enqueuePrompt sends input now, or queues it behind the session's running
+// turn so prompts never run concurrently on one session
+func (a *App) enqueuePrompt(state *SessionState, input string) {
+	state.promptMu.Lock()
+	if state.closed {
+		state.promptMu.Unlock()
+		return
+	}
+	if state.busy {
+		state.prompts = append(state.prompts, input)
+		position := len(state.prompts)
+		state.promptMu.Unlock()
+		a.emitter.Emit(fmt.Sprintf("session:%s:queued", state.ID), map[string]any{"text": input, "position": position})
+		return
+	}
+	state.busy = true
+	state.promptMu.Unlock()
+	a.emitter.Emit(fmt.Sprintf("session:%s:busy", state.ID), true)
+	go a.runPrompts(state, input)
+}
+
+// runPrompts sends input, then each queued prompt, until the queue is empty
+func (a *App) runPrompts(state *SessionState, input string) {
+	eventPrefix := fmt.Sprintf("session:%s:", state.ID)
+	for {
 		if err := state.Session.SendPrompt(input, []string{"mcp__ccui__ccui_ask_user_question"}); err != nil {
 			slog.Error("prompt failed", "error", err)
-			wailsRuntime.EventsEmit(a.ctx, eventPrefix+"error", err.Error())
+			a.emitter.Emit(eventPrefix+"error", err.Error())
 		}
-	}()
+
+		state.promptMu.Lock()
+		if len(state.prompts) == 0 || state.closed {
+			state.busy = false
+			state.promptMu.Unlock()
+			a.emitter.Emit(eventPrefix+"busy", false)
+			return
+		}
+		input = state.prompts[0]
+		state.prompts = state.prompts[1:]
+		state.promptMu.Unlock()
+	}
 }
 
+// handlePermissionResponse routes the user's choice to the session named in
+// the payload, falling back to the active session for older frontends
 func (a *App) handlePermissionResponse(data ...interface{}) {
-	if optionID, ok := firstAs[string](data); ok {
-		// ACP backend permission response (type assert to access RespondToPermission)
-		if sess := a.getActiveSession(); sess != nil {
-			if client, ok := sess.(*acp.Client); ok {
-				client.RespondToPermission(optionID)
-				return
-			}
-		}
-		// Anthropic backend permission response
-		if a.permLayer != nil {
-			// extract toolCallId from data if present
-			if len(data) >= 2 {
-				if m, ok := data[1].(map[string]interface{}); ok {
-					if toolCallID, ok := m["toolCallId"].(string); ok {
-						a.permLayer.Respond(toolCallID, optionID)
-						return
-					}
-				}
-			}
+	optionID, ok := firstAs[string](data)
+	if !ok {
+		return
+	}
+	var meta map[string]interface{}
+	if len(data) >= 2 {
+		meta, _ = data[1].(map[string]interface{})
+	}
+	session := a.sessionFor(mapStr(meta, "sessionId"))
+	// ACP sessions wait on their own channel
+	if client, ok := session.(*acp.Client); ok {
+		client.RespondToPermission(optionID)
+		return
+	}
+	// Anthropic backend requests are keyed by session and tool call ID
+	if toolCallID := mapStr(meta, "toolCallId"); a.permLayer != nil && session != nil && toolCallID != "" {
+		if !a.permLayer.Respond(session.SessionID(), toolCallID, optionID) {
+			slog.Warn("permission response without a pending request", "toolCallId", toolCallID)
 		}
 	}
 }
@@ -304,34 +799,64 @@ func (a *App) handleUserAnswer(data ...interface{}) {
 }
 
 func (a *App) handleCancel(data ...interface{}) {
-	if sess := a.getActiveSession(); sess != nil {
-		sess.Cancel()
+	meta, _ := firstAs[map[string]interface{}](data)
+	state := a.stateFor(mapStr(meta, "sessionId"))
+	if state == nil || state.Session == nil {
+		return
 	}
+	// cancelling stops the whole run, not just the current turn
+	state.promptMu.Lock()
+	state.prompts = nil
+	state.promptMu.Unlock()
+	sess := state.Session
+	reason := mapStr(meta, "reason")
+	if reason == "" {
+		reason = "user aborted"
+	}
+	if rc, ok := sess.(backend.ReasonCanceler); ok {
+		rc.CancelWithReason(reason)
+		return
+	}
+	sess.Cancel()
 }
 
 func (a *App) handleSubmitReview(data ...interface{}) {
 	if commentsRaw, ok := firstAs[[]interface{}](data); ok {
-		a.SubmitReview(parseReviewComments(commentsRaw))
+		sessionID := ""
+		if len(data) >= 2 {
+			sessionID, _ = data[1].(string)
+		}
+		var opts ReviewOptions
+		if len(data) >= 3 {
+			m, _ := data[2].(map[string]interface{})
+			opts = ReviewOptions{AgentCommand: mapStr(m, "agentCommand"), Model: mapStr(m, "model"), ReadOnly: m["readOnly"] == true}
+		}
+		a.SubmitReview(sessionID, parseReviewComments(commentsRaw), opts)
 	}
 }
 
 func (a *App) shutdown(ctx context.Context) {
+	a.cancelAllReviews()
 	if a.mcpServer != nil {
 		a.mcpServer.Stop()
 	}
 	if a.ptyManager != nil {
 		a.ptyManager.StopAll()
 	}
-	a.sessionMu.Lock()
-	for _, s := range a.sessions {
+	// Close can block on the agent, so don't hold sessionMu for it
+	a.forEachSession(func(s *SessionState) {
 		if s.Session != nil {
 			s.Session.Close()
 		}
+	})
+	a.sessionMu.Lock()
+	for _, s := range a.sessions {
 		if s.EventChan != nil {
 			close(s.EventChan)
 		}
 	}
 	a.sessionMu.Unlock()
+	a.events.Close()
 }
 
 func (a *App) SetMode(modeID string) error {
@@ -355,10 +880,160 @@ func (a *App) GetCurrentMode() string {
 	return ""
 }
 
-type ReviewComment struct{ ID, Type, FilePath, Text string; LineNumber, HunkIndex int }
+// Authenticate logs sessionID's ACP agent in and finishes creating the
+// session; an empty sessionID means the active session
+func (a *App) Authenticate(sessionID, methodID string) error {
+	state := a.stateFor(sessionID)
+	if state == nil {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	client, ok := state.Session.(*acp.Client)
+	if !ok {
+		return fmt.Errorf("session does not support authentication")
+	}
+	if err := client.Authenticate(methodID, nil); err != nil {
+		return err
+	}
+	if modes := client.AvailableModes(); len(modes) > 0 {
+		eventPrefix := fmt.Sprintf("session:%s:", state.ID)
+		a.emitter.Emit(eventPrefix+"modes_available", modes)
+		a.emitter.Emit(eventPrefix+"mode_changed", client.CurrentMode())
+	}
+	return nil
+}
 
-func (a *App) SubmitReview(comments []ReviewComment) {
+// SetAPIKey replaces the Anthropic API key for subsequent requests, e.g. after
+// an auth_required event, without restarting running sessions
+func (a *App) SetAPIKey(key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return fmt.Errorf("API key is required")
+	}
+	setter, ok := a.backend.(interface{ SetAPIKey(string) })
+	if !ok {
+		return fmt.Errorf("backend does not support changing the API key")
+	}
+	setter.SetAPIKey(key)
+	return nil
+}
+
+// GetToolStates returns a session's tool calls parent-first, or nil when the
+// session cannot report them
+func (a *App) GetToolStates(sessionID string) ([]*backend.ToolState, error) {
+	a.sessionMu.RLock()
+	state := a.sessions[sessionID]
+	a.sessionMu.RUnlock()
+	if state == nil || state.Session == nil {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	snapshotter, ok := state.Session.(backend.Snapshotter)
+	if !ok {
+		return nil, nil
+	}
+	return snapshotter.Snapshot().Tools, nil
+}
+
+// GetToolStats returns execution metrics for the direct API tools
+func (a *App) GetToolStats() map[string]tools.ToolStat {
+	if a.toolReg == nil {
+		return nil
+	}
+	return a.toolReg.Stats()
+}
+
+// GetFileDiff returns the active session's change to path, with hunks and
+// stats from its original to current content
+func (a *App) GetFileDiff(path string) (*backend.FileChange, error) {
 	state := a.getActiveState()
+	if state == nil || state.Session == nil {
+		return nil, fmt.Errorf("no active session")
+	}
+	store := state.Session.FileChangeStore()
+	if store == nil {
+		return nil, fmt.Errorf("file not tracked: %s", path)
+	}
+	diff := store.Diff(path)
+	if diff == nil {
+		return nil, fmt.Errorf("file not tracked: %s", path)
+	}
+	return diff, nil
+}
+
+// ReadFilePage returns one page of a file's numbered lines so the UI can
+// page through huge files without loading them whole
+func (a *App) ReadFilePage(path string, page, pageSize int) (tools.FilePage, error) {
+	return tools.ReadPage(a.ctx, path, page, pageSize)
+}
+
+// CompactHistory summarizes older turns of the active direct API session
+func (a *App) CompactHistory() error {
+	state := a.getActiveState()
+	if state == nil {
+		return fmt.Errorf("no active session")
+	}
+	session, ok := state.Session.(*anthropic.AnthropicSession)
+	if !ok {
+		return fmt.Errorf("session does not support compaction")
+	}
+	return session.Compact(a.ctx)
+}
+
+func (a *App) SetModel(model string) error {
+	if sess := a.getActiveSession(); sess != nil {
+		return sess.SetModel(model)
+	}
+	return fmt.Errorf("no active session")
+}
+
+func (a *App) GetModel() string {
+	if sess := a.getActiveSession(); sess != nil {
+		return sess.GetModel()
+	}
+	return ""
+}
+
+type ReviewComment struct {
+	ID, Type, FilePath, Text string
+	LineNumber, HunkIndex    int
+	Severity                 string // info, warn or blocker; empty means info
+	Category                 string // bug, style, perf or security; optional
+}
+
+// severityRank orders blockers first, then warnings, then everything else
+func severityRank(severity string) int {
+	switch severity {
+	case "blocker":
+		return 0
+	case "warn":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ReviewOptions override how the review agent runs; empty fields fall back
+// to CCUI_REVIEW_AGENT and CCUI_REVIEW_MODEL, then to the backend defaults
+type ReviewOptions struct {
+	AgentCommand string `json:"agentCommand,omitempty"` // ACP agent binary
+	Model        string `json:"model,omitempty"`
+	ReadOnly     bool   `json:"readOnly,omitempty"` // reject tool calls needing permission instead of auto-approving
+}
+
+// withEnvDefaults fills empty fields from the environment
+func (o ReviewOptions) withEnvDefaults() ReviewOptions {
+	if o.AgentCommand == "" {
+		o.AgentCommand = os.Getenv("CCUI_REVIEW_AGENT")
+	}
+	if o.Model == "" {
+		o.Model = os.Getenv("CCUI_REVIEW_MODEL")
+	}
+	return o
+}
+
+// SubmitReview runs a review agent over sessionID's file changes and the
+// comments; an empty sessionID reviews the active session
+func (a *App) SubmitReview(sessionID string, comments []ReviewComment, opts ReviewOptions) {
+	state := a.stateFor(sessionID)
 	if state == nil || state.Session == nil {
 		return
 	}
@@ -371,37 +1046,106 @@ func (a *App) SubmitReview(comments []ReviewComment) {
 		return
 	}
 	eventPrefix := fmt.Sprintf("session:%s:", state.ID)
+	opts = opts.withEnvDefaults()
+	ctx, run := a.startReview(state.ID)
 	go func() {
-		wailsRuntime.EventsEmit(a.ctx, eventPrefix+"review_agent_running", true)
+		defer func() {
+			a.finishReview(state.ID, run)
+			a.emitter.Emit(eventPrefix+"review_agent_complete", nil)
+		}()
+		a.emitter.Emit(eventPrefix+"review_agent_running", opts)
 		prompt := buildReviewPrompt(changes, comments)
-		cwd, _ := os.Getwd()
+		cwd := state.CWD
+		if cwd == "" {
+			cwd, _ = os.Getwd()
+		}
 		reviewEventChan := make(chan backend.Event, 100)
 
 		// Create review session with auto-permission and shared file store
-		reviewSession, err := a.backend.NewSession(a.ctx, backend.SessionOpts{
+		reviewSession, err := a.backend.NewSession(ctx, backend.SessionOpts{
 			CWD:                cwd,
 			MCPServers:         []any{},
 			EventChan:          reviewEventChan,
-			AutoPermission:     true,
+			AutoPermission:     !opts.ReadOnly,
+			ReadOnly:           opts.ReadOnly,
 			SuppressToolEvents: true,
 			FileChangeStore:    fileStore,
+			AgentCommand:       opts.AgentCommand,
+			Model:              opts.Model,
 		})
 		if err != nil {
-			wailsRuntime.EventsEmit(a.ctx, eventPrefix+"review_agent_chunk", "Error: "+err.Error())
-			wailsRuntime.EventsEmit(a.ctx, eventPrefix+"review_agent_complete", nil)
+			a.emitter.Emit(eventPrefix+"review_agent_chunk", "Error: "+err.Error())
 			close(reviewEventChan)
 			return
 		}
 
-		go a.bridgeEvents(eventPrefix, reviewEventChan, "review_agent_chunk")
-		if err := reviewSession.SendPrompt(prompt, []string{}); err != nil {
-			wailsRuntime.EventsEmit(a.ctx, eventPrefix+"review_agent_chunk", "\nError: "+err.Error())
+		go a.bridgeEvents(eventPrefix, reviewEventChan, "review_agent_chunk", "review_agent_thought")
+		stop := context.AfterFunc(ctx, func() {
+			reviewSession.Cancel()
+			reviewSession.Close()
+		})
+		err = reviewSession.SendPrompt(prompt, []string{})
+		stop()
+		switch {
+		case ctx.Err() != nil:
+			a.emitter.Emit(eventPrefix+"review_agent_chunk", "\nReview cancelled")
+		case err != nil:
+			a.emitter.Emit(eventPrefix+"review_agent_chunk", "\nError: "+err.Error())
 		}
-		wailsRuntime.EventsEmit(a.ctx, eventPrefix+"review_agent_complete", nil)
 		go func() { reviewSession.Close(); close(reviewEventChan) }()
 	}()
 }
 
+// reviewRun is an in-flight review agent
+type reviewRun struct {
+	cancel context.CancelFunc
+}
+
+// startReview registers a cancellable review for sessionID, cancelling any
+// review already running for it
+func (a *App) startReview(sessionID string) (context.Context, *reviewRun) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	run := &reviewRun{cancel: cancel}
+	a.reviewMu.Lock()
+	if prev := a.reviews[sessionID]; prev != nil {
+		prev.cancel()
+	}
+	a.reviews[sessionID] = run
+	a.reviewMu.Unlock()
+	return ctx, run
+}
+
+// finishReview releases run, leaving a newer review for the session alone
+func (a *App) finishReview(sessionID string, run *reviewRun) {
+	run.cancel()
+	a.reviewMu.Lock()
+	if a.reviews[sessionID] == run {
+		delete(a.reviews, sessionID)
+	}
+	a.reviewMu.Unlock()
+}
+
+// CancelReview stops sessionID's running review agent
+func (a *App) CancelReview(sessionID string) error {
+	a.reviewMu.Lock()
+	run := a.reviews[sessionID]
+	a.reviewMu.Unlock()
+	if run == nil {
+		return fmt.Errorf("no review running for session: %s", sessionID)
+	}
+	run.cancel()
+	return nil
+}
+
+// cancelAllReviews stops every running review agent
+func (a *App) cancelAllReviews() {
+	a.reviewMu.Lock()
+	defer a.reviewMu.Unlock()
+	for _, run := range a.reviews {
+		run.cancel()
+	}
+}
+
 func buildReviewPrompt(changes []backend.FileChange, comments []ReviewComment) string {
 	var b strings.Builder
 	b.WriteString("Review feedback for recent changes:\n\n")
@@ -416,16 +1160,32 @@ func buildReviewPrompt(changes []backend.FileChange, comments []ReviewComment) s
 		b.WriteString("```\n\n")
 	}
 	b.WriteString("## Review Comments:\n")
-	for _, c := range comments {
+	sorted := slices.Clone(comments)
+	slices.SortStableFunc(sorted, func(x, y ReviewComment) int {
+		return severityRank(x.Severity) - severityRank(y.Severity)
+	})
+	prioritized := false
+	for _, c := range sorted {
+		b.WriteString("- ")
+		if c.Severity == "blocker" || c.Severity == "warn" {
+			fmt.Fprintf(&b, "[%s] ", strings.ToUpper(c.Severity))
+			prioritized = true
+		}
+		if c.Category != "" {
+			fmt.Fprintf(&b, "(%s) ", c.Category)
+		}
 		switch c.Type {
 		case "line":
-			fmt.Fprintf(&b, "- [%s:%d] %s\n", c.FilePath, c.LineNumber, c.Text)
+			fmt.Fprintf(&b, "[%s:%d] %s\n", c.FilePath, c.LineNumber, c.Text)
 		case "hunk":
-			fmt.Fprintf(&b, "- [%s hunk %d] %s\n", c.FilePath, c.HunkIndex+1, c.Text)
+			fmt.Fprintf(&b, "[%s hunk %d] %s\n", c.FilePath, c.HunkIndex+1, c.Text)
 		default:
-			fmt.Fprintf(&b, "- [General] %s\n", c.Text)
+			fmt.Fprintf(&b, "[General] %s\n", c.Text)
 		}
 	}
+	if prioritized {
+		b.WriteString("\nComments are ordered by severity. Resolve every BLOCKER first, then WARN items, then the rest.")
+	}
 	b.WriteString("\nPlease address this feedback by making the necessary changes.")
 	return b.String()
 }
@@ -436,6 +1196,7 @@ func parseReviewComments(raw []interface{}) (comments []ReviewComment) {
 			comments = append(comments, ReviewComment{
 				ID: mapStr(m, "id"), Type: mapStr(m, "type"), Text: mapStr(m, "text"),
 				FilePath: mapStr(m, "filePath"), LineNumber: mapInt(m, "lineNumber"), HunkIndex: mapInt(m, "hunkIndex"),
+				Severity: mapStr(m, "severity"), Category: mapStr(m, "category"),
 			})
 		}
 	}
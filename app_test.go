@@ -1,8 +1,19 @@
 package main
 
 import (
+	"ccui/backend"
 	"ccui/backend/acp"
+	"ccui/backend/anthropic"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNormalizeToolName(t *testing.T) {
@@ -21,4 +32,927 @@ func TestNormalizeToolName(t *testing.T) {
 	}
 }
 
-// Note: parseUnifiedDiff and buildHunksFromTexts tests moved to backend/acp package
+// Note: ParseUnifiedDiff and BuildHunks tests live in the backend package
+
+// stubSession is a backend.Session backed only by a file change store
+type stubSession struct {
+	store *backend.FileChangeStore
+}
+
+func (s *stubSession) SendPrompt(text string, allowedTools []string) error { return nil }
+func (s *stubSession) SetMode(modeID string) error                         { return nil }
+func (s *stubSession) SetModel(model string) error                         { return nil }
+func (s *stubSession) Cancel()                                             {}
+func (s *stubSession) Close() error                                        { return nil }
+func (s *stubSession) SessionID() string                                   { return "stub" }
+func (s *stubSession) CurrentMode() string                                 { return "" }
+func (s *stubSession) GetModel() string                                    { return "" }
+func (s *stubSession) AvailableModes() []backend.SessionMode               { return nil }
+func (s *stubSession) FileChangeStore() *backend.FileChangeStore           { return s.store }
+
+func TestApp_GetFileDiff(t *testing.T) {
+	// given: an active session that edited a file twice
+	store := backend.NewFileChangeStore()
+	store.RecordChange("/tmp/main.go", "a\nb\nc\n", "a\nB\nc\n", backend.BuildHunks("a\nb\nc\n", "a\nB\nc\n"))
+	store.RecordChange("/tmp/main.go", "a\nB\nc\n", "a\nB\nB2\nc\n", backend.BuildHunks("a\nB\nc\n", "a\nB\nB2\nc\n"))
+	app := NewApp()
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: &stubSession{store: store}}
+	app.activeSessionID = "s1"
+
+	// when
+	diff, err := app.GetFileDiff("/tmp/main.go")
+
+	// then: before/after span both edits
+	if err != nil {
+		t.Fatalf("GetFileDiff: %v", err)
+	}
+	if diff.OriginalContent != "a\nb\nc\n" || diff.CurrentContent != "a\nB\nB2\nc\n" {
+		t.Errorf("unexpected contents: %+v", diff)
+	}
+	if diff.Stats == nil || diff.Stats.Additions != 2 || diff.Stats.Deletions != 1 {
+		t.Errorf("expected +2 -1, got %+v", diff.Stats)
+	}
+	if len(diff.Hunks) != 1 || diff.Hunks[0].OldStart != 1 {
+		t.Errorf("expected one hunk from the original, got %+v", diff.Hunks)
+	}
+
+	// when: the file was never touched
+	_, err = app.GetFileDiff("/tmp/other.go")
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "not tracked") {
+		t.Errorf("expected not tracked error, got %v", err)
+	}
+}
+
+// snapshotStub adds a fixed snapshot to stubSession
+type snapshotStub struct {
+	stubSession
+	snap backend.SessionSnapshot
+}
+
+func (s *snapshotStub) Snapshot() backend.SessionSnapshot { return s.snap }
+
+// recordingEmitter captures emitted events in order
+type recordingEmitter struct {
+	names []string
+	data  []any
+}
+
+func (e *recordingEmitter) Emit(eventName string, data any) {
+	e.names = append(e.names, eventName)
+	e.data = append(e.data, data)
+}
+
+func TestApp_BridgeEvents_ChunkAndThoughtNames(t *testing.T) {
+	// given: a review-style bridge with its own chunk and thought names
+	emitter := &recordingEmitter{}
+	app := NewApp()
+	app.emitter = emitter
+	events := make(chan backend.Event, 2)
+	events <- backend.Event{Type: backend.EventMessageChunk, Data: "said"}
+	events <- backend.Event{Type: backend.EventThoughtChunk, Data: "thought"}
+	close(events)
+
+	// when
+	app.bridgeEvents("session:s1:", events, "review_agent_chunk", "review_agent_thought")
+
+	// then: neither lands in the main chat's events
+	want := []string{"session:s1:review_agent_chunk", "session:s1:review_agent_thought"}
+	if strings.Join(emitter.names, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, emitter.names)
+	}
+}
+
+func TestApp_SnapshotSession(t *testing.T) {
+	// given: a session with two tools, a plan and a changed file
+	store := backend.NewFileChangeStore()
+	store.RecordChange("/tmp/main.go", "a\n", "b\n", backend.BuildHunks("a\n", "b\n"))
+	sess := &snapshotStub{
+		stubSession: stubSession{store: store},
+		snap: backend.SessionSnapshot{
+			Tools: []*backend.ToolState{
+				{ID: "t1", Status: "completed", ToolName: "Task"},
+				{ID: "t2", Status: "running", ToolName: "Read", ParentID: "t1"},
+			},
+			Plan: []backend.PlanEntry{{Content: "write tests", Status: "pending"}},
+		},
+	}
+	emitter := &recordingEmitter{}
+	app := NewApp()
+	app.emitter = emitter
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: sess}
+
+	// when
+	if err := app.SnapshotSession("s1"); err != nil {
+		t.Fatalf("SnapshotSession: %v", err)
+	}
+
+	// then: stored tool states are re-emitted in order under the session prefix
+	want := []string{
+		"session:s1:tool_state",
+		"session:s1:tool_state",
+		"session:s1:plan_update",
+		"session:s1:file_changes_updated",
+	}
+	if strings.Join(emitter.names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, emitter.names)
+	}
+	if tool, _ := emitter.data[1].(*backend.ToolState); tool == nil || tool.ID != "t2" || tool.ParentID != "t1" {
+		t.Errorf("expected tool t2 under t1, got %+v", emitter.data[1])
+	}
+	if changes, _ := emitter.data[3].([]backend.FileChange); len(changes) != 1 {
+		t.Errorf("expected one file change, got %+v", emitter.data[3])
+	}
+
+	// when: the session does not exist
+	err := app.SnapshotSession("missing")
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// recordingBackend hands out stub sessions and remembers their options
+type recordingBackend struct {
+	opts []backend.SessionOpts
+}
+
+func (b *recordingBackend) NewSession(ctx context.Context, opts backend.SessionOpts) (backend.Session, error) {
+	b.opts = append(b.opts, opts)
+	return &stubSession{store: backend.NewFileChangeStore()}, nil
+}
+
+func TestApp_CreateSession_CWD(t *testing.T) {
+	// given: two project directories
+	projectA, projectB := t.TempDir(), t.TempDir()
+	be := &recordingBackend{}
+	app := NewApp()
+	app.backend = be
+	app.emitter = &recordingEmitter{}
+
+	// when
+	idA, errA := app.CreateSession("a", projectA)
+	idB, errB := app.CreateSession("b", projectB)
+	_, errDefault := app.CreateSession("default", "")
+
+	// then: each agent starts in its own directory, empty uses ours
+	if errA != nil || errB != nil || errDefault != nil {
+		t.Fatalf("CreateSession: %v, %v, %v", errA, errB, errDefault)
+	}
+	wd, _ := os.Getwd()
+	want := []string{projectA, projectB, wd}
+	for i, opts := range be.opts {
+		if opts.CWD != want[i] {
+			t.Errorf("session %d: expected cwd %s, got %s", i, want[i], opts.CWD)
+		}
+	}
+	if app.sessions[idA].CWD != projectA || app.sessions[idB].CWD != projectB {
+		t.Errorf("session state lost its cwd")
+	}
+
+	// when: the directory is missing or a file
+	file := filepath.Join(projectA, "file.txt")
+	os.WriteFile(file, []byte("x"), 0644)
+	_, errMissing := app.CreateSession("missing", filepath.Join(projectA, "nope"))
+	_, errFile := app.CreateSession("file", file)
+
+	// then: clear errors and no agent started
+	if errMissing == nil || !strings.Contains(errMissing.Error(), "session directory") {
+		t.Errorf("expected session directory error, got %v", errMissing)
+	}
+	if errFile == nil || !strings.Contains(errFile.Error(), "not a directory") {
+		t.Errorf("expected not a directory error, got %v", errFile)
+	}
+	if len(be.opts) != 3 {
+		t.Errorf("expected 3 sessions started, got %d", len(be.opts))
+	}
+}
+
+// exitingSession is a stub whose Close blocks until its agent exits
+type exitingSession struct {
+	stubSession
+	exited chan struct{}
+}
+
+func (s *exitingSession) Close() error {
+	<-s.exited
+	return nil
+}
+
+// exitingBackend hands out exitingSessions sharing one exit signal
+type exitingBackend struct {
+	exited chan struct{}
+}
+
+func (b *exitingBackend) NewSession(ctx context.Context, opts backend.SessionOpts) (backend.Session, error) {
+	return &exitingSession{exited: b.exited}, nil
+}
+
+func TestApp_CreateSession_Limit(t *testing.T) {
+	// given: a cap of one session, already in use
+	be := &exitingBackend{exited: make(chan struct{})}
+	app := NewApp()
+	app.backend = be
+	app.emitter = &recordingEmitter{}
+	app.maxSessions = 1
+	id, err := app.CreateSession("first", "")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// when
+	_, err = app.CreateSession("second", "")
+
+	// then: a clear limit error
+	if !errors.Is(err, ErrSessionLimit) {
+		t.Fatalf("expected ErrSessionLimit, got %v", err)
+	}
+
+	// when: the first is closed but its agent has not exited
+	if err := app.CloseSession(id); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+
+	// then: the slot is still held
+	if _, err := app.CreateSession("second", ""); !errors.Is(err, ErrSessionLimit) {
+		t.Fatalf("expected slot held until agent exits, got %v", err)
+	}
+
+	// when: the agent exits
+	close(be.exited)
+
+	// then: the slot frees
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, err := app.CreateSession("second", "")
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrSessionLimit) || time.Now().After(deadline) {
+			t.Fatalf("expected slot freed after close, got %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestApp_SessionLifecycleEvents(t *testing.T) {
+	// given: a headless app set up without the Wails runtime
+	emitter := &recordingEmitter{}
+	app := NewAppWithEmitter(emitter)
+	ctx := context.Background()
+	app.setup(ctx)
+	defer app.shutdown(ctx)
+	if app.emitter != emitter {
+		t.Fatal("setup replaced the injected emitter")
+	}
+	app.backend = &recordingBackend{}
+
+	// when: two sessions are created, one switched to, then closed
+	first, err := app.CreateSession("first", "")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	second, err := app.CreateSession("second", "")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	app.SwitchSession(first)
+	app.CloseSession(first)
+
+	// then: every lifecycle change reaches the emitter in order
+	want := []struct {
+		name string
+		data any
+	}{
+		{"sessions_updated", nil}, {"active_session_changed", first},
+		{"sessions_updated", nil}, {"active_session_changed", second},
+		{"active_session_changed", first},
+		{"sessions_updated", nil}, {"active_session_changed", second},
+	}
+	if len(emitter.names) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), emitter.names)
+	}
+	for i, w := range want {
+		if emitter.names[i] != w.name {
+			t.Errorf("event %d: expected %s, got %s", i, w.name, emitter.names[i])
+		}
+		if w.data != nil && emitter.data[i] != w.data {
+			t.Errorf("event %d: expected %v, got %v", i, w.data, emitter.data[i])
+		}
+	}
+	if sessions := emitter.data[len(want)-2].([]SessionInfo); len(sessions) != 1 || sessions[0].ID != second {
+		t.Errorf("expected only %s after close, got %+v", second, sessions)
+	}
+}
+
+// reentrantEmitter reads App state from inside Emit, as a frontend
+// callback would
+type reentrantEmitter struct {
+	app    *App
+	active []string
+}
+
+func (e *reentrantEmitter) Emit(eventName string, data any) {
+	e.app.GetSessions()
+	if eventName == "active_session_changed" {
+		e.active = append(e.active, e.app.GetActiveSession())
+	}
+}
+
+func TestApp_CloseSession_ActiveAmongSeveral(t *testing.T) {
+	// given: three sessions, the newest active
+	app := NewApp()
+	emitter := &reentrantEmitter{app: app}
+	app.emitter = emitter
+	now := time.Now()
+	for i, id := range []string{"s1", "s2", "s3"} {
+		app.sessions[id] = &SessionState{
+			ID:        id,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+			Session:   &stubSession{},
+			EventChan: make(chan backend.Event),
+		}
+	}
+	app.activeSessionID = "s3"
+
+	// when: the active session is closed
+	done := make(chan error, 1)
+	go func() { done <- app.CloseSession("s3") }()
+
+	// then: no deadlock, and the newest remaining session is active
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("CloseSession: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("CloseSession deadlocked emitting under the session lock")
+	}
+	if got := app.GetActiveSession(); got != "s2" {
+		t.Errorf("expected s2 active, got %q", got)
+	}
+	if len(emitter.active) != 1 || emitter.active[0] != "s2" {
+		t.Errorf("expected active_session_changed to s2, got %v", emitter.active)
+	}
+
+	// when: a non-active session is closed, then the rest
+	if err := app.CloseSession("s1"); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	if got := app.GetActiveSession(); got != "s2" {
+		t.Errorf("closing an inactive session changed active to %q", got)
+	}
+	if err := app.CloseSession("s2"); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+
+	// then: nothing is active and unknown sessions error
+	if got := app.GetActiveSession(); got != "" {
+		t.Errorf("expected no active session, got %q", got)
+	}
+	if err := app.CloseSession("s2"); err == nil {
+		t.Error("expected error closing a closed session")
+	}
+}
+
+// permissionTransport is an acp.Transport that reports permission replies
+type permissionTransport struct {
+	handler func(method string, params json.RawMessage, id *int)
+	replies chan string
+}
+
+func (p *permissionTransport) Send(method string, params any) (json.RawMessage, error) {
+	return nil, nil
+}
+func (p *permissionTransport) Notify(method string, params any)        {}
+func (p *permissionTransport) RespondError(id *int, err *acp.RPCError) {}
+func (p *permissionTransport) Close() error                            { return nil }
+func (p *permissionTransport) OnMethod(handler func(method string, params json.RawMessage, id *int)) {
+	p.handler = handler
+}
+func (p *permissionTransport) Respond(id *int, result json.RawMessage) {
+	var resp acp.PermissionResponse
+	json.Unmarshal(result, &resp)
+	p.replies <- resp.Outcome.OptionID
+}
+
+func TestApp_HandlePermissionResponse_RoutesBySession(t *testing.T) {
+	// given: two ACP sessions, the background one waiting on a prompt
+	app := NewApp()
+	transports := map[string]*permissionTransport{}
+	for _, id := range []string{"fg", "bg"} {
+		transports[id] = &permissionTransport{replies: make(chan string, 1)}
+		client := acp.NewClient(acp.ClientConfig{Transport: transports[id]})
+		app.sessions[id] = &SessionState{ID: id, Session: client}
+	}
+	app.activeSessionID = "fg"
+	params, _ := json.Marshal(acp.PermissionRequest{
+		ToolCall: acp.ToolCallInfo{ToolCallID: "tool-1", Title: "Write", Kind: "edit"},
+		Options:  []backend.PermOption{{OptionID: "allow", Kind: "allow_once"}},
+	})
+	reqID := 7
+	go transports["bg"].handler("session/request_permission", params, &reqID)
+
+	// when: the user answers the background session's prompt
+	app.handlePermissionResponse("allow", map[string]interface{}{"toolCallId": "tool-1", "sessionId": "bg"})
+
+	// then: the background agent gets the reply, the active one nothing
+	select {
+	case got := <-transports["bg"].replies:
+		if got != "allow" {
+			t.Errorf("expected allow, got %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("background session never received the permission response")
+	}
+	select {
+	case got := <-transports["fg"].replies:
+		t.Errorf("active session received %q", got)
+	default:
+	}
+}
+
+// sendRecorder records the methods sent to an agent
+type sendRecorder struct {
+	permissionTransport
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *sendRecorder) Send(method string, params any) (json.RawMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, method)
+	return nil, nil
+}
+
+func TestApp_Authenticate_TargetsSession(t *testing.T) {
+	// given: two ACP sessions, the other one active
+	app := NewApp()
+	app.emitter = &recordingEmitter{}
+	transports := map[string]*sendRecorder{}
+	for _, id := range []string{"fg", "bg"} {
+		transports[id] = &sendRecorder{}
+		app.sessions[id] = &SessionState{ID: id, Session: acp.NewClient(acp.ClientConfig{Transport: transports[id]})}
+	}
+	app.activeSessionID = "fg"
+
+	// when: the user answers the background session's auth prompt
+	if err := app.Authenticate("bg", "oauth"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// then: only the background agent was authenticated
+	if got := transports["bg"].sent; len(got) != 1 || got[0] != "authenticate" {
+		t.Errorf("expected bg to authenticate, sent %v", got)
+	}
+	if got := transports["fg"].sent; len(got) != 0 {
+		t.Errorf("active session should not be contacted, sent %v", got)
+	}
+	if err := app.Authenticate("missing", "oauth"); err == nil {
+		t.Error("expected an error for an unknown session")
+	}
+}
+
+// promptRecorder blocks each prompt until released and records overlap
+type promptRecorder struct {
+	stubSession
+	mu      sync.Mutex
+	prompts []string
+	active  int
+	overlap bool
+	release chan struct{}
+	started chan string
+}
+
+func (p *promptRecorder) SendPrompt(text string, allowedTools []string) error {
+	p.mu.Lock()
+	p.active++
+	p.overlap = p.overlap || p.active > 1
+	p.prompts = append(p.prompts, text)
+	p.mu.Unlock()
+	p.started <- text
+	<-p.release
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	return nil
+}
+
+func TestApp_SendMessage_QueuesDuringTurn(t *testing.T) {
+	// given: an active session whose first turn is still running
+	app := NewApp()
+	events := eventWaiter(make(chan string, 10))
+	app.emitter = events
+	sess := &promptRecorder{release: make(chan struct{}), started: make(chan string, 2)}
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: sess}
+	app.activeSessionID = "s1"
+	app.handleSendMessage("first")
+	if got := <-sess.started; got != "first" {
+		t.Fatalf("expected first prompt to start, got %q", got)
+	}
+
+	// when: a second message arrives mid-turn
+	app.handleSendMessage("second")
+
+	// then: it is queued, and sent only after the first turn ends
+	events.waitFor(t, "session:s1:queued")
+	select {
+	case got := <-sess.started:
+		t.Fatalf("%q started while the first turn was running", got)
+	default:
+	}
+	sess.release <- struct{}{}
+	if got := <-sess.started; got != "second" {
+		t.Fatalf("expected second prompt next, got %q", got)
+	}
+	sess.release <- struct{}{}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.overlap {
+		t.Error("prompts ran concurrently")
+	}
+	if len(sess.prompts) != 2 || sess.prompts[0] != "first" || sess.prompts[1] != "second" {
+		t.Errorf("expected prompts in order, got %v", sess.prompts)
+	}
+}
+
+// busyEvents records session busy events
+type busyEvents struct {
+	mu     sync.Mutex
+	states []bool
+}
+
+func (b *busyEvents) Emit(eventName string, data any) {
+	if eventName == "session:s1:busy" {
+		b.mu.Lock()
+		b.states = append(b.states, data.(bool))
+		b.mu.Unlock()
+	}
+}
+
+func (b *busyEvents) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.states)
+}
+
+func TestApp_SessionBusyDuringPrompt(t *testing.T) {
+	// given: an idle session
+	app := NewApp()
+	events := &busyEvents{}
+	app.emitter = events
+	sess := &promptRecorder{release: make(chan struct{}), started: make(chan string, 1)}
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: sess}
+	app.activeSessionID = "s1"
+	if app.GetSessions()[0].Busy {
+		t.Fatal("expected idle session before prompting")
+	}
+
+	// when: a prompt is running
+	app.handleSendMessage("hello")
+	<-sess.started
+
+	// then: the session reports busy until the prompt returns
+	if !app.GetSessions()[0].Busy {
+		t.Error("expected busy during the prompt")
+	}
+	sess.release <- struct{}{}
+	deadline := time.Now().Add(2 * time.Second)
+	for events.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if app.GetSessions()[0].Busy {
+		t.Error("expected idle after the prompt")
+	}
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if len(events.states) != 2 || !events.states[0] || events.states[1] {
+		t.Errorf("expected busy true then false, got %v", events.states)
+	}
+}
+
+// eventWaiter forwards emitted event names to a channel
+type eventWaiter chan string
+
+func (w eventWaiter) Emit(eventName string, data any) { w <- eventName }
+
+// waitFor blocks until name is emitted
+func (w eventWaiter) waitFor(t *testing.T, name string) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case got := <-w:
+			if got == name {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", name)
+		}
+	}
+}
+
+func TestApp_SubmitReview_TargetsSession(t *testing.T) {
+	// given: the active session and a background session with its own edits
+	activeStore, bgStore := backend.NewFileChangeStore(), backend.NewFileChangeStore()
+	activeStore.RecordChange("/tmp/active.go", "a\n", "b\n", nil)
+	bgStore.RecordChange("/tmp/bg.go", "a\n", "b\n", nil)
+	be := &recordingBackend{}
+	events := make(eventWaiter, 100)
+	app := NewApp()
+	app.ctx = context.Background()
+	app.backend = be
+	app.emitter = events
+	app.sessions["active"] = &SessionState{ID: "active", Session: &stubSession{store: activeStore}}
+	app.sessions["bg"] = &SessionState{ID: "bg", CWD: t.TempDir(), Session: &stubSession{store: bgStore}}
+	app.activeSessionID = "active"
+
+	// when: review is submitted for the background session
+	app.SubmitReview("bg", []ReviewComment{{FilePath: "/tmp/bg.go", LineNumber: 1, Text: "rename"}}, ReviewOptions{})
+	events.waitFor(t, "session:bg:review_agent_complete")
+
+	// then: the reviewer used that session's store and directory
+	if len(be.opts) != 1 {
+		t.Fatalf("expected one review session, got %d", len(be.opts))
+	}
+	if be.opts[0].FileChangeStore != bgStore {
+		t.Error("review ran against the wrong file change store")
+	}
+	if be.opts[0].CWD != app.sessions["bg"].CWD {
+		t.Errorf("expected review in %s, got %s", app.sessions["bg"].CWD, be.opts[0].CWD)
+	}
+
+	// when: no session is given
+	app.SubmitReview("", nil, ReviewOptions{})
+	events.waitFor(t, "session:active:review_agent_complete")
+
+	// then: the active session is reviewed
+	if len(be.opts) != 2 || be.opts[1].FileChangeStore != activeStore {
+		t.Error("empty session ID should review the active session")
+	}
+}
+
+func TestApp_SubmitReview_Options(t *testing.T) {
+	// given: a session with changes and a review model in the environment
+	t.Setenv("CCUI_REVIEW_AGENT", "")
+	t.Setenv("CCUI_REVIEW_MODEL", "env-model")
+	store := backend.NewFileChangeStore()
+	store.RecordChange("/tmp/main.go", "a\n", "b\n", nil)
+	be := &recordingBackend{}
+	events := make(eventWaiter, 100)
+	app := NewApp()
+	app.ctx = context.Background()
+	app.backend = be
+	app.emitter = events
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: &stubSession{store: store}}
+
+	// when: review runs with explicit options, then with none
+	app.SubmitReview("s1", nil, ReviewOptions{AgentCommand: "fast-agent", Model: "small-model", ReadOnly: true})
+	events.waitFor(t, "session:s1:review_agent_complete")
+	app.SubmitReview("s1", nil, ReviewOptions{})
+	events.waitFor(t, "session:s1:review_agent_complete")
+
+	// then: the first review client gets the provided config
+	if len(be.opts) != 2 {
+		t.Fatalf("expected two review sessions, got %d", len(be.opts))
+	}
+	got := be.opts[0]
+	if got.AgentCommand != "fast-agent" || got.Model != "small-model" {
+		t.Errorf("expected fast-agent/small-model, got %q/%q", got.AgentCommand, got.Model)
+	}
+	if !got.ReadOnly || got.AutoPermission {
+		t.Errorf("read-only review must not auto-approve: %+v", got)
+	}
+
+	// then: the default review auto-approves and takes the model from the env
+	got = be.opts[1]
+	if got.Model != "env-model" || got.AgentCommand != "" {
+		t.Errorf("expected env-model and default agent, got %q/%q", got.Model, got.AgentCommand)
+	}
+	if got.ReadOnly || !got.AutoPermission {
+		t.Errorf("default review should auto-approve: %+v", got)
+	}
+}
+
+// blockingSession is a review agent that runs until cancelled or closed
+type blockingSession struct {
+	stubSession
+	ctx    context.Context
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (s *blockingSession) SendPrompt(text string, allowedTools []string) error {
+	select {
+	case <-s.ctx.Done():
+	case <-s.closed:
+	}
+	return errors.New("agent terminated")
+}
+
+func (s *blockingSession) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// blockingBackend starts blockingSessions bound to the session context
+type blockingBackend struct {
+	started chan *blockingSession
+}
+
+func (b *blockingBackend) NewSession(ctx context.Context, opts backend.SessionOpts) (backend.Session, error) {
+	sess := &blockingSession{ctx: ctx, closed: make(chan struct{})}
+	b.started <- sess
+	return sess, nil
+}
+
+func TestApp_CancelReview(t *testing.T) {
+	// given: a review agent that never finishes on its own
+	store := backend.NewFileChangeStore()
+	store.RecordChange("/tmp/main.go", "a\n", "b\n", nil)
+	be := &blockingBackend{started: make(chan *blockingSession, 1)}
+	events := make(eventWaiter, 100)
+	app := NewApp()
+	app.ctx = context.Background()
+	app.backend = be
+	app.emitter = events
+	app.sessions["s1"] = &SessionState{ID: "s1", Session: &stubSession{store: store}}
+
+	app.SubmitReview("s1", nil, ReviewOptions{})
+	var agent *blockingSession
+	select {
+	case agent = <-be.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("review agent never started")
+	}
+
+	// when: the review is cancelled mid-run
+	if err := app.CancelReview("s1"); err != nil {
+		t.Fatalf("CancelReview: %v", err)
+	}
+
+	// then: the agent is terminated and the review completes
+	events.waitFor(t, "session:s1:review_agent_complete")
+	select {
+	case <-agent.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("review agent was not closed")
+	}
+	if agent.ctx.Err() == nil {
+		t.Error("review agent context was not cancelled")
+	}
+
+	// then: nothing is left to cancel
+	if err := app.CancelReview("s1"); err == nil {
+		t.Error("expected error cancelling a finished review")
+	}
+}
+
+func TestBuildReviewPrompt_SeverityOrdering(t *testing.T) {
+	// given: comments added in arbitrary severity order
+	comments := parseReviewComments([]interface{}{
+		map[string]interface{}{"type": "general", "text": "nit: wording"},
+		map[string]interface{}{"type": "line", "filePath": "a.go", "lineNumber": float64(3), "text": "slow loop", "severity": "warn", "category": "perf"},
+		map[string]interface{}{"type": "line", "filePath": "a.go", "lineNumber": float64(9), "text": "nil deref", "severity": "blocker", "category": "bug"},
+		map[string]interface{}{"type": "hunk", "filePath": "b.go", "hunkIndex": float64(0), "text": "injection", "severity": "blocker", "category": "security"},
+	})
+
+	// when
+	prompt := buildReviewPrompt(nil, comments)
+
+	// then: blockers first in their original order, then warnings, then the rest
+	order := []string{
+		"- [BLOCKER] (bug) [a.go:9] nil deref",
+		"- [BLOCKER] (security) [b.go hunk 1] injection",
+		"- [WARN] (perf) [a.go:3] slow loop",
+		"- [General] nit: wording",
+	}
+	last := -1
+	for _, line := range order {
+		idx := strings.Index(prompt, line)
+		if idx < 0 {
+			t.Fatalf("prompt missing %q:\n%s", line, prompt)
+		}
+		if idx < last {
+			t.Errorf("%q is out of severity order:\n%s", line, prompt)
+		}
+		last = idx
+	}
+	if !strings.Contains(prompt, "Resolve every BLOCKER first") {
+		t.Errorf("prompt should ask to prioritize blockers:\n%s", prompt)
+	}
+
+	// when: no comment has a severity
+	plain := buildReviewPrompt(nil, []ReviewComment{{Type: "general", Text: "looks good"}})
+
+	// then: the prompt keeps its plain form
+	if strings.Contains(plain, "BLOCKER") || !strings.Contains(plain, "- [General] looks good") {
+		t.Errorf("unexpected plain prompt:\n%s", plain)
+	}
+}
+
+func TestApp_SetAPIKey(t *testing.T) {
+	// given: an app on the direct API backend
+	app := NewApp()
+	be := anthropic.NewAnthropicBackend(anthropic.BackendConfig{APIKey: "old"})
+	app.backend = be
+
+	// when/then: a blank key is refused, a real one accepted
+	if err := app.SetAPIKey("  "); err == nil {
+		t.Error("expected blank key to be rejected")
+	}
+	if err := app.SetAPIKey("new"); err != nil {
+		t.Errorf("SetAPIKey: %v", err)
+	}
+
+	// then: backends without key refresh say so
+	app.backend = &recordingBackend{}
+	if err := app.SetAPIKey("new"); err == nil {
+		t.Error("expected an error for a backend without SetAPIKey")
+	}
+}
+
+// modesTransport is an acp.Transport whose agent offers two modes
+type modesTransport struct {
+	sendRecorder
+}
+
+func (m *modesTransport) Send(method string, params any) (json.RawMessage, error) {
+	m.sendRecorder.Send(method, params)
+	if method != "session/new" {
+		return nil, nil
+	}
+	return json.Marshal(acp.SessionNewResult{SessionID: "acp-1", Modes: &acp.ModesInfo{
+		CurrentModeID:  "default",
+		AvailableModes: []backend.SessionMode{{ID: "default", Name: "Default"}, {ID: "acceptEdits", Name: "Accept Edits"}},
+	}})
+}
+
+func TestApp_ModesThroughSessionInterface(t *testing.T) {
+	// given: an ACP session and a direct API session
+	app := NewApp()
+	transport := &modesTransport{}
+	client := acp.NewClient(acp.ClientConfig{Transport: transport})
+	if err := client.NewSession("/tmp", nil); err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	app.sessions["acp"] = &SessionState{ID: "acp", Session: client}
+	be := anthropic.NewAnthropicBackend(anthropic.BackendConfig{APIKey: "k"})
+	sess, err := be.NewSession(context.Background(), backend.SessionOpts{CWD: "/tmp"})
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer sess.Close()
+	app.sessions["api"] = &SessionState{ID: "api", Session: sess}
+
+	modeIDs := func() []string {
+		var ids []string
+		for _, m := range app.GetModes() {
+			ids = append(ids, m.ID)
+		}
+		return ids
+	}
+
+	// when/then: the ACP session lists the agent's modes and asks it to switch
+	app.activeSessionID = "acp"
+	if got := modeIDs(); !reflect.DeepEqual(got, []string{"default", "acceptEdits"}) {
+		t.Errorf("ACP modes = %v", got)
+	}
+	if err := app.SetMode("acceptEdits"); err != nil {
+		t.Fatalf("SetMode: %v", err)
+	}
+	if got := app.GetCurrentMode(); got != "acceptEdits" {
+		t.Errorf("ACP current mode = %q", got)
+	}
+	if sent := transport.sent; sent[len(sent)-1] != "session/set_mode" {
+		t.Errorf("expected session/set_mode, sent %v", sent)
+	}
+
+	// when/then: the direct API session offers its own modes and rejects others
+	app.activeSessionID = "api"
+	if got := modeIDs(); !reflect.DeepEqual(got, []string{anthropic.ModeDefault, anthropic.ModePlan, anthropic.ModeReadOnly}) {
+		t.Errorf("direct API modes = %v", got)
+	}
+	if got := app.GetCurrentMode(); got != anthropic.ModeDefault {
+		t.Errorf("direct API current mode = %q", got)
+	}
+	if err := app.SetMode(anthropic.ModePlan); err != nil {
+		t.Fatalf("SetMode: %v", err)
+	}
+	if got := app.GetCurrentMode(); got != anthropic.ModePlan {
+		t.Errorf("direct API current mode = %q", got)
+	}
+	if err := app.SetMode("acceptEdits"); err == nil {
+		t.Error("expected an unknown mode to be rejected")
+	}
+
+	// then: switching one session's mode leaves the other's alone
+	app.activeSessionID = "acp"
+	if got := app.GetCurrentMode(); got != "acceptEdits" {
+		t.Errorf("ACP current mode changed to %q", got)
+	}
+}
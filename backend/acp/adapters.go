@@ -1,9 +1,7 @@
 package acp
 
 import (
-	"bufio"
 	"encoding/json"
-	"strconv"
 	"strings"
 
 	"ccui/backend"
@@ -20,9 +18,15 @@ type ToolEventAdapter interface {
 
 // DefaultToolAdapters returns the default set of adapters
 func DefaultToolAdapters() []ToolEventAdapter {
+	return toolAdapters(0)
+}
+
+// toolAdapters returns the default adapters, building fallback hunks with
+// contextLines of context
+func toolAdapters(contextLines int) []ToolEventAdapter {
 	return []ToolEventAdapter{
 		ClaudeCodeAdapter{},
-		OpenCodeAdapter{},
+		OpenCodeAdapter{ContextLines: contextLines},
 	}
 }
 
@@ -56,7 +60,11 @@ func (ClaudeCodeAdapter) ToolResponse(update UpdateContent) *ToolResponse {
 }
 
 // OpenCodeAdapter handles OpenCode tool events
-type OpenCodeAdapter struct{}
+type OpenCodeAdapter struct {
+	// ContextLines is the context around hunks built when the agent sends
+	// none; 0 diffs the whole texts, negative keeps no context
+	ContextLines int
+}
 
 func (OpenCodeAdapter) Name() string {
 	return "opencode"
@@ -74,7 +82,7 @@ func (OpenCodeAdapter) DiffBlocks(update UpdateContent) []backend.DiffBlock {
 	return parseDiffBlocks(update.Content)
 }
 
-func (OpenCodeAdapter) ToolResponse(update UpdateContent) *ToolResponse {
+func (a OpenCodeAdapter) ToolResponse(update UpdateContent) *ToolResponse {
 	diffs := parseDiffBlocks(update.Content)
 	meta := extractOpenCodeMeta(update.RawOutput)
 	primary := firstDiffBlock(diffs)
@@ -110,7 +118,7 @@ func (OpenCodeAdapter) ToolResponse(update UpdateContent) *ToolResponse {
 	}
 	tr.StructuredPatch = meta.hunks
 	if len(tr.StructuredPatch) == 0 {
-		tr.StructuredPatch = buildHunksFromTexts(tr.OriginalFile, tr.Content)
+		tr.StructuredPatch = buildHunksFromTexts(tr.OriginalFile, tr.Content, a.ContextLines)
 	}
 	if tr.Content == "" && toolName == "Write" {
 		tr.Content = newText
@@ -138,7 +146,7 @@ func extractOpenCodeMeta(rawOutput *ToolRawOutput) openCodeMeta {
 	if meta.filePath == "" {
 		meta.filePath = rawOutput.Metadata.Filepath
 	}
-	meta.hunks = parseUnifiedDiff(rawOutput.Metadata.Diff)
+	meta.hunks = backend.ParseUnifiedDiff(rawOutput.Metadata.Diff)
 	return meta
 }
 
@@ -185,7 +193,12 @@ func parseDiffBlocks(content json.RawMessage) []backend.DiffBlock {
 	return diffs
 }
 
-func buildHunksFromTexts(oldText, newText string) []backend.PatchHunk {
+// buildHunksFromTexts diffs oldText against newText with contextLines of
+// context, or replaces one whole text with the other when contextLines is 0
+func buildHunksFromTexts(oldText, newText string, contextLines int) []backend.PatchHunk {
+	if contextLines != 0 {
+		return backend.BuildHunksWithContext(oldText, newText, contextLines)
+	}
 	oldLines := splitLines(oldText)
 	newLines := splitLines(newText)
 	if len(oldLines) == 0 && len(newLines) == 0 {
@@ -207,80 +220,6 @@ func buildHunksFromTexts(oldText, newText string) []backend.PatchHunk {
 	}}
 }
 
-func parseUnifiedDiff(diffText string) []backend.PatchHunk {
-	if diffText == "" {
-		return nil
-	}
-	scanner := bufio.NewScanner(strings.NewReader(diffText))
-	var hunks []backend.PatchHunk
-	var current *backend.PatchHunk
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "@@") {
-			oldStart, oldLines, newStart, newLines, ok := parseHunkHeader(line)
-			if !ok {
-				current = nil
-				continue
-			}
-			hunk := backend.PatchHunk{
-				OldStart: oldStart,
-				OldLines: oldLines,
-				NewStart: newStart,
-				NewLines: newLines,
-			}
-			hunks = append(hunks, hunk)
-			current = &hunks[len(hunks)-1]
-			continue
-		}
-		if current == nil {
-			continue
-		}
-		if strings.HasPrefix(line, "\\") {
-			continue
-		}
-		current.Lines = append(current.Lines, line)
-	}
-	return hunks
-}
-
-func parseHunkHeader(line string) (int, int, int, int, bool) {
-	trimmed := strings.TrimSpace(strings.TrimPrefix(line, "@@"))
-	trimmed = strings.TrimSuffix(trimmed, "@@")
-	trimmed = strings.TrimSpace(trimmed)
-	parts := strings.Split(trimmed, " ")
-	if len(parts) < 2 {
-		return 0, 0, 0, 0, false
-	}
-	oldStart, oldLines, ok := parseRange(strings.TrimPrefix(parts[0], "-"))
-	if !ok {
-		return 0, 0, 0, 0, false
-	}
-	newStart, newLines, ok := parseRange(strings.TrimPrefix(parts[1], "+"))
-	if !ok {
-		return 0, 0, 0, 0, false
-	}
-	return oldStart, oldLines, newStart, newLines, true
-}
-
-func parseRange(part string) (int, int, bool) {
-	if part == "" {
-		return 0, 0, false
-	}
-	pieces := strings.Split(part, ",")
-	start, err := strconv.Atoi(pieces[0])
-	if err != nil {
-		return 0, 0, false
-	}
-	lines := 1
-	if len(pieces) > 1 {
-		lines, err = strconv.Atoi(pieces[1])
-		if err != nil {
-			return 0, 0, false
-		}
-	}
-	return start, lines, true
-}
-
 func splitLines(text string) []string {
 	if text == "" {
 		return nil
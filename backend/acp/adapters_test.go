@@ -1,37 +1,12 @@
 package acp
 
 import (
+	"strings"
 	"testing"
 )
 
-func TestParseUnifiedDiff(t *testing.T) {
-	diffText := "Index: /tmp/hello.md\n" +
-		"===================================================================\n" +
-		"--- /tmp/hello.md\n" +
-		"+++ /tmp/hello.md\n" +
-		"@@ -1,3 +1,5 @@\n" +
-		" # Hello\n" +
-		" \n" +
-		"-This is a simple hello markdown file.\n" +
-		"+This is a simple hello markdown file.\n" +
-		"+\n" +
-		"+Created by: Dan\n" +
-		"\\ No newline at end of file\n"
-	hunks := parseUnifiedDiff(diffText)
-	if len(hunks) != 1 {
-		t.Fatalf("expected 1 hunk, got %d", len(hunks))
-	}
-	hunk := hunks[0]
-	if hunk.OldStart != 1 || hunk.OldLines != 3 || hunk.NewStart != 1 || hunk.NewLines != 5 {
-		t.Fatalf("unexpected hunk header: %+v", hunk)
-	}
-	if len(hunk.Lines) == 0 || hunk.Lines[0] != " # Hello" {
-		t.Fatalf("unexpected hunk lines: %+v", hunk.Lines)
-	}
-}
-
 func TestBuildHunksFromTexts(t *testing.T) {
-	hunks := buildHunksFromTexts("a\nb", "a\nb\nc")
+	hunks := buildHunksFromTexts("a\nb", "a\nb\nc", 0)
 	if len(hunks) != 1 {
 		t.Fatalf("expected 1 hunk, got %d", len(hunks))
 	}
@@ -49,6 +24,24 @@ func TestBuildHunksFromTexts(t *testing.T) {
 	}
 }
 
+func TestOpenCodeAdapter_ContextHunks(t *testing.T) {
+	// given - an adapter configured for 1 line of context
+	adapter := OpenCodeAdapter{ContextLines: 1}
+	update := UpdateContent{Content: []byte(`[{"type":"diff","path":"/x.txt","oldText":"a\nb\nc\nd\n","newText":"a\nb\nC\nd\n"}]`)}
+
+	// when
+	tr := adapter.ToolResponse(update)
+
+	// then - the fallback hunk keeps only the neighbouring lines
+	if tr == nil || len(tr.StructuredPatch) != 1 {
+		t.Fatalf("expected one hunk, got %+v", tr)
+	}
+	expected := []string{" b", "-c", "+C", " d"}
+	if got := tr.StructuredPatch[0].Lines; strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected lines %q", got)
+	}
+}
+
 func TestNormalizeToolName(t *testing.T) {
 	if got := normalizeToolName("write", ""); got != "Write" {
 		t.Fatalf("expected Write, got %q", got)
@@ -0,0 +1,282 @@
+package acp
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ccui/backend"
+)
+
+// agentLogTailLines is how many recent stderr lines are kept for the UI
+const agentLogTailLines = 50
+
+// LogRetention bounds agent log files; zero fields use DefaultLogRetention
+type LogRetention struct {
+	MaxBytes int64         // rotate to a new file past this size
+	MaxFiles int           // keep at most this many of a session's log files
+	MaxAge   time.Duration // delete finished sessions' log files older than this
+}
+
+// DefaultLogRetention keeps up to 20 files of 10MB for two weeks
+var DefaultLogRetention = LogRetention{
+	MaxBytes: 10 << 20,
+	MaxFiles: 20,
+	MaxAge:   14 * 24 * time.Hour,
+}
+
+// withDefaults fills unset limits from DefaultLogRetention
+func (r LogRetention) withDefaults() LogRetention {
+	if r.MaxBytes <= 0 {
+		r.MaxBytes = DefaultLogRetention.MaxBytes
+	}
+	if r.MaxFiles <= 0 {
+		r.MaxFiles = DefaultLogRetention.MaxFiles
+	}
+	if r.MaxAge <= 0 {
+		r.MaxAge = DefaultLogRetention.MaxAge
+	}
+	return r
+}
+
+// AgentLog carries the most recent agent stderr lines
+type AgentLog struct {
+	Path  string   `json:"path,omitempty"` // log file, empty when not persisted
+	Lines []string `json:"lines"`
+}
+
+// agentLog captures agent stderr into a log file and keeps its last lines
+type agentLog struct {
+	mu        sync.Mutex
+	dir       string
+	name      string
+	retention LogRetention
+	seq       int // rotation count; file n>0 is <name>.<n>.log
+	path      string
+	file      *os.File
+	size      int64
+	lines     []string
+	partial   []byte
+	events    chan<- backend.Event
+}
+
+// newAgentLog creates <dir>/<name>.log, pruning old logs in dir; an empty
+// dir keeps the tail in memory only
+func newAgentLog(dir, name string, retention LogRetention, events chan<- backend.Event) (*agentLog, error) {
+	l := &agentLog{dir: dir, name: name, retention: retention.withDefaults(), events: events}
+	if dir == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create agent log dir: %w", err)
+	}
+	if err := l.openLocked(); err != nil {
+		return nil, err
+	}
+	liveAgentLogs.add(name)
+	pruneAgentLogs(dir, name, l.retention, l.path, time.Now())
+	return l, nil
+}
+
+// liveAgentLogs counts the open agentLogs per log name (respawned agents
+// share their session's), so pruning never touches a running session's files
+var liveAgentLogs = &logRegistry{names: make(map[string]int)}
+
+type logRegistry struct {
+	mu    sync.Mutex
+	names map[string]int
+}
+
+func (r *logRegistry) add(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names[name]++
+}
+
+func (r *logRegistry) remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name]--; r.names[name] <= 0 {
+		delete(r.names, name)
+	}
+}
+
+func (r *logRegistry) live(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.names[name] > 0
+}
+
+// openLocked opens the log file for the current rotation
+func (l *agentLog) openLocked() error {
+	name := l.name + ".log"
+	if l.seq > 0 {
+		name = fmt.Sprintf("%s.%d.log", l.name, l.seq)
+	}
+	path := filepath.Join(l.dir, name)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open agent log: %w", err)
+	}
+	l.path, l.file, l.size = path, f, 0
+	return nil
+}
+
+// rotateLocked starts a new file and prunes logs beyond retention; if the
+// new file cannot be opened, logging carries on in the current one
+func (l *agentLog) rotateLocked() error {
+	old, oldPath := l.file, l.path
+	l.seq++
+	if err := l.openLocked(); err != nil {
+		l.seq--
+		// retry after another MaxBytes rather than on every write
+		l.file, l.path, l.size = old, oldPath, 0
+		return err
+	}
+	old.Close()
+	pruneAgentLogs(l.dir, l.name, l.retention, l.path, time.Now())
+	return nil
+}
+
+// Write appends stderr output to the file and emits the updated tail
+func (l *agentLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil && l.size > 0 && l.size+int64(len(p)) > l.retention.MaxBytes {
+		if err := l.rotateLocked(); err != nil {
+			slog.Warn("agent log rotation failed; continuing in the current file", "path", l.path, "error", err)
+		}
+	}
+	if l.file != nil {
+		n, _ := l.file.Write(p)
+		l.size += int64(n)
+	}
+
+	l.partial = append(l.partial, p...)
+	added := false
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.appendLine(string(bytes.TrimRight(l.partial[:i], "\r")))
+		l.partial = l.partial[i+1:]
+		added = true
+	}
+	if added {
+		l.emitLocked()
+	}
+	return len(p), nil
+}
+
+func (l *agentLog) appendLine(line string) {
+	l.lines = append(l.lines, line)
+	if over := len(l.lines) - agentLogTailLines; over > 0 {
+		l.lines = append([]string(nil), l.lines[over:]...)
+	}
+}
+
+// emitLocked sends the tail without blocking the agent's stderr
+func (l *agentLog) emitLocked() {
+	if l.events == nil {
+		return
+	}
+	event := backend.Event{
+		Type: backend.EventAgentLog,
+		Data: AgentLog{Path: l.path, Lines: append([]string(nil), l.lines...)},
+	}
+	select {
+	case l.events <- event:
+	default:
+	}
+}
+
+// Tail returns the recent lines, including any unterminated last line
+func (l *agentLog) Tail() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := l.lines
+	if len(l.partial) > 0 {
+		lines = append(append([]string(nil), lines...), string(l.partial))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Path returns the log file path, or "" when not persisted
+func (l *agentLog) Path() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.path
+}
+
+// Close closes the log file and stops emitting, since the session may close
+// its event channel once the agent has exited
+func (l *agentLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = nil
+	if l.file == nil {
+		return nil
+	}
+	liveAgentLogs.remove(l.name)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// pruneAgentLogs deletes name's rotated logs beyond the newest MaxFiles and
+// finished sessions' logs older than MaxAge. It never touches active or the
+// files of other sessions still open in this process.
+func pruneAgentLogs(dir, name string, retention LogRetention, active string, now time.Time) {
+	paths, _ := filepath.Glob(filepath.Join(dir, "agent-*.log"))
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var own []logFile
+	for _, p := range paths {
+		if p == active {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		switch owner := agentLogOwner(filepath.Base(p)); {
+		case owner == name:
+			own = append(own, logFile{p, info.ModTime()})
+		case liveAgentLogs.live(owner):
+		case now.Sub(info.ModTime()) > retention.MaxAge:
+			os.Remove(p)
+		}
+	}
+
+	// newest first; the active file takes one of the MaxFiles slots
+	sort.Slice(own, func(i, j int) bool { return own[i].modTime.After(own[j].modTime) })
+	for i, f := range own {
+		if i+1 >= retention.MaxFiles || now.Sub(f.modTime) > retention.MaxAge {
+			os.Remove(f.path)
+		}
+	}
+}
+
+// agentLogOwner returns the session log name a file belongs to, stripping
+// ".log" and any rotation sequence: "agent-1.2.log" -> "agent-1"
+func agentLogOwner(base string) string {
+	base = strings.TrimSuffix(base, ".log")
+	if i := strings.LastIndexByte(base, '.'); i >= 0 {
+		if _, err := strconv.Atoi(base[i+1:]); err == nil {
+			return base[:i]
+		}
+	}
+	return base
+}
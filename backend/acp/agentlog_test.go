@@ -0,0 +1,231 @@
+package acp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ccui/backend"
+)
+
+func TestAgentLog_CapturesStderr(t *testing.T) {
+	// given: a log writing to a temp dir
+	dir := t.TempDir()
+	events := make(chan backend.Event, 10)
+	l, err := newAgentLog(dir, "agent-1", LogRetention{}, events)
+	if err != nil {
+		t.Fatalf("newAgentLog: %v", err)
+	}
+
+	// when: stderr arrives in arbitrary chunks
+	fmt.Fprint(l, "starting\r\nloading con")
+	fmt.Fprint(l, "fig\npartial")
+	l.Close()
+
+	// then: the file has everything, the tail splits lines
+	data, err := os.ReadFile(filepath.Join(dir, "agent-1.log"))
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if string(data) != "starting\r\nloading config\npartial" {
+		t.Errorf("unexpected log file: %q", data)
+	}
+	if got := l.Tail(); got != "starting\nloading config\npartial" {
+		t.Errorf("unexpected tail: %q", got)
+	}
+
+	// then: each chunk with complete lines emitted the tail
+	var last AgentLog
+	for len(events) > 0 {
+		ev := <-events
+		if ev.Type != backend.EventAgentLog {
+			t.Fatalf("unexpected event type %s", ev.Type)
+		}
+		last = ev.Data.(AgentLog)
+	}
+	if len(last.Lines) != 2 || last.Lines[1] != "loading config" || last.Path != l.Path() {
+		t.Errorf("unexpected agent_log event: %+v", last)
+	}
+}
+
+func TestAgentLog_NoEventsAfterClose(t *testing.T) {
+	// given: a closed log whose session then closed its event channel
+	events := make(chan backend.Event, 10)
+	l, _ := newAgentLog("", "unused", LogRetention{}, events)
+	l.Close()
+	close(events)
+
+	// when: the exiting agent still writes stderr
+	fmt.Fprint(l, "shutting down\n")
+
+	// then: nothing is sent on the closed channel, the tail still updates
+	if got := l.Tail(); got != "shutting down" {
+		t.Errorf("unexpected tail: %q", got)
+	}
+}
+
+func TestAgentLog_KeepsLastLines(t *testing.T) {
+	l, _ := newAgentLog("", "unused", LogRetention{}, nil)
+
+	for i := 0; i < agentLogTailLines+10; i++ {
+		fmt.Fprintf(l, "line %d\n", i)
+	}
+
+	lines := strings.Split(l.Tail(), "\n")
+	if len(lines) != agentLogTailLines || lines[0] != "line 10" {
+		t.Errorf("expected last %d lines starting at line 10, got %d starting %q", agentLogTailLines, len(lines), lines[0])
+	}
+	if l.Path() != "" {
+		t.Errorf("expected no log file, got %s", l.Path())
+	}
+}
+
+func TestAgentLog_RotatesAndPrunes(t *testing.T) {
+	// given: logs from finished sessions and another session still running
+	dir := t.TempDir()
+	now := time.Now()
+	for i, age := range []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 30 * 24 * time.Hour} {
+		p := filepath.Join(dir, fmt.Sprintf("agent-old%d.log", i))
+		os.WriteFile(p, []byte("old\n"), 0600)
+		os.Chtimes(p, now.Add(-age), now.Add(-age))
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600)
+	live, err := newAgentLog(dir, "agent-live", LogRetention{}, nil)
+	if err != nil {
+		t.Fatalf("newAgentLog: %v", err)
+	}
+	defer live.Close()
+	old := now.Add(-30 * 24 * time.Hour)
+	os.Chtimes(live.Path(), old, old)
+
+	// when: a new log opens with small limits
+	l, err := newAgentLog(dir, "agent-new", LogRetention{MaxBytes: 10, MaxFiles: 2, MaxAge: 24 * time.Hour}, nil)
+	if err != nil {
+		t.Fatalf("newAgentLog: %v", err)
+	}
+	defer l.Close()
+
+	// then: only the finished session's expired log is gone
+	logs, _ := filepath.Glob(filepath.Join(dir, "agent-*.log"))
+	if len(logs) != 5 {
+		t.Fatalf("expected 5 logs after open, got %v", logs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "agent-old3.log")); !os.IsNotExist(err) {
+		t.Error("expected expired log pruned")
+	}
+	if _, err := os.Stat(live.Path()); err != nil {
+		t.Error("a running session's log should not be pruned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("non-log file should not be pruned")
+	}
+
+	// when: writes exceed the size limit twice
+	fmt.Fprint(l, "first line\n")
+	fmt.Fprint(l, "second\n")
+	fmt.Fprint(l, "third line\n")
+
+	// then: the log rotated and only this session's oldest file was pruned
+	if got := filepath.Base(l.Path()); got != "agent-new.2.log" {
+		t.Errorf("expected rotated path, got %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "agent-new.log")); !os.IsNotExist(err) {
+		t.Error("expected MaxFiles to prune the session's first file")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "agent-new.1.log"))
+	if string(data) != "second\n" {
+		t.Errorf("unexpected rotated file: %q", data)
+	}
+	logs, _ = filepath.Glob(filepath.Join(dir, "agent-*.log"))
+	if len(logs) != 6 {
+		t.Errorf("expected other sessions' logs kept, got %v", logs)
+	}
+	if l.Tail() != "first line\nsecond\nthird line" {
+		t.Errorf("tail should span rotations, got %q", l.Tail())
+	}
+}
+
+func TestAgentLog_RotationFailureKeepsLogging(t *testing.T) {
+	// given: a log whose next rotation target cannot be created
+	dir := t.TempDir()
+	l, err := newAgentLog(dir, "agent-1", LogRetention{MaxBytes: 10}, nil)
+	if err != nil {
+		t.Fatalf("newAgentLog: %v", err)
+	}
+	defer l.Close()
+	os.Mkdir(filepath.Join(dir, "agent-1.1.log"), 0755)
+
+	// when: writes exceed the size limit
+	fmt.Fprint(l, "first line\n")
+	fmt.Fprint(l, "second\n")
+
+	// then: logging carries on in the current file
+	if got := filepath.Base(l.Path()); got != "agent-1.log" {
+		t.Errorf("expected current path kept, got %s", got)
+	}
+	data, _ := os.ReadFile(l.Path())
+	if string(data) != "first line\nsecond\n" {
+		t.Errorf("unexpected log file: %q", data)
+	}
+}
+
+func TestAgentLogOwner(t *testing.T) {
+	for base, want := range map[string]string{
+		"agent-1.log":   "agent-1",
+		"agent-1.2.log": "agent-1",
+		"agent-x.y.log": "agent-x.y",
+	} {
+		if got := agentLogOwner(base); got != want {
+			t.Errorf("agentLogOwner(%q) = %q, want %q", base, got, want)
+		}
+	}
+}
+
+// TestHelperFakeAgent is run as a subprocess by TestACPBackend_StartupFailureIncludesStderr
+func TestHelperFakeAgent(t *testing.T) {
+	if os.Getenv("CCUI_FAKE_AGENT") != "1" {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "fake agent: booting")
+	fmt.Fprintln(os.Stderr, "fake agent: missing credentials")
+	os.Exit(1)
+}
+
+func TestACPBackend_StartupFailureIncludesStderr(t *testing.T) {
+	// given: a backend launching a fake agent that logs and exits
+	t.Setenv("CCUI_FAKE_AGENT", "1")
+	dir := t.TempDir()
+	b := NewACPBackendWithConfig(context.Background(), BackendConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperFakeAgent$"},
+		LogDir:  dir,
+	})
+
+	// when
+	_, err := b.NewSession(context.Background(), backend.SessionOpts{CWD: t.TempDir()})
+
+	// then: the error carries the stderr tail
+	if err == nil {
+		t.Fatal("expected startup error")
+	}
+	if !strings.Contains(err.Error(), "initialize") || !strings.Contains(err.Error(), "fake agent: missing credentials") {
+		t.Errorf("expected stderr tail in error, got %v", err)
+	}
+
+	// then: stderr landed in the session log file
+	logs, _ := filepath.Glob(filepath.Join(dir, "agent-*.log"))
+	if len(logs) != 1 {
+		t.Fatalf("expected one log file, got %v", logs)
+	}
+	data, err := os.ReadFile(logs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "fake agent: booting\nfake agent: missing credentials\n") {
+		t.Errorf("unexpected log contents: %q", data)
+	}
+}
@@ -2,59 +2,226 @@ package acp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"sync"
+	"time"
 
 	"ccui/backend"
 )
 
+// defaultAgentCommand is the ACP agent binary launched per session
+const defaultAgentCommand = "claude-code-acp"
+
 // ACPBackend implements AgentBackend for claude-code-acp subprocess
 type ACPBackend struct {
-	ctx    context.Context
-	apiKey string
+	ctx         context.Context
+	apiKey      string
+	command     string
+	args        []string
+	logDir      string
+	logKeep     LogRetention
+	terminal    TerminalHost
+	autoApprove []string
+	reconnect   *ReconnectOptions
+	permMode    string
+}
+
+// BackendConfig for creating an ACPBackend
+type BackendConfig struct {
+	APIKey   string
+	Command  string       // agent binary, defaults to claude-code-acp
+	Args     []string     // extra agent arguments
+	LogDir   string       // where agent stderr is logged per session; empty keeps it in memory
+	LogKeep  LogRetention // rotation and retention for LogDir
+	Terminal TerminalHost // optional; runs agent commands through ccui terminals
+
+	AutoApproveTools []string          // tool names, titles or kinds allowed without asking
+	Reconnect        *ReconnectOptions // respawn dead agents and session/load them; nil disables
+	PermissionMode   string            // initial agent permission mode for new sessions; empty uses the agent's
 }
 
 // NewACPBackend creates a new ACP backend
 func NewACPBackend(ctx context.Context, apiKey string) *ACPBackend {
-	return &ACPBackend{ctx: ctx, apiKey: apiKey}
+	return NewACPBackendWithConfig(ctx, BackendConfig{APIKey: apiKey})
+}
+
+// NewACPBackendWithConfig creates an ACP backend from cfg
+func NewACPBackendWithConfig(ctx context.Context, cfg BackendConfig) *ACPBackend {
+	command := cfg.Command
+	if command == "" {
+		command = defaultAgentCommand
+	}
+	return &ACPBackend{
+		ctx:         ctx,
+		apiKey:      cfg.APIKey,
+		command:     command,
+		args:        cfg.Args,
+		logDir:      cfg.LogDir,
+		logKeep:     cfg.LogKeep,
+		terminal:    cfg.Terminal,
+		autoApprove: cfg.AutoApproveTools,
+		reconnect:   cfg.Reconnect,
+		permMode:    cfg.PermissionMode,
+	}
 }
 
 // NewSession creates a new ACP session
 func (b *ACPBackend) NewSession(ctx context.Context, opts backend.SessionOpts) (backend.Session, error) {
-	cmd := exec.CommandContext(ctx, "claude-code-acp")
-	cmd.Env = append(os.Environ(), "ANTHROPIC_API_KEY="+b.apiKey)
-	cmd.Dir = opts.CWD
-	cmd.Stderr = os.Stderr
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, fmt.Errorf("stdin pipe: %w", err)
-	}
-	stdout, err := cmd.StdoutPipe()
+	logName := fmt.Sprintf("agent-%d", time.Now().UnixNano())
+	proc, err := b.startAgent(ctx, opts, logName)
 	if err != nil {
-		return nil, fmt.Errorf("stdout pipe: %w", err)
+		return nil, err
 	}
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("start: %w", err)
+
+	var procMu sync.Mutex
+	current, stopped := proc, false
+	var transport Transport = proc.transport
+	var reconnecting *reconnectTransport
+	if b.reconnect != nil {
+		// respawned agents append to the same log file
+		reconnecting = newReconnectTransport(proc.transport, *b.reconnect, func() (Transport, error) {
+			procMu.Lock()
+			defer procMu.Unlock()
+			if stopped {
+				return nil, errTransportClosed
+			}
+			current.stop() // a wedged agent may still be running
+			p, err := b.startAgent(ctx, opts, logName)
+			if err != nil {
+				return nil, err
+			}
+			go p.wait()
+			current = p
+			return p.transport, nil
+		})
+		transport = reconnecting
 	}
 
 	client := NewClient(ClientConfig{
-		Transport:          NewStdioTransport(stdin, stdout),
+		Transport:          transport,
 		EventChan:          opts.EventChan,
 		AutoPermission:     opts.AutoPermission,
 		SuppressToolEvents: opts.SuppressToolEvents,
 		FileChangeStore:    opts.FileChangeStore,
+		ReadOnly:           opts.ReadOnly,
+		TerminalHost:       b.terminal,
+		WorkspaceRoot:      opts.WorkspaceRoot,
+		AutoApproveTools:   b.autoApprove,
+		PermissionMode:     b.permMode,
+		DedupChunks:        opts.DedupChunks,
+		MaxTaskDepth:       opts.MaxTaskDepth,
+		DiffContextLines:   opts.DiffContextLines,
 	})
 
+	// fail reports a startup error with the agent's last stderr lines
+	fail := func(step string, err error) error {
+		proc.cmd.Process.Kill()
+		proc.cmd.Wait() // flushes stderr into the log
+		proc.log.Close()
+		if tail := proc.log.Tail(); tail != "" {
+			return fmt.Errorf("%s: %w\nagent stderr:\n%s", step, err, tail)
+		}
+		return fmt.Errorf("%s: %w", step, err)
+	}
+
 	if err := client.Initialize(); err != nil {
-		cmd.Process.Kill()
-		return nil, fmt.Errorf("initialize: %w", err)
+		return nil, fail("initialize", err)
 	}
 	if err := client.NewSession(opts.CWD, opts.MCPServers); err != nil {
-		cmd.Process.Kill()
-		return nil, fmt.Errorf("new session: %w", err)
+		// keep the agent running so the UI can complete login via Authenticate
+		if !errors.Is(err, ErrAuthRequired) {
+			return nil, fail("new session", err)
+		}
 	}
 
+	client.agentLogPath = proc.log.Path()
+	client.stopAgent = func() {
+		procMu.Lock()
+		defer procMu.Unlock()
+		stopped = true
+		current.stop()
+	}
+	go proc.wait()
+	if reconnecting != nil {
+		go reconnecting.watch(client.reload, func(status ReconnectStatus, stop <-chan struct{}) {
+			client.emitUntil(backend.EventReconnecting, status, stop)
+		})
+	}
 	return client, nil
 }
+
+// agentExitGrace is how long an agent gets to exit after its stdin closes
+// before it is killed
+const agentExitGrace = 5 * time.Second
+
+// agentProcess is a running agent subprocess
+type agentProcess struct {
+	cmd       *exec.Cmd
+	log       *agentLog
+	transport *StdioTransport
+	exited    chan struct{} // closed by wait
+}
+
+// wait reaps the process and closes its log
+func (p *agentProcess) wait() {
+	p.cmd.Wait()
+	p.log.Close()
+	close(p.exited)
+}
+
+// stop closes the agent's stdin and blocks until it exits, killing it if
+// it outlives agentExitGrace
+func (p *agentProcess) stop() {
+	p.transport.Close()
+	select {
+	case <-p.exited:
+		return
+	case <-time.After(agentExitGrace):
+	}
+	p.cmd.Process.Kill()
+	<-p.exited
+}
+
+// startAgent launches the agent for opts with stderr captured in logName
+func (b *ACPBackend) startAgent(ctx context.Context, opts backend.SessionOpts, logName string) (*agentProcess, error) {
+	agentLog, err := newAgentLog(b.logDir, logName, b.logKeep, opts.EventChan)
+	if err != nil {
+		return nil, err
+	}
+
+	command, args := b.command, b.args
+	if opts.AgentCommand != "" {
+		command, args = opts.AgentCommand, nil
+	}
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Env = os.Environ()
+	if b.apiKey != "" {
+		// an empty override would hide the agent's own login with a blank key
+		cmd.Env = append(cmd.Env, "ANTHROPIC_API_KEY="+b.apiKey)
+	}
+	if opts.Model != "" {
+		// claude-code-acp has no session/set_model; the agent reads its model from the environment
+		cmd.Env = append(cmd.Env, "ANTHROPIC_MODEL="+opts.Model)
+	}
+	cmd.Dir = opts.CWD
+	cmd.Stderr = agentLog
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		agentLog.Close()
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		agentLog.Close()
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		agentLog.Close()
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	return &agentProcess{cmd: cmd, log: agentLog, transport: NewStdioTransport(stdin, stdout), exited: make(chan struct{})}, nil
+}
@@ -2,15 +2,18 @@ package acp
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"ccui/backend"
 )
 
 // PermissionLayer abstracts permission request handling
 type PermissionLayer interface {
-	Request(toolCallID, toolName string, options []backend.PermOption) (string, error)
+	Request(sessionID, toolCallID, toolName, input string, options []backend.PermOption) (string, error)
 }
 
 // Client manages communication with an ACP subprocess
@@ -23,6 +26,7 @@ type Client struct {
 	toolManager     *backend.ToolCallManager
 	fileChangeStore *backend.FileChangeStore
 	toolAdapters    []ToolEventAdapter
+	terminalHost    TerminalHost
 
 	// Permission handling
 	permissionRespCh  chan string
@@ -33,12 +37,54 @@ type Client struct {
 	// Config
 	autoPermission     bool
 	suppressToolEvents bool
+	readOnly           bool
+	workspaceRoot      string
+	autoApprove        map[string]bool       // tool names, titles or kinds allowed without asking
+	chunkDedup         *backend.ChunkDeduper // nil unless DedupChunks
+	permissionMode     string                // sent with session/new; empty leaves the agent default
+	diffContext        int                   // lines around fs/write_text_file hunks; 0 uses backend.DefaultDiffContextLines
+
+	// Negotiated in Initialize
+	protocolVersion int
+	authMethods     []AuthMethod
+	loadSession     bool // agent supports session/load
+
+	// session/new awaiting authentication
+	pendingSession *sessionRequest
+
+	// arguments of the established session, reused by session/load
+	session sessionRequest
+
+	// set while session/load replays history we already have
+	replaying atomic.Bool
+
+	// agent stderr log file, empty when not persisted
+	agentLogPath string
+
+	// waits for the agent process to exit on Close; nil when there is none
+	stopAgent func()
+
+	// assembled history of prompts, replies and tool calls
+	transcript transcript
+
+	// last plan update, replayed by Snapshot
+	plan   []backend.PlanEntry
+	planMu sync.Mutex
 
 	// Session modes
 	currentModeID  string
 	availableModes []backend.SessionMode
 }
 
+// ErrAuthRequired is returned by NewSession when the agent needs the user to log in
+var ErrAuthRequired = errors.New("authentication required")
+
+// sessionRequest holds session/new arguments for a retry
+type sessionRequest struct {
+	cwd        string
+	mcpServers []any
+}
+
 // ClientOption for configuring a Client
 type ClientOption func(*Client)
 
@@ -56,8 +102,19 @@ type ClientConfig struct {
 	AutoPermission     bool
 	SuppressToolEvents bool
 	FileChangeStore    *backend.FileChangeStore // optional shared store
+	ReadOnly           bool                     // reject every permission request
+	TerminalHost       TerminalHost             // optional; enables the terminal capability
+	WorkspaceRoot      string                   // confine fs/* requests to this dir; empty allows any
+	AutoApproveTools   []string                 // tool names, titles or kinds allowed without asking; ignored when ReadOnly
+	PermissionMode     string                   // initial agent permission mode (default, acceptEdits, bypassPermissions, plan); ignored when ReadOnly
+	DedupChunks        bool                     // drop message/thought chunks identical to the previous one
+	MaxTaskDepth       int                      // cap on nested Task depth; 0 uses backend.DefaultMaxTaskDepth
+	DiffContextLines   int                      // context around generated hunks; 0 uses the defaults, negative keeps none
 }
 
+// askUserQuestionTool is ccui's own MCP tool, always allowed
+const askUserQuestionTool = "mcp__ccui__ccui_ask_user_question"
+
 // NewClient creates a Client with the given transport
 func NewClient(cfg ClientConfig, opts ...ClientOption) *Client {
 	fileStore := cfg.FileChangeStore
@@ -70,10 +127,25 @@ func NewClient(cfg ClientConfig, opts ...ClientOption) *Client {
 		eventChan:          cfg.EventChan,
 		toolManager:        backend.NewToolCallManager(),
 		fileChangeStore:    fileStore,
-		toolAdapters:       DefaultToolAdapters(),
+		toolAdapters:       toolAdapters(cfg.DiffContextLines),
+		terminalHost:       cfg.TerminalHost,
 		permissionRespCh:   make(chan string, 1),
 		autoPermission:     cfg.AutoPermission,
 		suppressToolEvents: cfg.SuppressToolEvents,
+		readOnly:           cfg.ReadOnly,
+		workspaceRoot:      cfg.WorkspaceRoot,
+		autoApprove:        map[string]bool{askUserQuestionTool: true},
+		chunkDedup:         backend.NewChunkDeduper(cfg.DedupChunks),
+		diffContext:        cfg.DiffContextLines,
+	}
+	c.toolManager.SetMaxDepth(cfg.MaxTaskDepth)
+	// read-only sessions must see every permission request, so neither
+	// auto-approval nor an agent mode like acceptEdits applies
+	if !cfg.ReadOnly {
+		c.permissionMode = cfg.PermissionMode
+		for _, name := range cfg.AutoApproveTools {
+			c.autoApprove[name] = true
+		}
 	}
 
 	// Apply options
@@ -87,23 +159,69 @@ func NewClient(cfg ClientConfig, opts ...ClientOption) *Client {
 	return c
 }
 
-// Initialize performs the ACP initialize handshake
+// Initialize performs the ACP initialize handshake and records the
+// protocol version the agent agreed to
 func (c *Client) Initialize() error {
-	_, err := c.transport.Send("initialize", InitializeParams{
-		ProtocolVersion: 1,
+	resp, err := c.transport.Send("initialize", InitializeParams{
+		ProtocolVersion: ProtocolVersion,
 		ClientCapabilities: ClientCapabilities{
-			Terminal: false,
+			FS:       clientFSCapabilities,
+			Terminal: c.terminalHost != nil,
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	var result InitializeResult
+	if len(resp) > 0 {
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return fmt.Errorf("parse initialize result: %w", err)
+		}
+	}
+	// agents predating negotiation omit the version
+	if result.ProtocolVersion == 0 {
+		result.ProtocolVersion = ProtocolVersion
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("unsupported ACP protocol version %d (ccui supports %d)", result.ProtocolVersion, ProtocolVersion)
+	}
+	c.protocolVersion = result.ProtocolVersion
+	c.authMethods = result.AuthMethods
+	var caps AgentCapabilities
+	if len(result.AgentCapabilities) > 0 {
+		json.Unmarshal(result.AgentCapabilities, &caps)
+	}
+	c.loadSession = caps.LoadSession
+	return nil
+}
+
+// ProtocolVersion returns the negotiated protocol version, 0 before Initialize
+func (c *Client) ProtocolVersion() int {
+	return c.protocolVersion
 }
 
-// NewSession creates a new ACP session
+// NewSession creates a new ACP session; if the agent requires login it emits
+// auth_required and returns ErrAuthRequired, and Authenticate retries it
 func (c *Client) NewSession(cwd string, mcpServers []any) error {
-	resp, err := c.transport.Send("session/new", map[string]any{
+	params := map[string]any{
 		"cwd":        cwd,
 		"mcpServers": mcpServers,
-	})
+	}
+	if c.permissionMode != "" {
+		params["permissionMode"] = c.permissionMode
+	}
+	resp, err := c.transport.Send("session/new", params)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == rpcAuthRequired {
+		c.pendingSession = &sessionRequest{cwd: cwd, mcpServers: mcpServers}
+		c.emit(backend.EventAuthRequired, AuthRequired{
+			Message: rpcErr.Message,
+			URL:     authURL(rpcErr.Data),
+			Methods: c.authMethods,
+		})
+		return fmt.Errorf("%w: %s", ErrAuthRequired, rpcErr.Message)
+	}
 	if err != nil {
 		return err
 	}
@@ -111,6 +229,7 @@ func (c *Client) NewSession(cwd string, mcpServers []any) error {
 	var result SessionNewResult
 	json.Unmarshal(resp, &result)
 	c.sessionID = result.SessionID
+	c.session = sessionRequest{cwd: cwd, mcpServers: mcpServers}
 	if result.Modes != nil {
 		c.currentModeID = result.Modes.CurrentModeID
 		c.availableModes = result.Modes.AvailableModes
@@ -118,8 +237,62 @@ func (c *Client) NewSession(cwd string, mcpServers []any) error {
 	return nil
 }
 
+// reload re-initializes a respawned agent and reattaches it to this session
+// with session/load; the history it replays is already in the transcript
+func (c *Client) reload() error {
+	if err := c.Initialize(); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	if c.sessionID == "" {
+		return errors.New("no session to load")
+	}
+	if !c.loadSession {
+		return errors.New("agent does not support session/load")
+	}
+	c.replaying.Store(true)
+	defer c.replaying.Store(false)
+	if _, err := c.transport.Send("session/load", SessionLoadParams{
+		SessionID:  c.sessionID,
+		CWD:        c.session.cwd,
+		MCPServers: c.session.mcpServers,
+	}); err != nil {
+		return fmt.Errorf("session/load: %w", err)
+	}
+	return nil
+}
+
+// Authenticate logs in with one of the agent's auth methods, then retries a
+// session/new that failed with ErrAuthRequired
+func (c *Client) Authenticate(method string, params any) error {
+	req := map[string]any{}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal auth params: %w", err)
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("auth params must be an object: %w", err)
+		}
+	}
+	req["methodId"] = method
+
+	if _, err := c.transport.Send("authenticate", req); err != nil {
+		return fmt.Errorf("authenticate: %w", err)
+	}
+
+	pending := c.pendingSession
+	if pending == nil {
+		return nil
+	}
+	c.pendingSession = nil
+	return c.NewSession(pending.cwd, pending.mcpServers)
+}
+
 // SendPrompt implements backend.Session
 func (c *Client) SendPrompt(text string, allowedTools []string) error {
+	c.toolManager.NewTurn()
+	defer c.toolManager.EndTurn()
+	c.transcript.addUser(text)
 	resp, err := c.transport.Send("session/prompt", SessionPromptParams{
 		SessionID:    c.sessionID,
 		Prompt:       []PromptContent{{Type: "text", Text: text}},
@@ -150,14 +323,34 @@ func (c *Client) SetMode(modeID string) error {
 	return nil
 }
 
+// SetModel implements backend.Session (model selection is owned by the agent)
+func (c *Client) SetModel(model string) error {
+	return nil
+}
+
 // Cancel implements backend.Session
 func (c *Client) Cancel() {
 	c.transport.Notify("session/cancel", map[string]string{"sessionId": c.sessionID})
 }
 
-// Close implements backend.Session
+// CancelWithReason implements backend.ReasonCanceler; the reason is sent
+// with session/cancel for the agent's logs
+func (c *Client) CancelWithReason(reason string) {
+	params := map[string]string{"sessionId": c.sessionID}
+	if reason != "" {
+		params["reason"] = reason
+	}
+	c.transport.Notify("session/cancel", params)
+	c.emit(backend.EventCancelled, reason)
+}
+
+// Close implements backend.Session; it returns once the agent process has exited
 func (c *Client) Close() error {
-	return c.transport.Close()
+	err := c.transport.Close()
+	if c.stopAgent != nil {
+		c.stopAgent()
+	}
+	return err
 }
 
 // SessionID implements backend.Session
@@ -170,6 +363,11 @@ func (c *Client) CurrentMode() string {
 	return c.currentModeID
 }
 
+// GetModel implements backend.Session
+func (c *Client) GetModel() string {
+	return ""
+}
+
 // AvailableModes implements backend.Session
 func (c *Client) AvailableModes() []backend.SessionMode {
 	return c.availableModes
@@ -180,6 +378,23 @@ func (c *Client) RespondToPermission(optionID string) {
 	c.permissionRespCh <- optionID
 }
 
+// Transcript returns the session history assembled from streamed updates
+func (c *Client) Transcript() []TranscriptEntry {
+	return c.transcript.snapshot()
+}
+
+// Snapshot returns the current tool states and last plan
+func (c *Client) Snapshot() backend.SessionSnapshot {
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	return backend.SessionSnapshot{Tools: c.toolManager.List(), Plan: c.plan}
+}
+
+// AgentLogPath returns the file capturing the agent's stderr, if any
+func (c *Client) AgentLogPath() string {
+	return c.agentLogPath
+}
+
 // FileChangeStore returns the file change store
 func (c *Client) FileChangeStore() *backend.FileChangeStore {
 	return c.fileChangeStore
@@ -196,9 +411,31 @@ func (c *Client) emit(eventType backend.EventType, data any) {
 	}
 }
 
+// emitUntil is emit that gives up once done closes
+func (c *Client) emitUntil(eventType backend.EventType, data any, done <-chan struct{}) {
+	if c.eventChan == nil {
+		return
+	}
+	select {
+	case c.eventChan <- backend.Event{Type: eventType, Data: data}:
+	case <-done:
+	}
+}
+
+// emitToolState emits a deep copy of state; the live state keeps changing
+// on later updates
+func (c *Client) emitToolState(state *backend.ToolState) {
+	if state != nil {
+		c.emit(backend.EventToolState, state.Clone())
+	}
+}
+
 func (c *Client) handleMethod(method string, params json.RawMessage, id *int) {
 	switch method {
 	case "session/update":
+		if c.replaying.Load() {
+			return
+		}
 		var update SessionUpdate
 		json.Unmarshal(params, &update)
 		c.handleSessionUpdate(update)
@@ -207,11 +444,30 @@ func (c *Client) handleMethod(method string, params json.RawMessage, id *int) {
 		var req PermissionRequest
 		json.Unmarshal(params, &req)
 		c.handlePermissionRequest(req, id)
+
+	case "fs/read_text_file":
+		c.handleReadTextFile(params, id)
+
+	case "fs/write_text_file":
+		c.handleWriteTextFile(params, id)
+
+	case "terminal/create", "terminal/output", "terminal/wait_for_exit", "terminal/kill", "terminal/release":
+		c.handleTerminalMethod(method, params, id)
+
+	default:
+		// an unanswered request would wedge the agent; notifications need no reply
+		if id != nil {
+			c.transport.RespondError(id, &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + method})
+		}
 	}
 }
 
 func (c *Client) handleSessionUpdate(update SessionUpdate) {
 	u := update.Update
+	if u.SessionUpdate != "agent_message_chunk" && u.SessionUpdate != "agent_thought_chunk" {
+		// any other update separates chunks, so a repeat after it is kept
+		c.chunkDedup.Reset()
+	}
 
 	switch u.SessionUpdate {
 	case "agent_message_chunk":
@@ -219,6 +475,10 @@ func (c *Client) handleSessionUpdate(update SessionUpdate) {
 		if len(u.Content) > 0 {
 			json.Unmarshal(u.Content, &content)
 		}
+		if !c.chunkDedup.Keep(backend.EventMessageChunk, content.Text) {
+			return
+		}
+		c.transcript.appendText(RoleAssistant, content.Text)
 		c.emit(backend.EventMessageChunk, content.Text)
 
 	case "agent_thought_chunk":
@@ -226,15 +486,21 @@ func (c *Client) handleSessionUpdate(update SessionUpdate) {
 		if len(u.Content) > 0 {
 			json.Unmarshal(u.Content, &content)
 		}
+		if !c.chunkDedup.Keep(backend.EventThoughtChunk, content.Text) {
+			return
+		}
+		c.transcript.appendText(RoleThought, content.Text)
 		c.emit(backend.EventThoughtChunk, content.Text)
 
 	case "tool_call":
+		c.transcript.recordTool(u, ResolveToolName(c.adapterFor(u), u))
 		if c.suppressToolEvents {
 			return
 		}
 		c.handleToolCall(u)
 
 	case "tool_call_update":
+		c.transcript.recordTool(u, ResolveToolName(c.adapterFor(u), u))
 		c.handleToolCallUpdate(u)
 
 	case "current_mode_update":
@@ -242,6 +508,9 @@ func (c *Client) handleSessionUpdate(update SessionUpdate) {
 		c.emit(backend.EventModeChanged, u.ModeID)
 
 	case "plan":
+		c.planMu.Lock()
+		c.plan = u.Entries
+		c.planMu.Unlock()
 		c.emit(backend.EventPlanUpdate, u.Entries)
 	}
 }
@@ -255,16 +524,21 @@ func (c *Client) handleToolCall(u UpdateContent) {
 	}
 
 	// Update existing tool if present
-	if existing := c.toolManager.Get(u.ToolCallID); existing != nil {
-		existing.Status = u.Status
-		existing.Title = u.Title
-		if existing.ToolName == "" {
-			existing.ToolName = toolName
+	existing := c.toolManager.Update(u.ToolCallID, func(s *backend.ToolState) {
+		s.Status = u.Status
+		s.Title = u.Title
+		if s.ToolName == "" {
+			s.ToolName = toolName
 		}
 		if u.RawInput != nil {
-			existing.Input = u.RawInput
+			s.Input = u.RawInput
 		}
-		c.emit(backend.EventToolState, existing)
+	})
+	if existing != nil {
+		if backend.IsTerminalStatus(u.Status) {
+			c.toolManager.PopParent(u.ToolCallID)
+		}
+		c.emitToolState(existing)
 		return
 	}
 
@@ -279,12 +553,12 @@ func (c *Client) handleToolCall(u UpdateContent) {
 		Diffs:    diffs,
 	}
 
-	if toolName == "Task" {
+	if toolName == "Task" && !backend.IsTerminalStatus(u.Status) {
 		c.toolManager.PushParent(u.ToolCallID)
 	}
 
 	c.toolManager.Set(state)
-	c.emit(backend.EventToolState, state)
+	c.emitToolState(state)
 }
 
 func (c *Client) handleToolCallUpdate(u UpdateContent) {
@@ -305,9 +579,9 @@ func (c *Client) handleToolCallUpdate(u UpdateContent) {
 		return
 	}
 
-	state := c.toolManager.Update(u.ToolCallID, func(s *backend.ToolState) {
+	apply := func(s *backend.ToolState) {
 		s.Status = u.Status
-		s.Output = u.Output
+		s.Output = appendOutput(s.Output, u.Output)
 		if u.RawInput != nil {
 			s.Input = u.RawInput
 		}
@@ -330,21 +604,46 @@ func (c *Client) handleToolCallUpdate(u UpdateContent) {
 		} else if len(diffs) > 0 && s.Diff == nil {
 			s.Diffs = diffs
 		}
-	})
+	}
 
+	state := c.toolManager.Update(u.ToolCallID, apply)
 	if state == nil {
-		return
+		if u.ToolCallID == "" {
+			return
+		}
+		// the tool_call was missed, e.g. when joining after a reconnect;
+		// build the state from the update so the tool still shows
+		state = &backend.ToolState{
+			ID:       u.ToolCallID,
+			Title:    u.Title,
+			Kind:     u.ToolKind,
+			ParentID: c.toolManager.CurrentParent(),
+		}
+		apply(state)
+		if state.ToolName == "Task" && !backend.IsTerminalStatus(u.Status) {
+			c.toolManager.PushParent(u.ToolCallID)
+		}
+		c.toolManager.Set(state)
 	}
-	if state.ToolName == "Task" && isTerminalStatus(u.Status) {
+	// pop on any final status, not just a Task's, in case the name resolved
+	// differently when the parent was pushed; PopParent ignores unknown ids
+	if backend.IsTerminalStatus(u.Status) {
 		c.toolManager.PopParent(u.ToolCallID)
 	}
-	c.emit(backend.EventToolState, state)
+	c.emitToolState(state)
 }
 
 func (c *Client) handlePermissionRequest(req PermissionRequest, id *int) {
-	// Auto-allow our MCP ask user question tool
-	if req.ToolCall.Title == "mcp__ccui__ccui_ask_user_question" {
-		c.sendPermissionResponse(id, "allow_always")
+	// Auto-allow trusted tools, including our MCP ask user question tool
+	if c.autoApproves(req.ToolCall) {
+		c.sendPermissionResponse(id, backend.AllowOptionID(req.Options))
+		return
+	}
+
+	// Read-only sessions reject anything that needs permission, which the
+	// agent only asks for on mutating tools
+	if c.readOnly {
+		c.sendPermissionResponse(id, backend.RejectOptionID(req.Options))
 		return
 	}
 
@@ -356,7 +655,8 @@ func (c *Client) handlePermissionRequest(req PermissionRequest, id *int) {
 
 	// Delegate to permission layer if present
 	if c.permissionLayer != nil {
-		optionID, _ := c.permissionLayer.Request(req.ToolCall.ToolCallID, req.ToolCall.Title, req.Options)
+		name, input := c.permissionSubject(req.ToolCall)
+		optionID, _ := c.permissionLayer.Request(c.sessionID, req.ToolCall.ToolCallID, name, input, req.Options)
 		c.sendPermissionResponse(id, optionID)
 		return
 	}
@@ -367,9 +667,7 @@ func (c *Client) handlePermissionRequest(req PermissionRequest, id *int) {
 		s.Status = "awaiting_permission"
 		s.PermissionOptions = req.Options
 	})
-	if state != nil {
-		c.emit(backend.EventToolState, state)
-	}
+	c.emitToolState(state)
 
 	// Emit permission request event
 	c.emit(backend.EventPermissionRequest, req)
@@ -384,6 +682,31 @@ func (c *Client) handlePermissionRequest(req PermissionRequest, id *int) {
 	c.sendPermissionResponse(id, optionID)
 }
 
+// permissionSubject returns the tool name and JSON input the permission layer
+// checks, preferring the resolved tool call over the request's title
+func (c *Client) permissionSubject(tc ToolCallInfo) (string, string) {
+	state := c.toolManager.Get(tc.ToolCallID)
+	if state == nil {
+		return tc.Title, ""
+	}
+	name := state.ToolName
+	if name == "" {
+		name = tc.Title
+	}
+	input, _ := json.Marshal(state.Input)
+	return name, string(input)
+}
+
+// autoApproves reports whether the tool's resolved name, title or kind is
+// configured to be allowed without asking
+func (c *Client) autoApproves(tc ToolCallInfo) bool {
+	if c.autoApprove[tc.Title] || c.autoApprove[tc.Kind] {
+		return true
+	}
+	state := c.toolManager.Get(tc.ToolCallID)
+	return state != nil && c.autoApprove[state.ToolName]
+}
+
 func (c *Client) sendPermissionResponse(id *int, optionID string) {
 	result, _ := json.Marshal(PermissionResponse{
 		Outcome: PermissionOutcome{Outcome: "selected", OptionID: optionID},
@@ -416,6 +739,60 @@ func (c *Client) adapterFor(update UpdateContent) ToolEventAdapter {
 	return nil
 }
 
-func isTerminalStatus(status string) bool {
-	return status == "completed" || status == "error" || status == "failed"
+// appendOutput accumulates a tool's output across updates. An update that
+// begins with every block so far, position by position, resends cumulative
+// output and replaces it; its last matching block may have grown, as ACP
+// content replaces rather than extends. Any other update is new output and
+// is appended whole, so identical blocks that legitimately repeat are kept.
+func appendOutput(existing, incoming []backend.OutputBlock) []backend.OutputBlock {
+	if len(incoming) >= len(existing) && hasOutputPrefix(incoming, existing) {
+		return append([]backend.OutputBlock(nil), incoming...)
+	}
+	return append(existing, incoming...)
+}
+
+// hasOutputPrefix reports whether blocks starts with prefix, allowing the
+// last block of prefix to be a text prefix of its counterpart in blocks
+func hasOutputPrefix(blocks, prefix []backend.OutputBlock) bool {
+	for i := range prefix {
+		if sameOutput(blocks[i], prefix[i]) {
+			continue
+		}
+		if i != len(prefix)-1 || !grownOutput(prefix[i], blocks[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// grownOutput reports whether b is a with more text appended
+func grownOutput(a, b backend.OutputBlock) bool {
+	if a.Content == nil || b.Content == nil || a.Content.Type != b.Content.Type ||
+		!strings.HasPrefix(b.Content.Text, a.Content.Text) {
+		return false
+	}
+	a.Content, b.Content = nil, nil
+	return sameOutput(a, b)
+}
+
+// sameOutput compares blocks by value, including their text content
+func sameOutput(a, b backend.OutputBlock) bool {
+	if a.Type != b.Type || a.Path != b.Path || a.OldContent != b.OldContent || a.NewContent != b.NewContent || a.MimeType != b.MimeType {
+		return false
+	}
+	if a.Content == nil || b.Content == nil {
+		return a.Content == b.Content
+	}
+	return *a.Content == *b.Content
+}
+
+// authURL extracts a login URL from auth error data, if the agent sent one
+func authURL(data json.RawMessage) string {
+	var d struct {
+		URL string `json:"url"`
+	}
+	if len(data) > 0 {
+		json.Unmarshal(data, &d)
+	}
+	return d.URL
 }
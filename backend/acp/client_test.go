@@ -2,6 +2,10 @@ package acp
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -17,11 +21,13 @@ type MockTransport struct {
 		Params any
 	}
 	responses map[string]json.RawMessage
+	errors    map[string]error
 }
 
 func NewMockTransport() *MockTransport {
 	return &MockTransport{
 		responses: make(map[string]json.RawMessage),
+		errors:    make(map[string]error),
 	}
 }
 
@@ -32,7 +38,11 @@ func (m *MockTransport) Send(method string, params any) (json.RawMessage, error)
 		Params any
 	}{method, params})
 	resp := m.responses[method]
+	err := m.errors[method]
 	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
 
@@ -59,6 +69,16 @@ func (m *MockTransport) Respond(id *int, result json.RawMessage) {
 	m.mu.Unlock()
 }
 
+func (m *MockTransport) RespondError(id *int, err *RPCError) {
+	// Track error response in sentMessages with empty method
+	m.mu.Lock()
+	m.sentMessages = append(m.sentMessages, struct {
+		Method string
+		Params any
+	}{"", map[string]any{"id": id, "error": err}})
+	m.mu.Unlock()
+}
+
 func (m *MockTransport) Close() error {
 	return nil
 }
@@ -68,6 +88,17 @@ func (m *MockTransport) SetResponse(method string, result any) {
 	m.responses[method] = data
 }
 
+// SetError makes Send fail for method; nil clears it
+func (m *MockTransport) SetError(method string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.errors, method)
+		return
+	}
+	m.errors[method] = err
+}
+
 func (m *MockTransport) SimulateMethod(method string, params any, id *int) {
 	if m.handler != nil {
 		data, _ := json.Marshal(params)
@@ -115,6 +146,109 @@ func TestClient_HandleMessageChunk(t *testing.T) {
 	}
 }
 
+func TestClient_DedupChunks(t *testing.T) {
+	for _, dedup := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dedup=%v", dedup), func(t *testing.T) {
+			// given - a client fed a resent chunk, then the same text after a plan update
+			transport := NewMockTransport()
+			events := make(chan backend.Event, 10)
+			NewClient(ClientConfig{Transport: transport, EventChan: events, DedupChunks: dedup})
+			chunk := func(text string) {
+				transport.SimulateMethod("session/update", SessionUpdate{Update: UpdateContent{
+					SessionUpdate: "agent_message_chunk",
+					Content:       json.RawMessage(`{"type":"text","text":"` + text + `"}`),
+				}}, nil)
+			}
+
+			// when
+			chunk("Hello")
+			chunk("Hello")
+			transport.SimulateMethod("session/update", SessionUpdate{Update: UpdateContent{SessionUpdate: "plan"}}, nil)
+			chunk("Hello")
+			close(events)
+
+			// then - only the consecutive repeat is dropped, and only when enabled
+			var chunks []any
+			for ev := range events {
+				if ev.Type == backend.EventMessageChunk {
+					chunks = append(chunks, ev.Data)
+				}
+			}
+			want := 3
+			if dedup {
+				want = 2
+			}
+			if len(chunks) != want {
+				t.Errorf("expected %d chunks, got %v", want, chunks)
+			}
+		})
+	}
+}
+
+func TestClient_UnhandledMethod(t *testing.T) {
+	// given
+	transport := NewMockTransport()
+	NewClient(ClientConfig{Transport: transport})
+
+	// when: the agent sends an unknown request and an unknown notification
+	id := 11
+	transport.SimulateMethod("_vendor/custom_request", map[string]any{}, &id)
+	transport.SimulateMethod("_vendor/custom_notice", map[string]any{}, nil)
+
+	// then: only the request is answered, with method not found
+	if len(transport.sentMessages) != 1 {
+		t.Fatalf("expected 1 response, got %d", len(transport.sentMessages))
+	}
+	resp := lastResponse(t, transport)
+	rpcErr, ok := resp["error"].(*RPCError)
+	if !ok || rpcErr.Code != rpcMethodNotFound {
+		t.Fatalf("expected method not found error, got %+v", resp)
+	}
+	if respID := resp["id"].(*int); *respID != id {
+		t.Errorf("expected response to id %d, got %d", id, *respID)
+	}
+}
+
+func TestClient_CancelWithReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel func(c *Client)
+		want   map[string]string
+		event  bool
+	}{
+		{"reason sent", func(c *Client) { c.CancelWithReason("user aborted") }, map[string]string{"sessionId": "s1", "reason": "user aborted"}, true},
+		{"plain cancel", func(c *Client) { c.Cancel() }, map[string]string{"sessionId": "s1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			transport := NewMockTransport()
+			events := make(chan backend.Event, 1)
+			client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+			client.sessionID = "s1"
+
+			// when
+			tt.cancel(client)
+
+			// then
+			msg := transport.sentMessages[0]
+			if msg.Method != "session/cancel" || !reflect.DeepEqual(msg.Params, tt.want) {
+				t.Errorf("unexpected notify %s %+v, want %+v", msg.Method, msg.Params, tt.want)
+			}
+			select {
+			case ev := <-events:
+				if !tt.event || ev.Type != backend.EventCancelled || ev.Data != "user aborted" {
+					t.Errorf("unexpected event: %+v", ev)
+				}
+			default:
+				if tt.event {
+					t.Error("expected cancelled event")
+				}
+			}
+		})
+	}
+}
+
 func TestClient_HandleThoughtChunk(t *testing.T) {
 	transport := NewMockTransport()
 	events := make(chan backend.Event, 10)
@@ -270,6 +404,43 @@ func TestClient_HandleToolCallUpdate(t *testing.T) {
 	}
 }
 
+func TestClient_HandleToolCallUpdate_UnknownTool(t *testing.T) {
+	// given - a client that never saw the tool_call, as after a reconnect
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+
+	// when - only an update arrives
+	transport.SimulateMethod("session/update", SessionUpdate{
+		SessionID: "test-session",
+		Update: UpdateContent{
+			SessionUpdate: "tool_call_update",
+			ToolCallID:    "late-1",
+			Title:         "Read",
+			ToolKind:      "read",
+			Status:        "in_progress",
+			Output:        []backend.OutputBlock{{Type: "text", Content: &backend.TextContent{Type: "text", Text: "partial"}}},
+		},
+	}, nil)
+
+	// then - the state is created and emitted from the update
+	select {
+	case evt := <-events:
+		state, ok := evt.Data.(*backend.ToolState)
+		if evt.Type != backend.EventToolState || !ok {
+			t.Fatalf("expected a tool state event, got %v %T", evt.Type, evt.Data)
+		}
+		if state.ID != "late-1" || state.Status != "in_progress" || state.Title != "Read" || state.Kind != "read" || len(state.Output) != 1 {
+			t.Errorf("unexpected state %+v", state)
+		}
+	default:
+		t.Fatal("expected event but got none")
+	}
+	if client.toolManager.Get("late-1") == nil {
+		t.Error("expected the state to be tracked for later updates")
+	}
+}
+
 func TestClient_HandlePermissionRequest(t *testing.T) {
 	transport := NewMockTransport()
 	events := make(chan backend.Event, 10)
@@ -376,6 +547,104 @@ func TestClient_HandlePermissionRequest_AutoAllow(t *testing.T) {
 	}
 }
 
+func TestClient_HandlePermissionRequest_ReadOnly(t *testing.T) {
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+
+	client := NewClient(ClientConfig{
+		Transport:      transport,
+		EventChan:      events,
+		ReadOnly:       true,
+		AutoPermission: true,
+	})
+
+	transport.OnMethod(func(method string, params json.RawMessage, id *int) {
+		client.handleMethod(method, params, id)
+	})
+
+	// Simulate permission request in a read-only session
+	id := 44
+	transport.SimulateMethod("session/request_permission", PermissionRequest{
+		SessionID: "test-session",
+		ToolCall:  ToolCallInfo{ToolCallID: "tool-ro", Title: "Write", Kind: "edit"},
+		Options: []backend.PermOption{
+			{OptionID: "allow_always", Name: "Always Allow", Kind: "allow_always"},
+			{OptionID: "allow", Name: "Allow", Kind: "allow_once"},
+			{OptionID: "reject", Name: "Reject", Kind: "reject_once"},
+		},
+	}, &id)
+
+	// Should reject without waiting, even with auto-permission
+	var resp map[string]any
+	for _, msg := range transport.sentMessages {
+		if msg.Method == "" {
+			resp = msg.Params.(map[string]any)
+		}
+	}
+	if resp == nil {
+		t.Fatal("expected permission response to be sent")
+	}
+	var out PermissionResponse
+	if err := json.Unmarshal(resp["result"].(json.RawMessage), &out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if out.Outcome.OptionID != "reject" {
+		t.Errorf("expected reject option, got %q", out.Outcome.OptionID)
+	}
+}
+
+func TestClient_HandlePermissionRequest_AutoApproveTools(t *testing.T) {
+	// given: Read is trusted and a Read call is in flight
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{
+		Transport:        transport,
+		EventChan:        events,
+		AutoApproveTools: []string{"Read"},
+	})
+	client.toolManager.Set(&backend.ToolState{ID: "tool-read", Status: "pending", Title: "Read main.go", ToolName: "Read"})
+	options := []backend.PermOption{
+		{OptionID: "allow_always", Name: "Always Allow", Kind: "allow_always"},
+		{OptionID: "allow", Name: "Allow", Kind: "allow_once"},
+		{OptionID: "reject", Name: "Reject", Kind: "reject_once"},
+	}
+
+	// when: the agent asks for the Read call and for the ask-user tool
+	for i, tc := range []ToolCallInfo{
+		{ToolCallID: "tool-read", Title: "Read main.go", Kind: "read"},
+		{ToolCallID: "tool-ask", Title: askUserQuestionTool, Kind: "other"},
+	} {
+		id := 50 + i
+		transport.SimulateMethod("session/request_permission", PermissionRequest{
+			SessionID: "test-session",
+			ToolCall:  tc,
+			Options:   options,
+		}, &id)
+	}
+
+	// then: both are allowed once without asking the UI
+	var allowed []string
+	for _, msg := range transport.sentMessages {
+		if msg.Method != "" {
+			continue
+		}
+		var out PermissionResponse
+		if err := json.Unmarshal(msg.Params.(map[string]any)["result"].(json.RawMessage), &out); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		allowed = append(allowed, out.Outcome.OptionID)
+	}
+	if strings.Join(allowed, ",") != "allow,allow" {
+		t.Errorf("expected two allow responses, got %v", allowed)
+	}
+	close(events)
+	for ev := range events {
+		if ev.Type == backend.EventPermissionRequest {
+			t.Errorf("auto-approved tool emitted a permission request: %+v", ev.Data)
+		}
+	}
+}
+
 func TestClient_HandleModeUpdate(t *testing.T) {
 	transport := NewMockTransport()
 	events := make(chan backend.Event, 10)
@@ -518,7 +787,7 @@ type mockPermRequest struct {
 	options    []backend.PermOption
 }
 
-func (m *mockPermissionLayer) Request(toolCallID, toolName string, options []backend.PermOption) (string, error) {
+func (m *mockPermissionLayer) Request(sessionID, toolCallID, toolName, input string, options []backend.PermOption) (string, error) {
 	m.mu.Lock()
 	m.requests = append(m.requests, mockPermRequest{toolCallID, toolName, options})
 	resp := m.response
@@ -581,3 +850,398 @@ func TestClient_PermissionLayerIntegration(t *testing.T) {
 		t.Errorf("expected 2 options, got %d", len(requests[0].options))
 	}
 }
+
+func TestClient_Initialize_RecordsProtocolVersion(t *testing.T) {
+	// given: an agent that answers with the negotiated version
+	transport := NewMockTransport()
+	transport.SetResponse("initialize", InitializeResult{ProtocolVersion: 1})
+	client := NewClient(ClientConfig{Transport: transport})
+
+	// when
+	err := client.Initialize()
+
+	// then
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if got := client.ProtocolVersion(); got != 1 {
+		t.Errorf("expected protocol version 1, got %d", got)
+	}
+}
+
+func TestClient_Initialize_UnsupportedProtocolVersion(t *testing.T) {
+	// given: an agent that only speaks a newer version
+	transport := NewMockTransport()
+	transport.SetResponse("initialize", InitializeResult{ProtocolVersion: 2})
+	client := NewClient(ClientConfig{Transport: transport})
+
+	// when
+	err := client.Initialize()
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "unsupported ACP protocol version 2") {
+		t.Fatalf("expected unsupported version error, got %v", err)
+	}
+	if client.ProtocolVersion() != 0 {
+		t.Errorf("expected no negotiated version, got %d", client.ProtocolVersion())
+	}
+}
+
+func TestClient_Initialize_MissingVersionAssumesCurrent(t *testing.T) {
+	transport := NewMockTransport()
+	transport.SetResponse("initialize", map[string]any{})
+	client := NewClient(ClientConfig{Transport: transport})
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if got := client.ProtocolVersion(); got != ProtocolVersion {
+		t.Errorf("expected protocol version %d, got %d", ProtocolVersion, got)
+	}
+}
+
+func TestClient_NewSession_PermissionMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ClientConfig
+		wantMode any
+	}{
+		{"configured mode sent", ClientConfig{PermissionMode: "acceptEdits"}, "acceptEdits"},
+		{"unset omits param", ClientConfig{}, nil},
+		{"read-only ignores mode", ClientConfig{PermissionMode: "acceptEdits", ReadOnly: true}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			transport := NewMockTransport()
+			transport.SetResponse("session/new", SessionNewResult{SessionID: "s1"})
+			tt.cfg.Transport = transport
+			client := NewClient(tt.cfg)
+
+			// when
+			if err := client.NewSession("/work", nil); err != nil {
+				t.Fatalf("NewSession: %v", err)
+			}
+
+			// then
+			params := transport.sentMessages[0].Params.(map[string]any)
+			if got := params["permissionMode"]; got != tt.wantMode {
+				t.Errorf("permissionMode = %v, want %v", got, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestClient_NewSession_AuthChallenge(t *testing.T) {
+	// given: an agent that advertises a login method and rejects session/new
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	transport.SetResponse("initialize", InitializeResult{
+		ProtocolVersion: 1,
+		AuthMethods:     []AuthMethod{{ID: "oauth", Name: "Log in with browser"}},
+	})
+	transport.SetError("session/new", &RPCError{
+		Code:    rpcAuthRequired,
+		Message: "Authentication required",
+		Data:    json.RawMessage(`{"url":"https://example.com/login"}`),
+	})
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	// when: creating the session
+	err := client.NewSession("/work", nil)
+
+	// then: auth is required and the UI is told how to log in
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired, got %v", err)
+	}
+	select {
+	case ev := <-events:
+		auth, ok := ev.Data.(AuthRequired)
+		if ev.Type != backend.EventAuthRequired || !ok {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+		if auth.URL != "https://example.com/login" || len(auth.Methods) != 1 || auth.Methods[0].ID != "oauth" {
+			t.Errorf("unexpected auth event: %+v", auth)
+		}
+	default:
+		t.Fatal("expected auth_required event")
+	}
+
+	// when: the user logs in and the agent now accepts session/new
+	transport.SetError("session/new", nil)
+	transport.SetResponse("session/new", SessionNewResult{SessionID: "sess-1"})
+	err = client.Authenticate("oauth", map[string]any{"token": "abc"})
+
+	// then: authenticate is sent and session/new retried
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if client.SessionID() != "sess-1" {
+		t.Errorf("expected retried session id sess-1, got %q", client.SessionID())
+	}
+	var authParams map[string]any
+	var newSessionCalls int
+	for _, msg := range transport.sentMessages {
+		switch msg.Method {
+		case "authenticate":
+			authParams = msg.Params.(map[string]any)
+		case "session/new":
+			newSessionCalls++
+		}
+	}
+	if authParams["methodId"] != "oauth" || authParams["token"] != "abc" {
+		t.Errorf("unexpected authenticate params: %+v", authParams)
+	}
+	if newSessionCalls != 2 {
+		t.Errorf("expected session/new sent twice, got %d", newSessionCalls)
+	}
+}
+
+func TestClient_Authenticate_Failure(t *testing.T) {
+	transport := NewMockTransport()
+	transport.SetError("authenticate", &RPCError{Code: -32000, Message: "bad credentials"})
+	client := NewClient(ClientConfig{Transport: transport})
+
+	err := client.Authenticate("oauth", nil)
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Message != "bad credentials" {
+		t.Fatalf("expected wrapped rpc error, got %v", err)
+	}
+}
+
+func TestClient_ToolCallUpdate_AccumulatesOutput(t *testing.T) {
+	// given: a running tool
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+	client.toolManager.Set(&backend.ToolState{ID: "tool-789", Status: "running", Title: "Bash"})
+
+	text := func(s string) backend.OutputBlock {
+		return backend.OutputBlock{Type: "content", Content: &backend.TextContent{Type: "text", Text: s}}
+	}
+	update := func(status string, output ...backend.OutputBlock) {
+		transport.SimulateMethod("session/update", SessionUpdate{
+			SessionID: "test-session",
+			Update: UpdateContent{
+				SessionUpdate: "tool_call_update",
+				ToolCallID:    "tool-789",
+				Status:        status,
+				Output:        output,
+			},
+		}, nil)
+	}
+
+	// when: output arrives in two pieces, the second resending the first
+	update("running", text("building...\n"))
+	update("completed", text("building...\n"), text("ok\n"))
+
+	// then: both pieces kept once, in order
+	state := client.toolManager.Get("tool-789")
+	if len(state.Output) != 2 {
+		t.Fatalf("expected 2 output blocks, got %d: %+v", len(state.Output), state.Output)
+	}
+	if state.Output[0].Content.Text != "building...\n" || state.Output[1].Content.Text != "ok\n" {
+		t.Errorf("unexpected output order: %+v", state.Output)
+	}
+	if state.Status != "completed" {
+		t.Errorf("expected completed, got %s", state.Status)
+	}
+
+	// then: the transcript carries the full output too
+	entries := client.Transcript()
+	if len(entries) != 1 || entries[0].Output != "building...\n\nok\n" {
+		t.Errorf("unexpected transcript: %+v", entries)
+	}
+}
+
+func TestClient_ToolCallUpdate_GrowingCumulativeOutput(t *testing.T) {
+	// given: a running tool
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+	client.toolManager.Set(&backend.ToolState{ID: "tool-789", Status: "running", Title: "Bash"})
+
+	text := func(s string) backend.OutputBlock {
+		return backend.OutputBlock{Type: "content", Content: &backend.TextContent{Type: "text", Text: s}}
+	}
+	update := func(output ...backend.OutputBlock) {
+		transport.SimulateMethod("session/update", SessionUpdate{
+			SessionID: "test-session",
+			Update: UpdateContent{
+				SessionUpdate: "tool_call_update",
+				ToolCallID:    "tool-789",
+				Status:        "running",
+				Output:        output,
+			},
+		}, nil)
+	}
+
+	// when: the agent resends its output with the last block grown
+	update(text("header\n"), text("abc"))
+	update(text("header\n"), text("abcdef"))
+
+	// then: the grown block replaces the shorter one
+	state := client.toolManager.Get("tool-789")
+	if len(state.Output) != 2 || state.Output[1].Content.Text != "abcdef" {
+		t.Errorf("expected [header, abcdef], got %+v", state.Output)
+	}
+}
+
+func TestClient_ToolCallUpdate_KeepsRepeatedOutput(t *testing.T) {
+	// given: a running tool
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+	client.toolManager.Set(&backend.ToolState{ID: "tool-789", Status: "running", Title: "Bash"})
+
+	dot := backend.OutputBlock{Type: "content", Content: &backend.TextContent{Type: "text", Text: "."}}
+	update := func(output ...backend.OutputBlock) {
+		transport.SimulateMethod("session/update", SessionUpdate{
+			SessionID: "test-session",
+			Update: UpdateContent{
+				SessionUpdate: "tool_call_update",
+				ToolCallID:    "tool-789",
+				Status:        "running",
+				Output:        output,
+			},
+		}, nil)
+	}
+
+	// when: identical progress blocks arrive within and across updates
+	update(dot, dot)
+	update(dot)
+
+	// then: every one is kept
+	if state := client.toolManager.Get("tool-789"); len(state.Output) != 3 {
+		t.Errorf("expected 3 output blocks, got %d: %+v", len(state.Output), state.Output)
+	}
+}
+
+// Run with -race: the consumer reads emitted states while later updates
+// mutate the live one
+func TestClient_ToolStateEventsAreSnapshots(t *testing.T) {
+	// given: a running tool and a consumer reading every emitted state
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 100)
+	client := NewClient(ClientConfig{Transport: transport, EventChan: events})
+	client.toolManager.Set(&backend.ToolState{ID: "tool-race", Status: "running", Title: "Bash"})
+
+	done := make(chan int)
+	go func() {
+		seen := 0
+		for ev := range events {
+			state := ev.Data.(*backend.ToolState)
+			for range state.Input {
+			}
+			for _, block := range state.Output {
+				_ = block.Content.Text
+			}
+			seen = len(state.Output)
+		}
+		done <- seen
+	}()
+
+	// when: updates keep mutating the tool while events are consumed
+	for i := 0; i < 50; i++ {
+		transport.SimulateMethod("session/update", SessionUpdate{
+			SessionID: "test-session",
+			Update: UpdateContent{
+				SessionUpdate: "tool_call_update",
+				ToolCallID:    "tool-race",
+				Status:        "running",
+				RawInput:      map[string]any{"step": i},
+				Output: []backend.OutputBlock{{
+					Type:    "content",
+					Content: &backend.TextContent{Type: "text", Text: fmt.Sprintf("line %d\n", i)},
+				}},
+			},
+		}, nil)
+	}
+	close(events)
+
+	// then: the last event held all output, and no race was reported
+	if seen := <-done; seen != 50 {
+		t.Errorf("expected 50 output blocks in the last event, got %d", seen)
+	}
+}
+
+func TestClient_SecondTurnDoesNotInheritParent(t *testing.T) {
+	// given - a first turn whose Task never reported a terminal status
+	transport := NewMockTransport()
+	client := NewClient(ClientConfig{Transport: transport})
+	if err := client.SendPrompt("first", nil); err != nil {
+		t.Fatalf("SendPrompt: %v", err)
+	}
+	transport.SimulateMethod("session/update", SessionUpdate{Update: UpdateContent{
+		SessionUpdate: "tool_call", ToolCallID: "task-1", Title: "Task", Status: "in_progress",
+	}}, nil)
+	if got := client.toolManager.CurrentParent(); got != "task-1" {
+		t.Fatalf("expected task-1 as parent, got %q", got)
+	}
+
+	// when - the next prompt runs a tool
+	if err := client.SendPrompt("second", nil); err != nil {
+		t.Fatalf("SendPrompt: %v", err)
+	}
+	transport.SimulateMethod("session/update", SessionUpdate{Update: UpdateContent{
+		SessionUpdate: "tool_call", ToolCallID: "read-1", Title: "Read", ToolKind: "read", Status: "pending",
+	}}, nil)
+
+	// then - it sits at the root
+	state := client.toolManager.Get("read-1")
+	if state == nil {
+		t.Fatal("expected read-1 to be tracked")
+	}
+	if state.ParentID != "" {
+		t.Errorf("expected no parent, got %q", state.ParentID)
+	}
+}
+
+// crashingTransport starts a Task during session/prompt, then fails the
+// prompt as if the agent died before finishing it
+type crashingTransport struct {
+	*MockTransport
+}
+
+func (c *crashingTransport) Send(method string, params any) (json.RawMessage, error) {
+	if method != "session/prompt" {
+		return c.MockTransport.Send(method, params)
+	}
+	c.SimulateMethod("session/update", SessionUpdate{Update: UpdateContent{
+		SessionUpdate: "tool_call", ToolCallID: "task-1", Title: "Task", Status: "in_progress",
+	}}, nil)
+	return nil, errors.New("agent exited")
+}
+
+func TestClient_UnterminatedTaskDoesNotAdoptLaterTools(t *testing.T) {
+	// given - a prompt whose Task never reported a terminal status
+	transport := &crashingTransport{NewMockTransport()}
+	client := NewClient(ClientConfig{Transport: transport})
+	if err := client.SendPrompt("go", nil); err == nil {
+		t.Fatal("expected the prompt to fail")
+	}
+
+	// when - a tool is reported afterwards, and a Task arrives already failed
+	for _, u := range []UpdateContent{
+		{SessionUpdate: "tool_call", ToolCallID: "read-1", Title: "Read", ToolKind: "read", Status: "pending"},
+		{SessionUpdate: "tool_call", ToolCallID: "task-2", Title: "Task", Status: "failed"},
+		{SessionUpdate: "tool_call", ToolCallID: "read-2", Title: "Read", ToolKind: "read", Status: "pending"},
+	} {
+		transport.SimulateMethod("session/update", SessionUpdate{Update: u}, nil)
+	}
+
+	// then - both reads sit at the root
+	for _, id := range []string{"read-1", "read-2"} {
+		state := client.toolManager.Get(id)
+		if state == nil {
+			t.Fatalf("expected %s to be tracked", id)
+		}
+		if state.ParentID != "" {
+			t.Errorf("%s: expected no parent, got %q", id, state.ParentID)
+		}
+	}
+}
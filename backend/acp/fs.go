@@ -0,0 +1,109 @@
+package acp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ccui/backend"
+)
+
+// clientFSCapabilities are the file operations ccui serves for the agent
+var clientFSCapabilities = &FSCapabilities{
+	ReadTextFile:  true,
+	WriteTextFile: true,
+}
+
+// handleReadTextFile serves fs/read_text_file from disk
+func (c *Client) handleReadTextFile(params json.RawMessage, id *int) {
+	var req ReadTextFileParams
+	if err := json.Unmarshal(params, &req); err != nil || req.Path == "" {
+		c.transport.RespondError(id, &RPCError{Code: rpcInvalidParams, Message: "path is required"})
+		return
+	}
+	if err := c.confinePath(req.Path); err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: err.Error()})
+		return
+	}
+
+	data, err := os.ReadFile(req.Path)
+	if err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: err.Error()})
+		return
+	}
+
+	// served verbatim: the agent edits this text and writes it back whole
+	// through fs/write_text_file, so scrubbing it would corrupt the file
+	result, _ := json.Marshal(ReadTextFileResult{Content: sliceLines(string(data), req.Line, req.Limit)})
+	c.transport.Respond(id, result)
+}
+
+// handleWriteTextFile serves fs/write_text_file and records the change
+func (c *Client) handleWriteTextFile(params json.RawMessage, id *int) {
+	var req WriteTextFileParams
+	if err := json.Unmarshal(params, &req); err != nil || req.Path == "" {
+		c.transport.RespondError(id, &RPCError{Code: rpcInvalidParams, Message: "path is required"})
+		return
+	}
+	if c.readOnly {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: "writes are not allowed in read-only mode"})
+		return
+	}
+	if err := c.confinePath(req.Path); err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: err.Error()})
+		return
+	}
+
+	// a missing file is created from empty
+	original := ""
+	if data, err := os.ReadFile(req.Path); err == nil {
+		original = string(data)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(req.Path), 0755); err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: fmt.Sprintf("create directory: %s", err)})
+		return
+	}
+	if err := os.WriteFile(req.Path, []byte(req.Content), 0644); err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: fmt.Sprintf("write file: %s", err)})
+		return
+	}
+
+	contextLines := c.diffContext
+	if contextLines == 0 {
+		contextLines = backend.DefaultDiffContextLines
+	}
+	c.fileChangeStore.RecordChange(req.Path, original, req.Content, backend.BuildHunksWithContext(original, req.Content, contextLines))
+	c.emit(backend.EventFileChanges, c.fileChangeStore.GetAll())
+	c.transport.Respond(id, json.RawMessage(`{}`))
+}
+
+// confinePath rejects paths outside the workspace root, when one is set
+func (c *Client) confinePath(path string) error {
+	if c.workspaceRoot == "" {
+		return nil
+	}
+	return backend.ConfinePath(c.workspaceRoot, path)
+}
+
+// sliceLines returns limit lines of content starting at the 1-based line
+func sliceLines(content string, line, limit *int) string {
+	if line == nil && limit == nil {
+		return content
+	}
+	lines := strings.SplitAfter(content, "\n")
+	start := 0
+	if line != nil && *line > 1 {
+		start = *line - 1
+	}
+	if start >= len(lines) {
+		return ""
+	}
+	end := len(lines)
+	if limit != nil && *limit >= 0 && start+*limit < end {
+		end = start + *limit
+	}
+	return strings.Join(lines[start:end], "")
+}
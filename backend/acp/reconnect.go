@@ -0,0 +1,256 @@
+package acp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pingMethod is sent to check an idle agent is alive; any response,
+// including method-not-found, proves it is
+const pingMethod = "$/ping"
+
+// ReconnectOptions enable respawning a dead agent; zero fields use defaults
+type ReconnectOptions struct {
+	PingInterval time.Duration // how often the agent is pinged; 0 only notices exits
+	PingTimeout  time.Duration // an unanswered ping past this counts as dead, default 10s
+	MaxAttempts  int           // respawns tried before giving up, default 3
+	Backoff      time.Duration // delay before the first respawn, doubling per attempt, default 1s
+}
+
+// withDefaults fills unset limits
+func (o ReconnectOptions) withDefaults() ReconnectOptions {
+	if o.PingTimeout <= 0 {
+		o.PingTimeout = 10 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = time.Second
+	}
+	return o
+}
+
+// doneNotifier is implemented by transports that report when the agent goes away
+type doneNotifier interface {
+	Done() <-chan struct{}
+}
+
+// reconnectTransport forwards to the current agent's transport and, when
+// that agent dies, dials a new one and restores the session on it
+type reconnectTransport struct {
+	opts    ReconnectOptions
+	dial    func() (Transport, error)
+	restore func() error
+	status  func(status ReconnectStatus, stop <-chan struct{}) // must give up sending once stop closes
+
+	reportMu sync.Mutex // held while a status is sent; Close waits on it
+	mu       sync.Mutex
+	inner    Transport
+	handler  func(method string, params json.RawMessage, id *int)
+	closed   bool
+	stop     chan struct{}
+}
+
+// newReconnectTransport wraps inner; call watch once the session is set up
+func newReconnectTransport(inner Transport, opts ReconnectOptions, dial func() (Transport, error)) *reconnectTransport {
+	return &reconnectTransport{
+		opts:  opts.withDefaults(),
+		dial:  dial,
+		inner: inner,
+		stop:  make(chan struct{}),
+	}
+}
+
+// current returns the live inner transport
+func (t *reconnectTransport) current() Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inner
+}
+
+// Send forwards to the current agent
+func (t *reconnectTransport) Send(method string, params any) (json.RawMessage, error) {
+	return t.current().Send(method, params)
+}
+
+// Notify forwards to the current agent
+func (t *reconnectTransport) Notify(method string, params any) {
+	t.current().Notify(method, params)
+}
+
+// Respond forwards to the current agent
+func (t *reconnectTransport) Respond(id *int, result json.RawMessage) {
+	t.current().Respond(id, result)
+}
+
+// RespondError forwards to the current agent
+func (t *reconnectTransport) RespondError(id *int, err *RPCError) {
+	t.current().RespondError(id, err)
+}
+
+// OnMethod registers the handler on this and every later agent
+func (t *reconnectTransport) OnMethod(handler func(method string, params json.RawMessage, id *int)) {
+	t.mu.Lock()
+	t.handler = handler
+	inner := t.inner
+	t.mu.Unlock()
+	inner.OnMethod(handler)
+}
+
+// Close stops watching and closes the current agent's transport
+func (t *reconnectTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.stop)
+	inner := t.inner
+	t.mu.Unlock()
+	err := inner.Close()
+	// wait out a report in flight; stop makes it give up rather than block
+	t.reportMu.Lock()
+	t.reportMu.Unlock()
+	return err
+}
+
+// watch restores the session each time the agent dies, until Close or
+// every respawn attempt fails; restore runs on the new transport and
+// status reports each attempt, giving up once its stop channel closes
+func (t *reconnectTransport) watch(restore func() error, status func(ReconnectStatus, <-chan struct{})) {
+	t.restore, t.status = restore, status
+	for {
+		if !t.waitForDeath(t.current()) {
+			return
+		}
+		if !t.reconnect() {
+			return
+		}
+	}
+}
+
+// waitForDeath blocks until inner exits or stops answering pings; it
+// returns false when the transport was closed instead
+func (t *reconnectTransport) waitForDeath(inner Transport) bool {
+	var done <-chan struct{}
+	if n, ok := inner.(doneNotifier); ok {
+		done = n.Done()
+	}
+	var tick <-chan time.Time
+	if t.opts.PingInterval > 0 {
+		ticker := time.NewTicker(t.opts.PingInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-t.stop:
+			return false
+		case <-done:
+			// Close also ends the inner transport
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return !t.closed
+		case <-tick:
+			if !ping(inner, t.opts.PingTimeout) {
+				inner.Close()
+				return true
+			}
+		}
+	}
+}
+
+// ping reports whether inner answers a request within timeout
+func ping(inner Transport, timeout time.Duration) bool {
+	errc := make(chan error, 1)
+	go func() {
+		_, err := inner.Send(pingMethod, struct{}{})
+		errc <- err
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errc:
+		var rpcErr *RPCError
+		return err == nil || errors.As(err, &rpcErr)
+	case <-timer.C:
+		return false
+	}
+}
+
+// reconnect dials and restores with exponential backoff; it returns false
+// once attempts run out or the transport is closed
+func (t *reconnectTransport) reconnect() bool {
+	backoff := t.opts.Backoff
+	var lastErr error
+	for attempt := 1; attempt <= t.opts.MaxAttempts; attempt++ {
+		if !t.report(ReconnectStatus{Status: "reconnecting", Attempt: attempt, MaxAttempts: t.opts.MaxAttempts}) {
+			return false
+		}
+		select {
+		case <-t.stop:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		lastErr = t.attempt()
+		if errors.Is(lastErr, errTransportClosed) {
+			return false
+		}
+		if lastErr == nil {
+			return t.report(ReconnectStatus{Status: "reconnected", Attempt: attempt, MaxAttempts: t.opts.MaxAttempts})
+		}
+	}
+	t.report(ReconnectStatus{Status: "failed", Attempt: t.opts.MaxAttempts, MaxAttempts: t.opts.MaxAttempts, Error: lastErr.Error()})
+	return false
+}
+
+// report sends status unless the transport is closed, returning false if
+// it is. Close waits for a report in flight, so the session's event channel
+// is never written after Close returns; the send itself happens outside mu
+// and gives up on stop, so an undrained channel cannot wedge Close.
+func (t *reconnectTransport) report(status ReconnectStatus) bool {
+	t.reportMu.Lock()
+	defer t.reportMu.Unlock()
+	t.mu.Lock()
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return false
+	}
+	t.status(status, t.stop)
+	return true
+}
+
+// errTransportClosed aborts a reconnect that raced with Close
+var errTransportClosed = errors.New("transport closed")
+
+// attempt dials one new agent, swaps it in and restores the session
+func (t *reconnectTransport) attempt() error {
+	inner, err := t.dial()
+	if err != nil {
+		return fmt.Errorf("respawn agent: %w", err)
+	}
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		inner.Close()
+		return errTransportClosed
+	}
+	inner.OnMethod(t.handler)
+	t.inner = inner
+	t.mu.Unlock()
+
+	if err := t.restore(); err != nil {
+		inner.Close()
+		return err
+	}
+	return nil
+}
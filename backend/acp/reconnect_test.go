@@ -0,0 +1,251 @@
+package acp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"ccui/backend"
+)
+
+// hangingTransport never answers requests, like a wedged agent
+type hangingTransport struct {
+	*MockTransport
+	closed chan struct{}
+}
+
+func (h *hangingTransport) Send(method string, params any) (json.RawMessage, error) {
+	<-h.closed
+	return nil, errors.New("connection closed")
+}
+
+func (h *hangingTransport) Close() error {
+	close(h.closed)
+	return nil
+}
+
+// loadableAgent returns a mock agent that initializes and accepts session/load
+func loadableAgent() *MockTransport {
+	agent := NewMockTransport()
+	agent.SetResponse("initialize", map[string]any{
+		"protocolVersion":   ProtocolVersion,
+		"agentCapabilities": map[string]any{"loadSession": true},
+	})
+	agent.SetResponse("session/load", map[string]any{})
+	return agent
+}
+
+// reconnectStatuses reads reconnecting events until one has a final status
+func reconnectStatuses(t *testing.T, events <-chan backend.Event) []ReconnectStatus {
+	t.Helper()
+	var statuses []ReconnectStatus
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != backend.EventReconnecting {
+				continue
+			}
+			status := ev.Data.(ReconnectStatus)
+			statuses = append(statuses, status)
+			if status.Status != "reconnecting" {
+				return statuses
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for reconnect, got %+v", statuses)
+		}
+	}
+}
+
+// sentMethod returns the params of the first request for method, if any
+func sentMethod(m *MockTransport, method string) (any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, msg := range m.sentMessages {
+		if msg.Method == method {
+			return msg.Params, true
+		}
+	}
+	return nil, false
+}
+
+func TestReconnect_TransportDeathReloadsSession(t *testing.T) {
+	// given: a client on a live stdio transport with an established session
+	stdoutR, stdoutW := io.Pipe()
+	_, stdinW := io.Pipe()
+	inner := NewStdioTransport(stdinW, stdoutR)
+	respawned := loadableAgent()
+	dials := 0
+	rt := newReconnectTransport(inner, ReconnectOptions{Backoff: time.Millisecond}, func() (Transport, error) {
+		dials++
+		return respawned, nil
+	})
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: rt, EventChan: events})
+	client.sessionID = "sess-1"
+	client.session = sessionRequest{cwd: "/work"}
+	go rt.watch(client.reload, func(status ReconnectStatus, stop <-chan struct{}) {
+		client.emitUntil(backend.EventReconnecting, status, stop)
+	})
+	defer rt.Close()
+
+	// when: the agent's stdout closes
+	stdoutW.Close()
+
+	// then: the agent is respawned once and the session reloaded on it
+	statuses := reconnectStatuses(t, events)
+	if len(statuses) != 2 || statuses[0].Status != "reconnecting" || statuses[1].Status != "reconnected" {
+		t.Fatalf("expected reconnecting then reconnected, got %+v", statuses)
+	}
+	if dials != 1 {
+		t.Errorf("expected 1 respawn, got %d", dials)
+	}
+	params, ok := sentMethod(respawned, "session/load")
+	if !ok {
+		t.Fatal("expected session/load on the respawned agent")
+	}
+	load := params.(SessionLoadParams)
+	if load.SessionID != "sess-1" || load.CWD != "/work" {
+		t.Errorf("unexpected session/load params: %+v", load)
+	}
+
+	// and: later requests go to the new agent
+	client.Cancel()
+	if _, ok := sentMethod(respawned, "session/cancel"); !ok {
+		t.Error("expected session/cancel on the respawned agent")
+	}
+}
+
+func TestReconnect_UnansweredPingCountsAsDeath(t *testing.T) {
+	// given: an agent that stops answering
+	hung := &hangingTransport{MockTransport: NewMockTransport(), closed: make(chan struct{})}
+	rt := newReconnectTransport(hung, ReconnectOptions{
+		PingInterval: time.Millisecond,
+		PingTimeout:  10 * time.Millisecond,
+		Backoff:      time.Millisecond,
+	}, func() (Transport, error) {
+		return loadableAgent(), nil
+	})
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: rt, EventChan: events})
+	client.sessionID = "sess-1"
+	defer rt.Close()
+
+	// when
+	go rt.watch(client.reload, func(status ReconnectStatus, stop <-chan struct{}) {
+		client.emitUntil(backend.EventReconnecting, status, stop)
+	})
+
+	// then: the hung agent is closed and replaced
+	statuses := reconnectStatuses(t, events)
+	if last := statuses[len(statuses)-1]; last.Status != "reconnected" {
+		t.Errorf("expected reconnected, got %+v", statuses)
+	}
+	select {
+	case <-hung.closed:
+	default:
+		t.Error("expected the hung transport to be closed")
+	}
+}
+
+func TestReconnect_GivesUpWithoutSessionLoad(t *testing.T) {
+	// given: respawned agents that cannot load sessions
+	stdoutR, stdoutW := io.Pipe()
+	_, stdinW := io.Pipe()
+	rt := newReconnectTransport(NewStdioTransport(stdinW, stdoutR), ReconnectOptions{MaxAttempts: 2, Backoff: time.Millisecond}, func() (Transport, error) {
+		agent := NewMockTransport()
+		agent.SetResponse("initialize", map[string]any{"protocolVersion": ProtocolVersion})
+		return agent, nil
+	})
+	events := make(chan backend.Event, 10)
+	client := NewClient(ClientConfig{Transport: rt, EventChan: events})
+	client.sessionID = "sess-1"
+	go rt.watch(client.reload, func(status ReconnectStatus, stop <-chan struct{}) {
+		client.emitUntil(backend.EventReconnecting, status, stop)
+	})
+	defer rt.Close()
+
+	// when
+	stdoutW.Close()
+
+	// then: both attempts are reported, then the failure
+	statuses := reconnectStatuses(t, events)
+	if len(statuses) != 3 {
+		t.Fatalf("expected 2 attempts and a failure, got %+v", statuses)
+	}
+	if failed := statuses[2]; failed.Status != "failed" || failed.Error != "agent does not support session/load" {
+		t.Errorf("unexpected failure status: %+v", failed)
+	}
+}
+
+func TestReconnect_CloseStopsWatching(t *testing.T) {
+	// given: a watched transport
+	stdoutR, _ := io.Pipe()
+	_, stdinW := io.Pipe()
+	dialed := make(chan struct{}, 1)
+	rt := newReconnectTransport(NewStdioTransport(stdinW, stdoutR), ReconnectOptions{Backoff: time.Millisecond}, func() (Transport, error) {
+		dialed <- struct{}{}
+		return loadableAgent(), nil
+	})
+	events := make(chan backend.Event, 10)
+	done := make(chan struct{})
+	go func() {
+		rt.watch(func() error { return nil }, func(status ReconnectStatus, stop <-chan struct{}) {
+			events <- backend.Event{Type: backend.EventReconnecting, Data: status}
+		})
+		close(done)
+	}()
+
+	// when: the session is closed, which also ends the inner transport
+	rt.Close()
+
+	// then: watch returns without reconnecting
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop after Close")
+	}
+	select {
+	case <-dialed:
+		t.Error("expected no respawn after Close")
+	case ev := <-events:
+		t.Errorf("expected no reconnect events, got %+v", ev)
+	default:
+	}
+}
+
+func TestReconnect_CloseWhileReportUndrained(t *testing.T) {
+	// given: a dead agent whose status is stuck on an event channel nobody reads
+	stdoutR, stdoutW := io.Pipe()
+	_, stdinW := io.Pipe()
+	rt := newReconnectTransport(NewStdioTransport(stdinW, stdoutR), ReconnectOptions{Backoff: time.Hour}, func() (Transport, error) {
+		return loadableAgent(), nil
+	})
+	events := make(chan backend.Event)
+	client := NewClient(ClientConfig{Transport: rt, EventChan: events})
+	reporting := make(chan struct{}, 1)
+	go rt.watch(client.reload, func(status ReconnectStatus, stop <-chan struct{}) {
+		reporting <- struct{}{}
+		client.emitUntil(backend.EventReconnecting, status, stop)
+	})
+	stdoutW.Close()
+	select {
+	case <-reporting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent death was never reported")
+	}
+
+	// when: the session closes
+	closed := make(chan error, 1)
+	go func() { closed <- rt.Close() }()
+
+	// then: Close returns and the channel can be closed without a late send
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the undrained report")
+	}
+	close(events)
+}
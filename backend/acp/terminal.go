@@ -0,0 +1,87 @@
+package acp
+
+import (
+	"encoding/json"
+
+	"ccui/backend"
+)
+
+// TerminalHost runs agent-requested commands in terminals ccui can display
+type TerminalHost interface {
+	Create(req TerminalCreateParams) (string, error)
+	Output(terminalID string) (TerminalOutputResult, error)
+	WaitForExit(terminalID string) (TerminalExitStatus, error)
+	Kill(terminalID string) error
+	Release(terminalID string) error
+}
+
+// handleTerminalMethod serves the terminal/* methods through the terminal host
+func (c *Client) handleTerminalMethod(method string, params json.RawMessage, id *int) {
+	if c.terminalHost == nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcMethodNotFound, Message: "terminal capability not enabled"})
+		return
+	}
+
+	if method == "terminal/create" {
+		c.handleTerminalCreate(params, id)
+		return
+	}
+
+	var req TerminalParams
+	if err := json.Unmarshal(params, &req); err != nil || req.TerminalID == "" {
+		c.transport.RespondError(id, &RPCError{Code: rpcInvalidParams, Message: "terminalId is required"})
+		return
+	}
+
+	switch method {
+	case "terminal/output":
+		out, err := c.terminalHost.Output(req.TerminalID)
+		c.respondTerminal(id, out, err)
+	case "terminal/wait_for_exit":
+		// blocks until the command exits, so keep the read loop free for kill
+		go func() {
+			status, err := c.terminalHost.WaitForExit(req.TerminalID)
+			c.respondTerminal(id, status, err)
+		}()
+	case "terminal/kill":
+		c.respondTerminal(id, struct{}{}, c.terminalHost.Kill(req.TerminalID))
+	case "terminal/release":
+		c.respondTerminal(id, struct{}{}, c.terminalHost.Release(req.TerminalID))
+	default:
+		c.transport.RespondError(id, &RPCError{Code: rpcMethodNotFound, Message: "unknown method " + method})
+	}
+}
+
+func (c *Client) handleTerminalCreate(params json.RawMessage, id *int) {
+	var req TerminalCreateParams
+	if err := json.Unmarshal(params, &req); err != nil || req.Command == "" {
+		c.transport.RespondError(id, &RPCError{Code: rpcInvalidParams, Message: "command is required"})
+		return
+	}
+	if c.readOnly {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: "commands are not allowed in read-only mode"})
+		return
+	}
+
+	terminalID, err := c.terminalHost.Create(req)
+	if err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: err.Error()})
+		return
+	}
+	c.emit(backend.EventAgentTerminal, backend.AgentTerminal{
+		TerminalID: terminalID,
+		Command:    req.Command,
+		Args:       req.Args,
+	})
+	c.respondTerminal(id, TerminalCreateResult{TerminalID: terminalID}, nil)
+}
+
+// respondTerminal sends result, or err as an RPC error
+func (c *Client) respondTerminal(id *int, result any, err error) {
+	if err != nil {
+		c.transport.RespondError(id, &RPCError{Code: rpcInternalError, Message: err.Error()})
+		return
+	}
+	data, _ := json.Marshal(result)
+	c.transport.Respond(id, data)
+}
@@ -0,0 +1,195 @@
+package acp
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ccui/backend"
+)
+
+// fakeTerminalHost records calls and returns canned results
+type fakeTerminalHost struct {
+	mu      sync.Mutex
+	created []TerminalCreateParams
+	killed  []string
+	exit    chan TerminalExitStatus
+}
+
+func newFakeTerminalHost() *fakeTerminalHost {
+	return &fakeTerminalHost{exit: make(chan TerminalExitStatus, 1)}
+}
+
+func (f *fakeTerminalHost) Create(req TerminalCreateParams) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, req)
+	return "term-1", nil
+}
+
+func (f *fakeTerminalHost) Output(terminalID string) (TerminalOutputResult, error) {
+	if terminalID != "term-1" {
+		return TerminalOutputResult{}, errors.New("terminal not found")
+	}
+	return TerminalOutputResult{Output: "hello\n"}, nil
+}
+
+func (f *fakeTerminalHost) WaitForExit(terminalID string) (TerminalExitStatus, error) {
+	return <-f.exit, nil
+}
+
+func (f *fakeTerminalHost) Kill(terminalID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.killed = append(f.killed, terminalID)
+	return nil
+}
+
+func (f *fakeTerminalHost) Release(terminalID string) error {
+	return nil
+}
+
+func decodeResult(t *testing.T, resp map[string]any, v any) {
+	t.Helper()
+	if resp["error"] != nil {
+		t.Fatalf("unexpected error response: %+v", resp["error"])
+	}
+	if err := json.Unmarshal(resp["result"].(json.RawMessage), v); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+}
+
+func TestClient_Initialize_AdvertisesTerminal(t *testing.T) {
+	transport := NewMockTransport()
+	client := NewClient(ClientConfig{Transport: transport, TerminalHost: newFakeTerminalHost()})
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	params := transport.sentMessages[0].Params.(InitializeParams)
+	if !params.ClientCapabilities.Terminal {
+		t.Error("expected terminal capability advertised")
+	}
+}
+
+func TestClient_TerminalCreateAndOutput(t *testing.T) {
+	// given: a client with a terminal host
+	transport := NewMockTransport()
+	events := make(chan backend.Event, 10)
+	host := newFakeTerminalHost()
+	NewClient(ClientConfig{Transport: transport, EventChan: events, TerminalHost: host})
+
+	// when: the agent creates a terminal
+	id := 1
+	transport.SimulateMethod("terminal/create", TerminalCreateParams{
+		SessionID: "s",
+		Command:   "go",
+		Args:      []string{"test", "./..."},
+		Env:       []EnvVariable{{Name: "GOFLAGS", Value: "-count=1"}},
+		CWD:       "/tmp",
+	}, &id)
+
+	// then: the host runs it and the UI is told about the terminal
+	var created TerminalCreateResult
+	decodeResult(t, lastResponse(t, transport), &created)
+	if created.TerminalID != "term-1" {
+		t.Errorf("got terminal id %q", created.TerminalID)
+	}
+	if len(host.created) != 1 || host.created[0].Command != "go" || host.created[0].Env[0].Name != "GOFLAGS" {
+		t.Errorf("unexpected create calls: %+v", host.created)
+	}
+	select {
+	case ev := <-events:
+		term, ok := ev.Data.(backend.AgentTerminal)
+		if ev.Type != backend.EventAgentTerminal || !ok || term.TerminalID != "term-1" || term.Command != "go" {
+			t.Errorf("unexpected event: %+v", ev)
+		}
+	default:
+		t.Error("expected agent_terminal event")
+	}
+
+	// when: the agent reads output
+	id = 2
+	transport.SimulateMethod("terminal/output", TerminalParams{SessionID: "s", TerminalID: "term-1"}, &id)
+
+	// then
+	var out TerminalOutputResult
+	decodeResult(t, lastResponse(t, transport), &out)
+	if out.Output != "hello\n" || out.ExitStatus != nil {
+		t.Errorf("unexpected output: %+v", out)
+	}
+}
+
+func TestClient_TerminalWaitForExitDoesNotBlock(t *testing.T) {
+	// given: a command that has not exited
+	transport := NewMockTransport()
+	host := newFakeTerminalHost()
+	NewClient(ClientConfig{Transport: transport, TerminalHost: host})
+
+	// when: the agent waits, then kills while waiting
+	id := 3
+	transport.SimulateMethod("terminal/wait_for_exit", TerminalParams{TerminalID: "term-1"}, &id)
+	killID := 4
+	transport.SimulateMethod("terminal/kill", TerminalParams{TerminalID: "term-1"}, &killID)
+
+	// then: kill is handled while wait is pending
+	if len(host.killed) != 1 {
+		t.Fatalf("expected kill handled, got %v", host.killed)
+	}
+
+	// when: the command exits
+	code := 137
+	host.exit <- TerminalExitStatus{ExitCode: &code}
+
+	// then: wait responds with the exit status
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp := lastResponse(t, transport)
+		if resp["id"].(*int) == &id {
+			var status TerminalExitStatus
+			decodeResult(t, resp, &status)
+			if status.ExitCode == nil || *status.ExitCode != 137 {
+				t.Errorf("unexpected exit status: %+v", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for wait_for_exit response")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClient_TerminalErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ClientConfig
+		method   string
+		params   any
+		wantCode int
+	}{
+		{"no host", ClientConfig{}, "terminal/create", TerminalCreateParams{Command: "ls"}, rpcMethodNotFound},
+		{"missing command", ClientConfig{TerminalHost: newFakeTerminalHost()}, "terminal/create", TerminalCreateParams{}, rpcInvalidParams},
+		{"read-only", ClientConfig{TerminalHost: newFakeTerminalHost(), ReadOnly: true}, "terminal/create", TerminalCreateParams{Command: "ls"}, rpcInternalError},
+		{"missing terminal id", ClientConfig{TerminalHost: newFakeTerminalHost()}, "terminal/output", TerminalParams{}, rpcInvalidParams},
+		{"unknown terminal", ClientConfig{TerminalHost: newFakeTerminalHost()}, "terminal/output", TerminalParams{TerminalID: "nope"}, rpcInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := NewMockTransport()
+			tt.cfg.Transport = transport
+			NewClient(tt.cfg)
+
+			id := 5
+			transport.SimulateMethod(tt.method, tt.params, &id)
+
+			rpcErr, ok := lastResponse(t, transport)["error"].(*RPCError)
+			if !ok || rpcErr.Code != tt.wantCode {
+				t.Errorf("expected error code %d, got %+v", tt.wantCode, rpcErr)
+			}
+		})
+	}
+}
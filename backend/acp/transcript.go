@@ -0,0 +1,114 @@
+package acp
+
+import (
+	"strings"
+	"sync"
+
+	"ccui/backend"
+)
+
+// Transcript entry roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleThought   = "thought"
+	RoleTool      = "tool"
+)
+
+// TranscriptEntry is one message or tool call in a session's history
+type TranscriptEntry struct {
+	Role       string         `json:"role"`
+	Text       string         `json:"text,omitempty"`
+	ToolCallID string         `json:"toolCallId,omitempty"`
+	ToolName   string         `json:"toolName,omitempty"`
+	Title      string         `json:"title,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Input      map[string]any `json:"input,omitempty"`
+	Output     string         `json:"output,omitempty"`
+}
+
+// transcript assembles streamed updates into whole entries; the zero
+// value is ready to use
+type transcript struct {
+	mu      sync.Mutex
+	entries []TranscriptEntry
+	tools   map[string]int // tool call ID -> entry index
+	outputs map[string][]backend.OutputBlock
+}
+
+// addUser records a prompt, starting a new turn
+func (t *transcript) addUser(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, TranscriptEntry{Role: RoleUser, Text: text})
+}
+
+// appendText extends the last entry when it has the same role, so chunks
+// of one message stay together until a tool call or prompt interrupts them
+func (t *transcript) appendText(role, text string) {
+	if text == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.entries); n > 0 && t.entries[n-1].Role == role {
+		t.entries[n-1].Text += text
+		return
+	}
+	t.entries = append(t.entries, TranscriptEntry{Role: role, Text: text})
+}
+
+// recordTool adds a tool call or merges an update into it
+func (t *transcript) recordTool(u UpdateContent, toolName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tools == nil {
+		t.tools = make(map[string]int)
+		t.outputs = make(map[string][]backend.OutputBlock)
+	}
+	i, ok := t.tools[u.ToolCallID]
+	if !ok {
+		i = len(t.entries)
+		t.tools[u.ToolCallID] = i
+		t.entries = append(t.entries, TranscriptEntry{Role: RoleTool, ToolCallID: u.ToolCallID})
+	}
+	e := &t.entries[i]
+	if e.ToolName == "" {
+		e.ToolName = toolName
+	}
+	if u.Title != "" {
+		e.Title = u.Title
+	}
+	if u.Status != "" {
+		e.Status = u.Status
+	}
+	if u.RawInput != nil {
+		e.Input = u.RawInput
+	}
+	t.outputs[u.ToolCallID] = appendOutput(t.outputs[u.ToolCallID], u.Output)
+	if out := toolOutputText(u.RawOutput, t.outputs[u.ToolCallID]); out != "" {
+		e.Output = out
+	}
+}
+
+// snapshot returns a copy of the entries
+func (t *transcript) snapshot() []TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TranscriptEntry(nil), t.entries...)
+}
+
+// toolOutputText prefers the raw output, else joins the text blocks
+func toolOutputText(raw *ToolRawOutput, blocks []backend.OutputBlock) string {
+	if raw != nil && raw.Output != "" {
+		return raw.Output
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Content != nil && block.Content.Text != "" {
+			parts = append(parts, block.Content.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
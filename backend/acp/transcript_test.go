@@ -0,0 +1,69 @@
+package acp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"ccui/backend"
+)
+
+func chunk(kind, text string) SessionUpdate {
+	content, _ := json.Marshal(backend.TextContent{Type: "text", Text: text})
+	return SessionUpdate{SessionID: "s", Update: UpdateContent{SessionUpdate: kind, Content: content}}
+}
+
+func TestClient_Transcript(t *testing.T) {
+	// given: a client that has sent a prompt
+	transport := NewMockTransport()
+	client := NewClient(ClientConfig{Transport: transport})
+	if err := client.SendPrompt("fix the bug", nil); err != nil {
+		t.Fatalf("SendPrompt: %v", err)
+	}
+
+	// when: the agent thinks, replies in chunks, runs a tool, then replies again
+	for _, u := range []SessionUpdate{
+		chunk("agent_thought_chunk", "look at "),
+		chunk("agent_thought_chunk", "main.go"),
+		chunk("agent_message_chunk", "Let me "),
+		chunk("agent_message_chunk", "check."),
+		{Update: UpdateContent{
+			SessionUpdate: "tool_call",
+			ToolCallID:    "tool-1",
+			Title:         "Read",
+			Status:        "pending",
+			RawInput:      map[string]any{"file_path": "main.go"},
+		}},
+		{Update: UpdateContent{
+			SessionUpdate: "tool_call_update",
+			ToolCallID:    "tool-1",
+			Status:        "completed",
+			Output: []backend.OutputBlock{
+				{Type: "content", Content: &backend.TextContent{Type: "text", Text: "package main"}},
+			},
+		}},
+		chunk("agent_message_chunk", "Found it."),
+	} {
+		transport.SimulateMethod("session/update", u, nil)
+	}
+
+	// then: chunks are joined per message and the tool call carries its result
+	got := client.Transcript()
+	want := []TranscriptEntry{
+		{Role: RoleUser, Text: "fix the bug"},
+		{Role: RoleThought, Text: "look at main.go"},
+		{Role: RoleAssistant, Text: "Let me check."},
+		{Role: RoleTool, ToolCallID: "tool-1", ToolName: "Read", Title: "Read", Status: "completed",
+			Input: map[string]any{"file_path": "main.go"}, Output: "package main"},
+		{Role: RoleAssistant, Text: "Found it."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected transcript:\n got %+v\nwant %+v", got, want)
+	}
+
+	// then: the returned slice is a copy
+	got[0].Text = "changed"
+	if client.Transcript()[0].Text != "fix the bug" {
+		t.Error("Transcript should return a copy")
+	}
+}
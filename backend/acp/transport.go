@@ -2,12 +2,18 @@ package acp
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
+	"time"
 )
 
+// ErrRequestTimeout is returned when a request gets no response within the transport timeout
+var ErrRequestTimeout = errors.New("request timed out")
+
 // Transport handles JSON-RPC communication
 type Transport interface {
 	// Send sends a request and blocks for response
@@ -19,6 +25,9 @@ type Transport interface {
 	// Respond sends a response to an incoming request
 	Respond(id *int, result json.RawMessage)
 
+	// RespondError sends an error response to an incoming request
+	RespondError(id *int, err *RPCError)
+
 	// OnMethod registers a handler for incoming methods (notifications)
 	OnMethod(handler func(method string, params json.RawMessage, id *int))
 
@@ -26,62 +35,89 @@ type Transport interface {
 	Close() error
 }
 
+// rpcResponse carries a request's result or error over a single channel
+type rpcResponse struct {
+	result json.RawMessage
+	err    *RPCError
+}
+
 // StdioTransport implements Transport over stdin/stdout pipes
 type StdioTransport struct {
 	stdin     io.WriteCloser
-	stdout    *bufio.Scanner
-	callbacks map[int]chan json.RawMessage
-	errors    map[int]chan *RPCError
+	stdout    *bufio.Reader
+	callbacks map[int]chan rpcResponse
 	msgID     int
 	mu        sync.Mutex
 	handler   func(method string, params json.RawMessage, id *int)
+	timeout   time.Duration // 0 waits indefinitely
 	done      chan struct{}
 	closeOnce sync.Once
 }
 
-// NewStdioTransport creates a new transport
+// NewStdioTransport creates a new transport whose requests wait indefinitely
 func NewStdioTransport(stdin io.WriteCloser, stdout io.Reader) *StdioTransport {
+	return NewStdioTransportWithTimeout(stdin, stdout, 0)
+}
+
+// NewStdioTransportWithTimeout creates a new transport that fails requests
+// unanswered after timeout; 0 disables the timeout
+func NewStdioTransportWithTimeout(stdin io.WriteCloser, stdout io.Reader, timeout time.Duration) *StdioTransport {
 	t := &StdioTransport{
 		stdin:     stdin,
-		stdout:    bufio.NewScanner(stdout),
-		callbacks: make(map[int]chan json.RawMessage),
-		errors:    make(map[int]chan *RPCError),
+		stdout:    bufio.NewReader(stdout),
+		callbacks: make(map[int]chan rpcResponse),
+		timeout:   timeout,
 		done:      make(chan struct{}),
 	}
 	go t.readLoop()
 	return t
 }
 
+// readLoop dispatches newline-delimited messages; lines may be arbitrarily
+// long, unlike bufio.Scanner's 64KB token limit
 func (t *StdioTransport) readLoop() {
-	for t.stdout.Scan() {
-		line := t.stdout.Bytes()
-		if len(line) == 0 || line[0] != '{' {
-			continue
+	for {
+		line, err := t.stdout.ReadBytes('\n')
+		if len(line) > 0 {
+			t.dispatch(bytes.TrimRight(line, "\r\n"))
 		}
-
-		var msg JSONRPCMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
+		if err != nil {
+			// the agent is gone; fail pending and future requests
+			t.closeOnce.Do(func() {
+				close(t.done)
+			})
+			return
 		}
+	}
+}
 
-		// Check Method BEFORE ID - requests have both
-		if msg.Method != "" {
-			if t.handler != nil {
-				t.handler(msg.Method, msg.Params, msg.ID)
-			}
+// dispatch routes one message to the method handler or a pending request
+func (t *StdioTransport) dispatch(line []byte) {
+	if len(line) == 0 || line[0] != '{' {
+		return
+	}
+
+	var msg JSONRPCMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return
+	}
+
+	// Check Method BEFORE ID - requests have both
+	if msg.Method != "" {
+		if t.handler != nil {
+			t.handler(msg.Method, msg.Params, msg.ID)
 		} else if msg.ID != nil {
-			t.mu.Lock()
-			if ch, ok := t.callbacks[*msg.ID]; ok {
-				if msg.Error != nil {
-					if errCh, ok := t.errors[*msg.ID]; ok {
-						errCh <- msg.Error
-					}
-				}
-				ch <- msg.Result
-				delete(t.callbacks, *msg.ID)
-				delete(t.errors, *msg.ID)
-			}
-			t.mu.Unlock()
+			// nothing can answer yet; fail the request rather than leave the agent waiting
+			t.RespondError(msg.ID, &RPCError{Code: rpcMethodNotFound, Message: "method not found: " + msg.Method})
+		}
+	} else if msg.ID != nil {
+		t.mu.Lock()
+		ch, ok := t.callbacks[*msg.ID]
+		delete(t.callbacks, *msg.ID)
+		t.mu.Unlock()
+		if ok {
+			// buffered and sent at most once, so this never blocks
+			ch <- rpcResponse{result: msg.Result, err: msg.Error}
 		}
 	}
 }
@@ -91,10 +127,8 @@ func (t *StdioTransport) Send(method string, params any) (json.RawMessage, error
 	t.mu.Lock()
 	t.msgID++
 	id := t.msgID
-	ch := make(chan json.RawMessage, 1)
-	errCh := make(chan *RPCError, 1)
+	ch := make(chan rpcResponse, 1)
 	t.callbacks[id] = ch
-	t.errors[id] = errCh
 	t.mu.Unlock()
 
 	paramsJSON, _ := json.Marshal(params)
@@ -107,28 +141,39 @@ func (t *StdioTransport) Send(method string, params any) (json.RawMessage, error
 
 	data, _ := json.Marshal(msg)
 	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
-		t.mu.Lock()
-		delete(t.callbacks, id)
-		delete(t.errors, id)
-		t.mu.Unlock()
+		t.forget(id)
 		return nil, err
 	}
 
+	var timeout <-chan time.Time
+	if t.timeout > 0 {
+		timer := time.NewTimer(t.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
 	select {
-	case result := <-ch:
-		select {
-		case rpcErr := <-errCh:
-			if rpcErr != nil {
-				return nil, fmt.Errorf("rpc error %d: %s", rpcErr.Code, rpcErr.Message)
-			}
-		default:
+	case resp := <-ch:
+		if resp.err != nil {
+			return nil, resp.err
 		}
-		return result, nil
+		return resp.result, nil
 	case <-t.done:
 		return nil, fmt.Errorf("connection closed")
+	case <-timeout:
+		// a late response finds no callback and is dropped by readLoop
+		t.forget(id)
+		return nil, fmt.Errorf("%s after %s: %w", method, t.timeout, ErrRequestTimeout)
 	}
 }
 
+// forget removes the callback registered for a request
+func (t *StdioTransport) forget(id int) {
+	t.mu.Lock()
+	delete(t.callbacks, id)
+	t.mu.Unlock()
+}
+
 // Notify sends a notification (no response expected)
 func (t *StdioTransport) Notify(method string, params any) {
 	paramsJSON, _ := json.Marshal(params)
@@ -152,11 +197,27 @@ func (t *StdioTransport) Respond(id *int, result json.RawMessage) {
 	t.stdin.Write(append(data, '\n'))
 }
 
+// RespondError sends an error response to an incoming request
+func (t *StdioTransport) RespondError(id *int, err *RPCError) {
+	msg := JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   err,
+	}
+	data, _ := json.Marshal(msg)
+	t.stdin.Write(append(data, '\n'))
+}
+
 // OnMethod registers a handler for incoming method calls
 func (t *StdioTransport) OnMethod(handler func(method string, params json.RawMessage, id *int)) {
 	t.handler = handler
 }
 
+// Done is closed once the transport is closed or the agent's stdout ends
+func (t *StdioTransport) Done() <-chan struct{} {
+	return t.done
+}
+
 // Close shuts down the transport
 func (t *StdioTransport) Close() error {
 	t.closeOnce.Do(func() {
@@ -1,9 +1,12 @@
 package acp
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -211,6 +214,37 @@ func TestTransport_MethodHandler(t *testing.T) {
 	serverWriter.Close()
 }
 
+func TestTransport_RequestWithoutHandler(t *testing.T) {
+	// given: a transport nobody has registered a handler on yet
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	transport := NewStdioTransport(clientWriter, clientReader)
+	defer transport.Close()
+
+	// when: the agent sends a notification, then a request
+	go func() {
+		serverWriter.Write([]byte(`{"jsonrpc":"2.0","method":"session/update","params":{}}` + "\n"))
+		serverWriter.Write([]byte(`{"jsonrpc":"2.0","id":4,"method":"fs/read_text_file","params":{}}` + "\n"))
+	}()
+
+	// then: only the request is answered, with method not found
+	line, err := bufio.NewReader(serverReader).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	var resp JSONRPCMessage
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.ID == nil || *resp.ID != 4 {
+		t.Fatalf("expected response to id 4, got %s", line)
+	}
+	if resp.Error == nil || resp.Error.Code != rpcMethodNotFound {
+		t.Errorf("expected method not found, got %s", line)
+	}
+	serverWriter.Close()
+}
+
 func TestTransport_Notify(t *testing.T) {
 	// given: a transport
 	serverReader, clientWriter := io.Pipe()
@@ -285,3 +319,207 @@ func TestTransport_ErrorResponse(t *testing.T) {
 	serverReader.Close()
 	serverWriter.Close()
 }
+
+func TestTransport_RequestTimeout(t *testing.T) {
+	// given: a transport with a short timeout and a server that never answers the first request
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	transport := NewStdioTransportWithTimeout(clientWriter, clientReader, 50*time.Millisecond)
+	defer transport.Close()
+
+	requests := make(chan JSONRPCMessage, 2)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := serverReader.Read(buf)
+			if err != nil {
+				return
+			}
+			var req JSONRPCMessage
+			json.Unmarshal(buf[:n], &req)
+			requests <- req
+		}
+	}()
+
+	// when: sending a request that gets no response
+	start := time.Now()
+	_, err := transport.Send("hung/method", nil)
+
+	// then: Send returns a timeout error and the callback is cleaned up
+	if !errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("expected ErrRequestTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send took %s, expected ~50ms", elapsed)
+	}
+	transport.mu.Lock()
+	pending := len(transport.callbacks)
+	transport.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected callbacks cleaned up, %d remain", pending)
+	}
+
+	// when: the late response arrives, followed by a normal request
+	hung := <-requests
+	late, _ := json.Marshal(JSONRPCMessage{JSONRPC: "2.0", ID: hung.ID, Result: json.RawMessage(`{"late":true}`)})
+	serverWriter.Write(append(late, '\n'))
+
+	go func() {
+		req := <-requests
+		resp, _ := json.Marshal(JSONRPCMessage{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"ok":true}`)})
+		serverWriter.Write(append(resp, '\n'))
+	}()
+	result, err := transport.Send("ok/method", nil)
+
+	// then: the late response is discarded and the next request is unaffected
+	if err != nil {
+		t.Fatalf("Send after timeout: %v", err)
+	}
+	if string(result) != `{"ok":true}` {
+		t.Errorf("got result %s", result)
+	}
+
+	serverReader.Close()
+	serverWriter.Close()
+}
+
+func TestTransport_ConcurrentMixedResponses(t *testing.T) {
+	// given: a server answering every request from its own goroutine,
+	// failing methods prefixed with "fail/"
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	transport := NewStdioTransport(clientWriter, clientReader)
+	defer transport.Close()
+
+	go func() {
+		scanner := bufio.NewScanner(serverReader)
+		for scanner.Scan() {
+			var req JSONRPCMessage
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				continue
+			}
+			go func(req JSONRPCMessage) {
+				resp := JSONRPCMessage{JSONRPC: "2.0", ID: req.ID}
+				if strings.HasPrefix(req.Method, "fail/") {
+					resp.Error = &RPCError{Code: -32000, Message: req.Method}
+				} else {
+					resp.Result = json.RawMessage(fmt.Sprintf(`{"method":%q}`, req.Method))
+				}
+				data, _ := json.Marshal(resp)
+				serverWriter.Write(append(data, '\n'))
+			}(req)
+		}
+	}()
+
+	// when: many requests are sent concurrently
+	const n = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			method := fmt.Sprintf("ok/%d", i)
+			if i%2 == 1 {
+				method = fmt.Sprintf("fail/%d", i)
+			}
+			result, err := transport.Send(method, nil)
+
+			// then: each caller sees exactly its own result or error
+			if i%2 == 1 {
+				want := fmt.Sprintf("rpc error -32000: %s", method)
+				if err == nil || err.Error() != want {
+					errs <- fmt.Errorf("%s: got err %v, result %s", method, err, result)
+				}
+				return
+			}
+			if err != nil {
+				errs <- fmt.Errorf("%s: unexpected error %v", method, err)
+				return
+			}
+			if string(result) != fmt.Sprintf(`{"method":%q}`, method) {
+				errs <- fmt.Errorf("%s: got result %s", method, result)
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for concurrent requests")
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// then: no callbacks are left behind
+	transport.mu.Lock()
+	pending := len(transport.callbacks)
+	transport.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected no pending callbacks, got %d", pending)
+	}
+
+	serverReader.Close()
+	serverWriter.Close()
+}
+
+func TestTransport_LargeMessage(t *testing.T) {
+	// given: a transport with a method handler
+	_, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	transport := NewStdioTransport(clientWriter, clientReader)
+	defer transport.Close()
+
+	received := make(chan json.RawMessage, 2)
+	transport.OnMethod(func(method string, params json.RawMessage, id *int) {
+		received <- params
+	})
+
+	// when: the server sends an update far larger than 64KB, then a small one
+	diff := strings.Repeat("+added line\n", 20000)
+	large, _ := json.Marshal(JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  "session/update",
+		Params:  json.RawMessage(fmt.Sprintf(`{"diff":%q}`, diff)),
+	})
+	if len(large) <= 64*1024 {
+		t.Fatalf("test message too small: %d bytes", len(large))
+	}
+	small, _ := json.Marshal(JSONRPCMessage{
+		JSONRPC: "2.0",
+		Method:  "session/update",
+		Params:  json.RawMessage(`{"diff":"small"}`),
+	})
+	go func() {
+		serverWriter.Write(append(large, '\n'))
+		serverWriter.Write(append(small, '\n'))
+	}()
+
+	// then: both are delivered intact, in order
+	for _, want := range []string{diff, "small"} {
+		select {
+		case params := <-received:
+			var p struct{ Diff string }
+			if err := json.Unmarshal(params, &p); err != nil {
+				t.Fatalf("unmarshal params: %v", err)
+			}
+			if p.Diff != want {
+				t.Errorf("got diff of %d bytes, want %d", len(p.Diff), len(want))
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for message")
+		}
+	}
+
+	serverWriter.Close()
+}
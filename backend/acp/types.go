@@ -2,6 +2,7 @@ package acp
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"ccui/backend"
 )
@@ -18,16 +19,62 @@ type JSONRPCMessage struct {
 
 // RPCError represents a JSON-RPC error
 type RPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
+// Error implements error
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// JSON-RPC error codes used in responses to agent requests
+const (
+	rpcAuthRequired   = -32000
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
 // InitializeParams for initialize request
 type InitializeParams struct {
 	ProtocolVersion    int                `json:"protocolVersion"`
 	ClientCapabilities ClientCapabilities `json:"clientCapabilities"`
 }
 
+// ProtocolVersion is the ACP protocol version ccui speaks
+const ProtocolVersion = 1
+
+// InitializeResult from initialize response
+type InitializeResult struct {
+	ProtocolVersion   int             `json:"protocolVersion"`
+	AgentCapabilities json.RawMessage `json:"agentCapabilities,omitempty"`
+	AuthMethods       []AuthMethod    `json:"authMethods,omitempty"`
+}
+
+// AuthMethod is a way the agent lets the user log in
+type AuthMethod struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// AuthRequired is emitted when the agent needs the user to authenticate
+type AuthRequired struct {
+	Message string       `json:"message"`
+	URL     string       `json:"url,omitempty"`
+	Methods []AuthMethod `json:"methods"`
+}
+
+// ReconnectStatus is emitted while a dead agent is respawned
+type ReconnectStatus struct {
+	Status      string `json:"status"` // reconnecting, reconnected or failed
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"maxAttempts"`
+	Error       string `json:"error,omitempty"`
+}
+
 // ClientCapabilities describes client capabilities
 type ClientCapabilities struct {
 	FS       *FSCapabilities `json:"fs,omitempty"`
@@ -52,6 +99,18 @@ type SessionNewResult struct {
 	Modes     *ModesInfo `json:"modes,omitempty"`
 }
 
+// SessionLoadParams for session/load request
+type SessionLoadParams struct {
+	SessionID  string `json:"sessionId"`
+	CWD        string `json:"cwd"`
+	MCPServers []any  `json:"mcpServers"`
+}
+
+// AgentCapabilities is the subset of initialize capabilities ccui uses
+type AgentCapabilities struct {
+	LoadSession bool `json:"loadSession,omitempty"`
+}
+
 // PromptContent for prompts
 type PromptContent struct {
 	Type string `json:"type"`
@@ -164,3 +223,63 @@ type PermissionOutcome struct {
 	Outcome  string `json:"outcome"`
 	OptionID string `json:"optionId,omitempty"`
 }
+
+// ReadTextFileParams for fs/read_text_file request
+type ReadTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Line      *int   `json:"line,omitempty"`  // 1-based first line
+	Limit     *int   `json:"limit,omitempty"` // max lines to return
+}
+
+// ReadTextFileResult for fs/read_text_file response
+type ReadTextFileResult struct {
+	Content string `json:"content"`
+}
+
+// WriteTextFileParams for fs/write_text_file request
+type WriteTextFileParams struct {
+	SessionID string `json:"sessionId"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+}
+
+// EnvVariable is an environment variable for a terminal command
+type EnvVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// TerminalCreateParams for terminal/create request
+type TerminalCreateParams struct {
+	SessionID       string        `json:"sessionId"`
+	Command         string        `json:"command"`
+	Args            []string      `json:"args,omitempty"`
+	Env             []EnvVariable `json:"env,omitempty"`
+	CWD             string        `json:"cwd,omitempty"`
+	OutputByteLimit *int          `json:"outputByteLimit,omitempty"`
+}
+
+// TerminalCreateResult for terminal/create response
+type TerminalCreateResult struct {
+	TerminalID string `json:"terminalId"`
+}
+
+// TerminalParams identifies the terminal for output/wait_for_exit/kill/release
+type TerminalParams struct {
+	SessionID  string `json:"sessionId"`
+	TerminalID string `json:"terminalId"`
+}
+
+// TerminalExitStatus describes how a terminal command exited
+type TerminalExitStatus struct {
+	ExitCode *int    `json:"exitCode"`
+	Signal   *string `json:"signal"`
+}
+
+// TerminalOutputResult for terminal/output response
+type TerminalOutputResult struct {
+	Output     string              `json:"output"`
+	Truncated  bool                `json:"truncated"`
+	ExitStatus *TerminalExitStatus `json:"exitStatus,omitempty"`
+}
@@ -2,6 +2,13 @@ package anthropic
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"ccui/backend"
 	"ccui/backend/tools"
@@ -9,33 +16,155 @@ import (
 )
 
 const (
-	defaultModel   = "claude-sonnet-4-20250514"
-	defaultMaxTokens = 8192
-	defaultBaseURL = "https://api.anthropic.com"
+	defaultModel      = "claude-sonnet-4-20250514"
+	defaultMaxTokens  = 8192
+	defaultBaseURL    = "https://api.anthropic.com"
+	defaultAPIVersion = "2023-06-01"
 )
 
-// AnthropicBackend implements AgentBackend for direct Anthropic API calls
+// AuthMode selects how requests carry the credential
+type AuthMode string
+
+const (
+	AuthAPIKey AuthMode = "api_key" // x-api-key header; the default
+	AuthOAuth  AuthMode = "oauth"   // bearer token from a Claude subscription login
+)
+
+// oauthBeta is the anthropic-beta value the API requires for OAuth tokens
+const oauthBeta = "oauth-2025-04-20"
+
+// ErrMissingAPIKey is returned when no API key is configured, instead of
+// letting the API reject an empty x-api-key
+var ErrMissingAPIKey = errors.New("no Anthropic API key: set ANTHROPIC_API_KEY to use the direct API backend")
+
+// ErrMissingAuthToken is ErrMissingAPIKey for AuthOAuth mode
+var ErrMissingAuthToken = errors.New("no Anthropic OAuth token: set ANTHROPIC_AUTH_TOKEN to use the direct API backend with a Claude subscription")
+
+// missingCredential returns the error for a blank credential in mode, or nil
+func missingCredential(mode AuthMode, credential string) error {
+	if strings.TrimSpace(credential) != "" {
+		return nil
+	}
+	if mode == AuthOAuth {
+		return ErrMissingAuthToken
+	}
+	return ErrMissingAPIKey
+}
+
+// AnthropicBackend implements AgentBackend for direct Anthropic API calls.
+// Apart from the API key, its fields are fixed by NewAnthropicBackend, so sessions
+// may be created and run concurrently; the shared executor and permission layer
+// guard their own state.
 type AnthropicBackend struct {
-	apiKey    string
-	baseURL   string
-	model     string
-	maxTokens int
-	executor  tools.ToolExecutor
-	permLayer *permission.Layer
+	keyMu    sync.RWMutex
+	apiKey   string   // API key or OAuth token per authMode; replaced by SetAPIKey
+	authMode AuthMode // empty is AuthAPIKey
+
+	baseURL    string
+	apiVersion string   // anthropic-version header
+	betas      []string // anthropic-beta features, besides the OAuth one
+	model      string
+	maxTokens  int
+	thinking   int // extended thinking budget, 0 disables
+	executor   tools.ToolExecutor
+	permLayer  *permission.Layer
+
+	stream   bool
+	auditDir string
+
+	webSearch        bool // advertise the server-side web_search tool
+	webSearchMaxUses int  // 0 leaves searches per request unlimited
+
+	prices PriceTable // for usage cost estimates
+
+	// sampling overrides, nil uses API defaults
+	temperature *float64
+	topP        *float64
+
+	httpClient   *http.Client
+	retries      int // extra attempts for retryable failures
+	retryBackoff time.Duration
 }
 
 // BackendConfig configures the Anthropic backend
 type BackendConfig struct {
-	APIKey    string
-	BaseURL   string
+	APIKey   string   // the OAuth access token in AuthOAuth mode
+	AuthMode AuthMode // empty is AuthAPIKey
+	BaseURL  string
+
+	APIVersion string   // anthropic-version header; empty uses 2023-06-01
+	Betas      []string // anthropic-beta features to opt into, e.g. token-efficient-tools-2025-02-19
+
 	Model     string
 	MaxTokens int
 	Executor  tools.ToolExecutor
 	PermLayer *permission.Layer
+
+	ThinkingBudget int // extended thinking tokens, must be below MaxTokens; 0 disables
+
+	Stream      bool     // use SSE streaming; false requests a single JSON response
+	Temperature *float64 // 0-1, nil omits from request
+	TopP        *float64 // 0-1, nil omits from request
+	AuditDir    string   // directory for per-session JSONL audit logs, empty disables
+
+	WebSearch        bool // let the model run Anthropic's server-side web search
+	WebSearchMaxUses int  // searches allowed per request, 0 is unlimited
+
+	Prices PriceTable // per-model prices for cost estimates, merged over DefaultPrices
+}
+
+// Validate checks the config for a missing credential, an unknown auth mode
+// and out-of-range values
+func (cfg BackendConfig) Validate() error {
+	switch cfg.AuthMode {
+	case "", AuthAPIKey, AuthOAuth:
+	default:
+		return fmt.Errorf("unknown auth mode %q", cfg.AuthMode)
+	}
+	if err := missingCredential(cfg.AuthMode, cfg.APIKey); err != nil {
+		return err
+	}
+	if cfg.APIVersion != "" && strings.TrimSpace(cfg.APIVersion) == "" {
+		return errors.New("api version must not be blank")
+	}
+	for _, beta := range cfg.Betas {
+		if strings.TrimSpace(beta) == "" {
+			return errors.New("beta features must not be blank")
+		}
+	}
+	if err := validateSampling(cfg.Temperature, cfg.TopP); err != nil {
+		return err
+	}
+	maxTokens := cfg.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+	return validateMaxTokens(maxTokens, cfg.ThinkingBudget)
+}
+
+// validateMaxTokens checks max_tokens leaves room for the thinking budget
+func validateMaxTokens(maxTokens, thinkingBudget int) error {
+	if maxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be positive, got %d", maxTokens)
+	}
+	if thinkingBudget > 0 && maxTokens <= thinkingBudget {
+		return fmt.Errorf("max_tokens (%d) must exceed thinking budget (%d)", maxTokens, thinkingBudget)
+	}
+	return nil
+}
+
+func validateSampling(temperature, topP *float64) error {
+	if temperature != nil && (*temperature < 0 || *temperature > 1) {
+		return fmt.Errorf("temperature must be between 0 and 1, got %v", *temperature)
+	}
+	if topP != nil && (*topP < 0 || *topP > 1) {
+		return fmt.Errorf("top_p must be between 0 and 1, got %v", *topP)
+	}
+	return nil
 }
 
-// NewAnthropicBackend creates a new backend with config
-func NewAnthropicBackend(cfg BackendConfig) *AnthropicBackend {
+// NewAnthropicBackend creates a new backend with config; opts override it
+func NewAnthropicBackend(cfg BackendConfig, opts ...Option) *AnthropicBackend {
 	baseURL := cfg.BaseURL
 	if baseURL == "" {
 		baseURL = defaultBaseURL
@@ -48,17 +177,98 @@ func NewAnthropicBackend(cfg BackendConfig) *AnthropicBackend {
 	if maxTokens == 0 {
 		maxTokens = defaultMaxTokens
 	}
-	return &AnthropicBackend{
-		apiKey:    cfg.APIKey,
-		baseURL:   baseURL,
-		model:     model,
-		maxTokens: maxTokens,
-		executor:  cfg.Executor,
-		permLayer: cfg.PermLayer,
+	authMode := cfg.AuthMode
+	if authMode == "" {
+		authMode = AuthAPIKey
+	}
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAPIVersion
 	}
+	b := &AnthropicBackend{
+		apiKey:     cfg.APIKey,
+		authMode:   authMode,
+		baseURL:    baseURL,
+		apiVersion: apiVersion,
+		betas:      cfg.Betas,
+		model:      model,
+		maxTokens:  maxTokens,
+		thinking:   cfg.ThinkingBudget,
+		executor:   cfg.Executor,
+		permLayer:  cfg.PermLayer,
+
+		stream:      cfg.Stream,
+		auditDir:    cfg.AuditDir,
+		temperature: cfg.Temperature,
+		topP:        cfg.TopP,
+
+		webSearch:        cfg.WebSearch,
+		webSearchMaxUses: cfg.WebSearchMaxUses,
+
+		prices: mergePrices(cfg.Prices),
+
+		httpClient:   http.DefaultClient,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// SetAPIKey replaces the API key, or the OAuth token in AuthOAuth mode;
+// requests already sent keep the old one, later requests from every session
+// use key
+func (b *AnthropicBackend) SetAPIKey(key string) {
+	b.keyMu.Lock()
+	b.apiKey = key
+	b.keyMu.Unlock()
+}
+
+// currentAPIKey returns the key for the next request
+func (b *AnthropicBackend) currentAPIKey() string {
+	b.keyMu.RLock()
+	defer b.keyMu.RUnlock()
+	return b.apiKey
+}
+
+// setAPIHeaders adds the version, beta and credential headers for the
+// backend's config and auth mode
+func (b *AnthropicBackend) setAPIHeaders(h http.Header) {
+	h.Set("anthropic-version", b.apiVersion)
+	betas := b.betas
+	if b.authMode == AuthOAuth {
+		betas = append([]string{oauthBeta}, betas...)
+	}
+	if len(betas) > 0 {
+		h.Set("anthropic-beta", strings.Join(betas, ","))
+	}
+	if b.authMode == AuthOAuth {
+		h.Set("Authorization", "Bearer "+b.currentAPIKey())
+		return
+	}
+	h.Set("x-api-key", b.currentAPIKey())
 }
 
 // NewSession creates a new AnthropicSession
 func (b *AnthropicBackend) NewSession(ctx context.Context, opts backend.SessionOpts) (backend.Session, error) {
-	return newAnthropicSession(ctx, b, opts), nil
+	if err := missingCredential(b.authMode, b.currentAPIKey()); err != nil {
+		return nil, err
+	}
+	if err := validateSampling(b.temperature, b.topP); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := validateMaxTokens(b.maxTokens, b.thinking); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	s := newAnthropicSession(ctx, b, opts)
+	if b.auditDir != "" {
+		audit, err := backend.OpenAuditLog(filepath.Join(b.auditDir, s.id+".jsonl"))
+		if err != nil {
+			s.cancel()
+			return nil, err
+		}
+		s.audit = audit
+	}
+	return s, nil
 }
@@ -0,0 +1,135 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ccui/backend"
+)
+
+const (
+	compactKeepTurns = 2    // most recent user turns kept verbatim
+	compactMaxTokens = 1024 // budget for the summary itself
+)
+
+// compactInstruction asks for the summary that replaces older turns
+const compactInstruction = `Summarize the conversation so far for your own future reference. ` +
+	`Keep the user's goals, decisions made, files touched and their current state, ` +
+	`and any open questions or next steps. Be concise; omit pleasantries and tool output details.`
+
+// compactSummaryPrefix introduces the summary in the compacted history
+const compactSummaryPrefix = "Summary of the earlier conversation:\n\n"
+
+// ErrNothingToCompact is returned when the history has no turns older than
+// the ones Compact keeps
+var ErrNothingToCompact = errors.New("not enough history to compact")
+
+// Compact asks the model to summarize older turns and replaces them with
+// the summary, keeping the most recent turns verbatim
+func (s *AnthropicSession) Compact(ctx context.Context) error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	s.mu.Lock()
+	split := compactSplit(s.history, compactKeepTurns)
+	if split == 0 {
+		s.mu.Unlock()
+		return ErrNothingToCompact
+	}
+	old := append([]Message(nil), s.history[:split]...)
+	req := MessagesRequest{
+		Model: s.modelLocked(),
+		Messages: append(old, Message{
+			Role:    "user",
+			Content: []ContentBlock{{Type: BlockTypeText, Text: compactInstruction}},
+		}),
+		MaxTokens: compactMaxTokens,
+		// tools stay declared for the tool_use blocks in history, but unusable
+		Tools:      DefaultTools(),
+		ToolChoice: &ToolChoice{Type: "none"},
+	}
+	s.mu.Unlock()
+
+	summary, usage, err := s.requestSummary(ctx, req)
+	if err != nil {
+		return fmt.Errorf("compact history: %w", err)
+	}
+
+	s.mu.Lock()
+	// prompts may have appended turns meanwhile; they follow the old prefix
+	recent := s.history[split:]
+	s.history = append([]Message{{
+		Role:    "user",
+		Content: []ContentBlock{{Type: BlockTypeText, Text: compactSummaryPrefix + summary}},
+	}}, recent...)
+	s.mu.Unlock()
+
+	saved := usage.InputTokens - usage.OutputTokens
+	if saved < 0 {
+		saved = 0
+	}
+	s.emit(backend.Event{Type: backend.EventHistoryCompacted, Data: backend.HistoryCompaction{
+		MessagesRemoved: split,
+		TokensBefore:    usage.InputTokens,
+		TokensAfter:     usage.OutputTokens,
+		TokensSaved:     saved,
+	}})
+	return nil
+}
+
+// compactSplit returns the index of the keep-th most recent user turn, or 0
+// when there is nothing before it
+func compactSplit(history []Message, keep int) int {
+	turns := 0
+	for i := len(history) - 1; i > 0; i-- {
+		if isUserTurn(history[i]) {
+			turns++
+			if turns == keep {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// isUserTurn reports whether m is a user prompt rather than tool results
+func isUserTurn(m Message) bool {
+	if m.Role != "user" {
+		return false
+	}
+	for _, cb := range m.Content {
+		if cb.Type == BlockTypeToolResult {
+			return false
+		}
+	}
+	return true
+}
+
+// requestSummary sends a non-streaming request and returns its text
+func (s *AnthropicSession) requestSummary(ctx context.Context, req MessagesRequest) (string, Usage, error) {
+	resp, err := s.postMessages(ctx, req)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var msg MessagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return "", Usage{}, fmt.Errorf("decode response: %w", err)
+	}
+	s.recordUsage(msg.Model, msg.Usage)
+	var text strings.Builder
+	for _, cb := range msg.Content {
+		if cb.Type == BlockTypeText {
+			text.WriteString(cb.Text)
+		}
+	}
+	summary := strings.TrimSpace(text.String())
+	if summary == "" {
+		return "", Usage{}, errors.New("empty summary")
+	}
+	return summary, msg.Usage, nil
+}
@@ -0,0 +1,70 @@
+package anthropic
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultRetryBackoff is the delay before the first retry; it doubles per attempt
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// Option overrides a BackendConfig value; options apply after the config and
+// its defaults, and zero values leave the setting unchanged
+type Option func(*AnthropicBackend)
+
+// WithModel sets the model
+func WithModel(model string) Option {
+	return func(b *AnthropicBackend) {
+		if model != "" {
+			b.model = model
+		}
+	}
+}
+
+// WithMaxTokens sets the default max_tokens per request
+func WithMaxTokens(maxTokens int) Option {
+	return func(b *AnthropicBackend) {
+		if maxTokens > 0 {
+			b.maxTokens = maxTokens
+		}
+	}
+}
+
+// WithBaseURL sets the API base URL
+func WithBaseURL(baseURL string) Option {
+	return func(b *AnthropicBackend) {
+		if baseURL != "" {
+			b.baseURL = baseURL
+		}
+	}
+}
+
+// WithHTTPClient sets the client used for API requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(b *AnthropicBackend) {
+		if client != nil {
+			b.httpClient = client
+		}
+	}
+}
+
+// WithRetries retries failed requests up to n extra times. Transport errors,
+// 429 and 5xx responses are retried with exponential backoff.
+func WithRetries(n int) Option {
+	return func(b *AnthropicBackend) {
+		if n >= 0 {
+			b.retries = n
+		}
+	}
+}
+
+// WithWebSearch enables the server-side web_search tool, allowing up to
+// maxUses searches per request (0 is unlimited)
+func WithWebSearch(maxUses int) Option {
+	return func(b *AnthropicBackend) {
+		b.webSearch = true
+		if maxUses > 0 {
+			b.webSearchMaxUses = maxUses
+		}
+	}
+}
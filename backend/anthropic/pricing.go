@@ -0,0 +1,108 @@
+package anthropic
+
+import (
+	"strings"
+
+	"ccui/backend"
+)
+
+// ModelPrice is a model's USD price per million tokens
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cacheWrite"` // cache_creation_input_tokens
+	CacheRead  float64 `json:"cacheRead"`  // cache_read_input_tokens
+}
+
+// Cost returns the estimated USD cost of u at p
+func (p ModelPrice) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationInputTokens)*p.CacheWrite +
+		float64(u.CacheReadInputTokens)*p.CacheRead) / 1e6
+}
+
+// PriceTable maps model name prefixes to prices; the longest matching prefix
+// wins, so dated model IDs share their family's price
+type PriceTable map[string]ModelPrice
+
+// Lookup returns model's price, if any prefix matches
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	var best string
+	for prefix := range t {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	price, ok := t[best]
+	return price, ok && best != ""
+}
+
+// DefaultPrices are Anthropic's list prices; BackendConfig.Prices overrides
+// or extends them
+var DefaultPrices = PriceTable{
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite: 0.30, CacheRead: 0.03},
+}
+
+// mergePrices returns DefaultPrices with overrides applied
+func mergePrices(overrides PriceTable) PriceTable {
+	prices := make(PriceTable, len(DefaultPrices)+len(overrides))
+	for prefix, price := range DefaultPrices {
+		prices[prefix] = price
+	}
+	for prefix, price := range overrides {
+		prices[prefix] = price
+	}
+	return prices
+}
+
+// usageMeter totals a session's token usage and estimated cost; a total's
+// cost is unknown once any of its requests used an unpriced model
+type usageMeter struct {
+	turn, session backend.TokenUsage
+	turnModel     string
+}
+
+// add counts one request's usage under model
+func (m *usageMeter) add(prices PriceTable, model string, u Usage) {
+	price, priced := prices.Lookup(model)
+	cost := price.Cost(u)
+	for _, total := range []*backend.TokenUsage{&m.turn, &m.session} {
+		unpriced := total.InputTokens+total.OutputTokens > 0 && total.CostUSD == nil
+		total.InputTokens += u.InputTokens
+		total.OutputTokens += u.OutputTokens
+		total.CacheCreationInputTokens += u.CacheCreationInputTokens
+		total.CacheReadInputTokens += u.CacheReadInputTokens
+		switch {
+		case !priced || unpriced:
+			total.CostUSD = nil
+		case total.CostUSD == nil:
+			total.CostUSD = &cost
+		default:
+			sum := *total.CostUSD + cost
+			total.CostUSD = &sum
+		}
+	}
+	m.turnModel = model
+}
+
+// startTurn clears the turn total, leaving usage recorded between prompts,
+// such as compaction, in the session total only
+func (m *usageMeter) startTurn() {
+	m.turn, m.turnModel = backend.TokenUsage{}, ""
+}
+
+// endTurn returns the finished turn's report and starts a new turn
+func (m *usageMeter) endTurn() backend.UsageReport {
+	report := backend.UsageReport{Model: m.turnModel, Turn: m.turn, Session: m.session}
+	m.startTurn()
+	return report
+}
@@ -4,18 +4,69 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"ccui/backend"
+	"ccui/backend/tools"
 	"ccui/permission"
 
 	"github.com/google/uuid"
 )
 
+// Session modes for the direct API backend
+const (
+	ModeDefault  = "default"
+	ModePlan     = "plan"
+	ModeReadOnly = "read-only"
+)
+
+// planModeInstruction is injected as the system prompt in plan mode
+const planModeInstruction = `You are in plan mode. Do not modify files or run commands. ` +
+	`Investigate the request using what you already know and respond with a concise, ` +
+	`numbered implementation plan. The user will switch out of plan mode to execute it.`
+
+// planModeSkippedText stands in for a plan mode reply that only called tools
+const planModeSkippedText = "(tool calls skipped in plan mode)"
+
+var sessionModes = []backend.SessionMode{
+	{ID: ModeDefault, Name: "Default", Description: "Execute tools with permission checks"},
+	{ID: ModePlan, Name: "Plan", Description: "Produce a plan without executing tools"},
+	{ID: ModeReadOnly, Name: "Read-only", Description: "Deny tools that modify files or run commands"},
+}
+
+// readOnlyTools may run in read-only mode; everything else is denied
+var readOnlyTools = map[string]bool{
+	"Read":           true,
+	"ReadMany":       true,
+	"Glob":           true,
+	"Grep":           true,
+	"Outline":        true,
+	"GitStatus":      true,
+	"GitDiff":        true,
+	"ProjectInfo":    true,
+	"WebSearch":      true,
+	"WebFetch":       true,
+	"ListBackground": true,
+	"ReadBackground": true,
+	planToolName:     true,
+}
+
+func isKnownMode(modeID string) bool {
+	for _, m := range sessionModes {
+		if m.ID == modeID {
+			return true
+		}
+	}
+	return false
+}
+
 // AnthropicSession implements backend.Session for direct API calls
 type AnthropicSession struct {
 	id          string
@@ -26,7 +77,17 @@ type AnthropicSession struct {
 	history     []Message
 	toolManager *backend.ToolCallManager
 	fileStore   *backend.FileChangeStore
+	model       string                   // per-session override, empty uses backend default
+	modeID      string                   // empty means ModeDefault
+	allowed     []string                 // allowedTools of the current prompt, empty allows all
+	maxTokens   int                      // max_tokens of the current prompt, 0 uses backend default
+	plan        []backend.PlanEntry      // last TodoWrite plan, replayed by Snapshot
+	audit       *backend.AuditLog        // nil disables audit logging
+	background  *tools.BackgroundManager // this session's background processes
+	chunkDedup  *backend.ChunkDeduper    // nil unless opts.DedupChunks
+	usage       usageMeter               // token and cost totals, reported per prompt
 	mu          sync.Mutex
+	compactMu   sync.Mutex // serializes Compact calls
 
 	// Review-mode configuration
 	autoPermission     bool
@@ -41,6 +102,11 @@ func newAnthropicSession(ctx context.Context, b *AnthropicBackend, opts backend.
 		fileStore = backend.NewFileChangeStore()
 	}
 
+	modeID := ""
+	if opts.ReadOnly {
+		modeID = ModeReadOnly
+	}
+
 	return &AnthropicSession{
 		id:                 uuid.New().String(),
 		ctx:                ctx,
@@ -50,6 +116,10 @@ func newAnthropicSession(ctx context.Context, b *AnthropicBackend, opts backend.
 		history:            make([]Message, 0),
 		toolManager:        backend.NewToolCallManager(),
 		fileStore:          fileStore,
+		background:         tools.NewBackgroundManager(),
+		chunkDedup:         backend.NewChunkDeduper(opts.DedupChunks),
+		model:              opts.Model,
+		modeID:             modeID,
 		autoPermission:     opts.AutoPermission,
 		suppressToolEvents: opts.SuppressToolEvents,
 	}
@@ -60,40 +130,133 @@ func (s *AnthropicSession) SessionID() string {
 	return s.id
 }
 
-// CurrentMode returns empty string (direct API has no modes)
+// CurrentMode returns the active session mode
 func (s *AnthropicSession) CurrentMode() string {
-	return ""
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modeLocked()
 }
 
-// AvailableModes returns nil (direct API has no modes)
+// modeLocked returns the effective mode; caller must hold s.mu
+func (s *AnthropicSession) modeLocked() string {
+	if s.modeID != "" {
+		return s.modeID
+	}
+	return ModeDefault
+}
+
+// AvailableModes returns the modes supported by the direct API backend
 func (s *AnthropicSession) AvailableModes() []backend.SessionMode {
-	return nil
+	return append([]backend.SessionMode(nil), sessionModes...)
 }
 
-// SetMode is a no-op for direct API
+// SetMode switches the session mode and emits a mode change
 func (s *AnthropicSession) SetMode(modeID string) error {
+	if !isKnownMode(modeID) {
+		return fmt.Errorf("unknown mode: %s", modeID)
+	}
+	s.mu.Lock()
+	s.modeID = modeID
+	s.mu.Unlock()
+	s.emit(backend.Event{Type: backend.EventModeChanged, Data: modeID})
 	return nil
 }
 
+// SetModel switches the model used for subsequent requests
+func (s *AnthropicSession) SetModel(model string) error {
+	if strings.TrimSpace(model) == "" {
+		return fmt.Errorf("model is required")
+	}
+	s.mu.Lock()
+	s.model = model
+	s.mu.Unlock()
+	return nil
+}
+
+// GetModel returns the model used for the next request
+func (s *AnthropicSession) GetModel() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modelLocked()
+}
+
+// modelLocked returns the effective model; caller must hold s.mu
+func (s *AnthropicSession) modelLocked() string {
+	if s.model != "" {
+		return s.model
+	}
+	return s.backend.model
+}
+
 // FileChangeStore returns the file change store
 func (s *AnthropicSession) FileChangeStore() *backend.FileChangeStore {
 	return s.fileStore
 }
 
+// Snapshot returns the current tool states and last plan
+func (s *AnthropicSession) Snapshot() backend.SessionSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return backend.SessionSnapshot{Tools: s.toolManager.List(), Plan: s.plan}
+}
+
 // Cancel cancels the current operation
 func (s *AnthropicSession) Cancel() {
 	s.cancel()
 }
 
+// CancelWithReason implements backend.ReasonCanceler
+func (s *AnthropicSession) CancelWithReason(reason string) {
+	// emit first; emit drops events once the context is done
+	s.emit(backend.Event{Type: backend.EventCancelled, Data: reason})
+	s.cancel()
+}
+
 // Close closes the session
 func (s *AnthropicSession) Close() error {
 	s.cancel()
+	if s.background != nil {
+		s.background.KillAll()
+	}
+	if s.audit != nil {
+		return s.audit.Close()
+	}
 	return nil
 }
 
+// AuditLogPath returns the session's audit log file, or "" when disabled
+func (s *AnthropicSession) AuditLogPath() string {
+	if s.audit == nil {
+		return ""
+	}
+	return s.audit.Path()
+}
+
+// PromptOptions overrides backend settings for a single prompt
+type PromptOptions struct {
+	MaxTokens int // 0 uses the backend's max tokens
+}
+
 // SendPrompt sends a prompt to the Anthropic API
 func (s *AnthropicSession) SendPrompt(text string, allowedTools []string) error {
+	return s.SendPromptWithOptions(text, allowedTools, PromptOptions{})
+}
+
+// SendPromptWithOptions sends a prompt with per-prompt overrides
+func (s *AnthropicSession) SendPromptWithOptions(text string, allowedTools []string, opts PromptOptions) error {
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = s.backend.maxTokens
+	}
+	if err := validateMaxTokens(maxTokens, s.backend.thinking); err != nil {
+		return err
+	}
+
+	s.toolManager.NewTurn()
 	s.mu.Lock()
+	s.usage.startTurn()
+	s.allowed = allowedTools
+	s.maxTokens = maxTokens
 	// Add user message to history
 	s.history = append(s.history, Message{
 		Role:    "user",
@@ -105,16 +268,20 @@ func (s *AnthropicSession) SendPrompt(text string, allowedTools []string) error
 	for {
 		select {
 		case <-s.ctx.Done():
+			s.reportUsage()
 			return s.ctx.Err()
 		default:
 		}
 
 		stopReason, err := s.doRequest()
 		if err != nil {
+			s.reportUsage()
 			return err
 		}
 
 		if stopReason != StopReasonToolUse {
+			s.reportUsage()
+
 			// Done - emit prompt complete
 			s.emit(backend.Event{
 				Type: backend.EventPromptComplete,
@@ -126,43 +293,213 @@ func (s *AnthropicSession) SendPrompt(text string, allowedTools []string) error
 	}
 }
 
+// reportUsage ends the turn's usage and emits its report
+func (s *AnthropicSession) reportUsage() {
+	s.mu.Lock()
+	report := s.usage.endTurn()
+	s.mu.Unlock()
+	s.emit(backend.Event{Type: backend.EventUsage, Data: report})
+}
+
 // doRequest makes a single API request and processes the response
 func (s *AnthropicSession) doRequest() (string, error) {
 	s.mu.Lock()
 	req := MessagesRequest{
-		Model:     s.backend.model,
+		Model:     s.modelLocked(),
 		Messages:  s.history,
-		MaxTokens: s.backend.maxTokens,
-		Tools:     DefaultTools(),
-		Stream:    true,
+		MaxTokens: s.maxTokens,
+		Tools:     s.advertisedToolsLocked(),
+		Stream:    s.backend.stream,
+
+		Temperature: s.backend.temperature,
+		TopP:        s.backend.topP,
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = s.backend.maxTokens
+	}
+	if s.backend.thinking > 0 {
+		req.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: s.backend.thinking}
+	}
+	if s.modeLocked() == ModePlan {
+		req.ToolChoice = &ToolChoice{Type: "none"}
+		req.System = planModeInstruction
 	}
 	s.mu.Unlock()
 
+	resp, err := s.postMessages(s.ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if !req.Stream {
+		return s.processResponse(resp.Body)
+	}
+	return s.processStream(resp.Body)
+}
+
+// postMessages sends req to /v1/messages, retrying retryable failures as
+// configured; the caller closes the body of a successful response
+func (s *AnthropicSession) postMessages(ctx context.Context, req MessagesRequest) (*http.Response, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(s.ctx, "POST", s.backend.baseURL+"/v1/messages", bytes.NewReader(body))
+	backoff := s.backend.retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, retryable, err := s.doPost(ctx, body)
+		if err == nil || !retryable || attempt >= s.backend.retries {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// doPost makes one request attempt and reports whether a failure may be retried
+func (s *AnthropicSession) doPost(ctx context.Context, body []byte) (*http.Response, bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.backend.baseURL+"/v1/messages", bytes.NewReader(body))
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, false, fmt.Errorf("create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", s.backend.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	s.backend.setAPIHeaders(httpReq.Header)
 
-	resp, err := http.DefaultClient.Do(httpReq)
+	client := s.backend.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("http request: %w", err)
+		return nil, ctx.Err() == nil, fmt.Errorf("http request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			s.emit(backend.Event{
+				Type: backend.EventAuthRequired,
+				Data: AuthRequired{Message: "The Anthropic API rejected the API key; set a new one to continue"},
+			})
+		}
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return nil, retryable, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
 	}
+	return resp, false, nil
+}
 
-	return s.processStream(resp.Body)
+// advertisedToolsLocked returns DefaultTools, plus web_search when enabled,
+// filtered by the allowlist; caller must hold s.mu
+func (s *AnthropicSession) advertisedToolsLocked() []Tool {
+	all := DefaultTools()
+	if s.backend.webSearch {
+		all = append(all, webSearchTool(s.backend.webSearchMaxUses))
+	}
+	if len(s.allowed) == 0 {
+		return all
+	}
+	var filtered []Tool
+	for _, t := range all {
+		if s.toolAllowedLocked(t.Name) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// toolAllowedLocked reports whether name is on the allowlist; caller must hold s.mu
+func (s *AnthropicSession) toolAllowedLocked(name string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	for _, allowed := range s.allowed {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// processResponse handles a non-streaming response, emitting events in one shot
+func (s *AnthropicSession) processResponse(body io.Reader) (string, error) {
+	var msg MessagesResponse
+	if err := json.NewDecoder(body).Decode(&msg); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	s.recordUsage(msg.Model, msg.Usage)
+
+	var assistantContent []ContentBlock
+	for _, cb := range msg.Content {
+		// as when streaming, only chunks within one block are consecutive
+		s.chunkDedup.Reset()
+		switch cb.Type {
+		case BlockTypeText:
+			s.emitChunk(backend.EventMessageChunk, cb.Text)
+			assistantContent = append(assistantContent, ContentBlock{Type: BlockTypeText, Text: cb.Text})
+		case BlockTypeThinking:
+			s.emitChunk(backend.EventThoughtChunk, cb.Thinking)
+			assistantContent = append(assistantContent, ContentBlock{
+				Type:      BlockTypeThinking,
+				Thinking:  cb.Thinking,
+				Signature: cb.Signature,
+			})
+		case BlockTypeToolUse:
+			s.startToolState(cb.ID, cb.Name)
+			assistantContent = append(assistantContent, ContentBlock{
+				Type:  BlockTypeToolUse,
+				ID:    cb.ID,
+				Name:  cb.Name,
+				Input: cb.Input,
+			})
+			input := cb.Input
+			s.toolManager.Update(cb.ID, func(ts *backend.ToolState) {
+				ts.Input = input
+			})
+		case BlockTypeServerToolUse:
+			s.startServerToolState(cb.ID, cb.Name)
+			assistantContent = append(assistantContent, cb)
+			input := cb.Input
+			s.updateToolState(cb.ID, func(ts *backend.ToolState) {
+				ts.Input = input
+			})
+		case BlockTypeWebSearchResult:
+			assistantContent = append(assistantContent, cb)
+			s.finishWebSearch(cb)
+		}
+	}
+
+	return s.finishResponse(assistantContent, msg.StopReason, nil)
+}
+
+// recordUsage adds one response's usage to the turn and session totals;
+// model falls back to the session's when the response omits it
+func (s *AnthropicSession) recordUsage(model string, u Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if model == "" {
+		model = s.modelLocked()
+	}
+	s.usage.add(s.backend.prices, model, u)
+}
+
+// startToolState records and emits a pending tool state for a tool_use block
+func (s *AnthropicSession) startToolState(id, name string) {
+	state := &backend.ToolState{
+		ID:       id,
+		Status:   "pending",
+		Title:    name,
+		Kind:     "tool",
+		ToolName: name,
+		ParentID: s.toolManager.CurrentParent(),
+	}
+	s.toolManager.Set(state)
+	s.emitToolState(state)
 }
 
 // contentBlockState tracks in-progress content blocks during streaming
@@ -173,6 +510,7 @@ type contentBlockState struct {
 	toolName    string
 	textBuilder strings.Builder
 	jsonBuilder strings.Builder
+	signature   string // thinking blocks only
 }
 
 // processStream processes SSE events and returns the stop reason
@@ -183,6 +521,16 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 	var stopReason string
 	blocks := make(map[int]*contentBlockState)
 	var assistantContent []ContentBlock
+	var malformed map[string]error // tool_use ID -> input parse error
+	var model string
+	var usage Usage
+	// tokens are billed once the message starts, even if the stream then fails
+	started := false
+	defer func() {
+		if started {
+			s.recordUsage(model, usage)
+		}
+	}()
 
 	for {
 		ev, err := reader.Next()
@@ -194,10 +542,17 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 		}
 
 		switch ev.Type {
+		case EventMessageStart:
+			if ev.MessageStart != nil {
+				model, usage = ev.MessageStart.Message.Model, ev.MessageStart.Message.Usage
+				started = true
+			}
+
 		case EventContentBlockStart:
 			if ev.ContentBlockStart == nil {
 				continue
 			}
+			s.chunkDedup.Reset()
 			idx := ev.ContentBlockStart.Index
 			cb := ev.ContentBlockStart.ContentBlock
 			blocks[idx] = &contentBlockState{
@@ -207,18 +562,15 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 				toolName:  cb.Name,
 			}
 
-			// If tool_use, create pending tool state
-			if cb.Type == BlockTypeToolUse {
-				state := &backend.ToolState{
-					ID:       cb.ID,
-					Status:   "pending",
-					Title:    cb.Name,
-					Kind:     "tool",
-					ToolName: cb.Name,
-					ParentID: s.toolManager.CurrentParent(),
-				}
-				s.toolManager.Set(state)
-				s.emitToolState(state)
+			switch cb.Type {
+			case BlockTypeToolUse:
+				s.startToolState(cb.ID, cb.Name)
+			case BlockTypeServerToolUse:
+				s.startServerToolState(cb.ID, cb.Name)
+			case BlockTypeWebSearchResult:
+				// results arrive whole in the start event
+				assistantContent = append(assistantContent, cb)
+				s.finishWebSearch(cb)
 			}
 
 		case EventContentBlockDelta:
@@ -235,17 +587,14 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 			switch delta.Type {
 			case DeltaTypeText:
 				block.textBuilder.WriteString(delta.Text)
-				s.emit(backend.Event{
-					Type: backend.EventMessageChunk,
-					Data: delta.Text,
-				})
+				s.emitChunk(backend.EventMessageChunk, delta.Text)
 			case DeltaTypeInputJSON:
 				block.jsonBuilder.WriteString(delta.PartialJSON)
 			case DeltaTypeThinking:
-				s.emit(backend.Event{
-					Type: backend.EventThoughtChunk,
-					Data: delta.Thinking,
-				})
+				block.textBuilder.WriteString(delta.Thinking)
+				s.emitChunk(backend.EventThoughtChunk, delta.Thinking)
+			case DeltaTypeSignature:
+				block.signature += delta.Signature
 			}
 
 		case EventContentBlockStop:
@@ -265,11 +614,25 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 					Type: BlockTypeText,
 					Text: block.textBuilder.String(),
 				})
+			case BlockTypeThinking:
+				// replayed next turn so the model keeps its reasoning; tool_use
+				// continuations require the signed block
+				assistantContent = append(assistantContent, ContentBlock{
+					Type:      BlockTypeThinking,
+					Thinking:  block.textBuilder.String(),
+					Signature: block.signature,
+				})
 			case BlockTypeToolUse:
-				// Parse accumulated JSON input
+				// Parse accumulated JSON input; a truncated stream must fail the
+				// call rather than run the tool with no arguments
 				var input map[string]any
 				if block.jsonBuilder.Len() > 0 {
-					json.Unmarshal([]byte(block.jsonBuilder.String()), &input)
+					if err := json.Unmarshal([]byte(block.jsonBuilder.String()), &input); err != nil {
+						if malformed == nil {
+							malformed = make(map[string]error)
+						}
+						malformed[block.toolID] = err
+					}
 				}
 				assistantContent = append(assistantContent, ContentBlock{
 					Type:  BlockTypeToolUse,
@@ -282,12 +645,36 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 				s.toolManager.Update(block.toolID, func(ts *backend.ToolState) {
 					ts.Input = input
 				})
+			case BlockTypeServerToolUse:
+				var input map[string]any
+				if block.jsonBuilder.Len() > 0 {
+					json.Unmarshal([]byte(block.jsonBuilder.String()), &input)
+				}
+				assistantContent = append(assistantContent, ContentBlock{
+					Type:  BlockTypeServerToolUse,
+					ID:    block.toolID,
+					Name:  block.toolName,
+					Input: input,
+				})
+				s.updateToolState(block.toolID, func(ts *backend.ToolState) {
+					ts.Input = input
+				})
 			}
 			delete(blocks, idx)
 
 		case EventMessageDelta:
 			if ev.MessageDelta != nil {
 				stopReason = ev.MessageDelta.Delta.StopReason
+				// message_delta counts are cumulative; input counts are
+				// sometimes only in message_start
+				if u := ev.MessageDelta.Usage; u.OutputTokens > 0 {
+					usage.OutputTokens = u.OutputTokens
+				}
+				if u := ev.MessageDelta.Usage; u.InputTokens > 0 {
+					usage.InputTokens = u.InputTokens
+					usage.CacheCreationInputTokens = u.CacheCreationInputTokens
+					usage.CacheReadInputTokens = u.CacheReadInputTokens
+				}
 			}
 
 		case EventError:
@@ -297,6 +684,22 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 		}
 	}
 
+	return s.finishResponse(assistantContent, stopReason, malformed)
+}
+
+// finishResponse records the assistant turn and runs any requested tools;
+// malformed maps tool_use IDs whose streamed input did not parse to the error
+func (s *AnthropicSession) finishResponse(assistantContent []ContentBlock, stopReason string, malformed map[string]error) (string, error) {
+	// Plan mode never executes tools: drop tool_use blocks and end the turn
+	if stopReason == StopReasonToolUse && s.CurrentMode() == ModePlan {
+		assistantContent = s.skipToolUses(assistantContent)
+		stopReason = StopReasonEndTurn
+		// keep the assistant turn so the next prompt doesn't follow a user message
+		if !hasText(assistantContent) {
+			assistantContent = append(assistantContent, ContentBlock{Type: BlockTypeText, Text: planModeSkippedText})
+		}
+	}
+
 	// Add assistant message to history
 	if len(assistantContent) > 0 {
 		s.mu.Lock()
@@ -309,7 +712,7 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 
 	// Execute tools if stop_reason is tool_use
 	if stopReason == StopReasonToolUse {
-		if err := s.executeTools(assistantContent); err != nil {
+		if err := s.executeTools(assistantContent, malformed); err != nil {
 			return "", err
 		}
 	}
@@ -317,14 +720,53 @@ func (s *AnthropicSession) processStream(body io.ReadCloser) (string, error) {
 	return stopReason, nil
 }
 
-// executeTools processes tool_use blocks and adds results to history
-func (s *AnthropicSession) executeTools(content []ContentBlock) error {
+// hasText reports whether content has a non-empty text block
+func hasText(content []ContentBlock) bool {
+	for _, block := range content {
+		if block.Type == BlockTypeText && strings.TrimSpace(block.Text) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// skipToolUses marks tool_use blocks as skipped and returns the remaining content
+func (s *AnthropicSession) skipToolUses(content []ContentBlock) []ContentBlock {
+	var kept []ContentBlock
+	for _, block := range content {
+		if block.Type != BlockTypeToolUse {
+			kept = append(kept, block)
+			continue
+		}
+		state := s.toolManager.Update(block.ID, func(ts *backend.ToolState) {
+			ts.Status = "error"
+			ts.Output = []backend.OutputBlock{{
+				Type:    "text",
+				Content: &backend.TextContent{Type: "text", Text: "Skipped in plan mode"},
+			}}
+		})
+		s.emitToolState(state)
+	}
+	return kept
+}
+
+// executeTools processes tool_use blocks and adds results to history; blocks
+// in malformed get an error result without running
+func (s *AnthropicSession) executeTools(content []ContentBlock, malformed map[string]error) error {
 	var toolResults []ContentBlock
 
 	for _, block := range content {
 		if block.Type != BlockTypeToolUse {
 			continue
 		}
+		if perr := malformed[block.ID]; perr != nil {
+			s.updateToolState(block.ID, func(ts *backend.ToolState) {
+				ts.Status = "error"
+			})
+			result, _ := s.toolError(block.ID, fmt.Sprintf("malformed tool input for %s: %v", block.Name, perr))
+			toolResults = append(toolResults, result)
+			continue
+		}
 
 		result, err := s.executeTool(block.ID, block.Name, block.Input)
 		if err != nil {
@@ -346,19 +788,82 @@ func (s *AnthropicSession) executeTools(content []ContentBlock) error {
 }
 
 // executeTool executes a single tool with permission checking
-func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (ContentBlock, error) {
+func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (result ContentBlock, err error) {
 	inputJSON, _ := json.Marshal(input)
 
+	// Every outcome below is audited, including denials
+	audit := backend.AuditAllow
+	start := time.Now()
+	defer func() {
+		s.recordAudit(id, name, inputJSON, audit, start, err == nil && !result.IsError)
+	}()
+
+	// The allowlist is a hard limit, even for tools the model was not offered
+	s.mu.Lock()
+	allowed := s.toolAllowedLocked(name)
+	s.mu.Unlock()
+	if !allowed {
+		audit = backend.AuditDeny
+		if state := s.toolManager.Update(id, func(ts *backend.ToolState) {
+			ts.Status = "error"
+		}); state != nil {
+			s.emitToolState(state)
+		}
+		return s.toolError(id, fmt.Sprintf("Permission denied: %s is not in allowedTools", name))
+	}
+
+	// Read-only mode keeps tools visible but refuses anything mutating
+	if !readOnlyTools[name] && s.CurrentMode() == ModeReadOnly {
+		audit = backend.AuditDeny
+		if state := s.toolManager.Update(id, func(ts *backend.ToolState) {
+			ts.Status = "error"
+		}); state != nil {
+			s.emitToolState(state)
+		}
+		return s.toolError(id, fmt.Sprintf("Permission denied: %s is not available in read-only mode", name))
+	}
+
+	// Plan updates are session-local and need no permission
+	if name == planToolName {
+		return s.updatePlan(id, input), nil
+	}
+
+	// Unknown tools fail the call, not the turn, and never prompt the user
+	if !s.toolRegistered(name) {
+		return s.unknownTool(id, name)
+	}
+
+	// Malformed input is rejected uniformly before anyone is asked to approve it
+	if schema, ok := toolSchema(name); ok {
+		if verr := validateInput(schema, input); verr != nil {
+			if state := s.toolManager.Update(id, func(ts *backend.ToolState) {
+				ts.Status = "error"
+			}); state != nil {
+				s.emitToolState(state)
+			}
+			return s.toolError(id, fmt.Sprintf("invalid input for %s: %v", name, verr))
+		}
+	}
+
+	// The denylist applies even when auto-permission skips the rules
+	if s.autoPermission && s.backend.permLayer != nil && s.backend.permLayer.Denied(name, string(inputJSON)) {
+		audit = backend.AuditDeny
+		return s.toolError(id, "Permission denied")
+	}
+
 	// Skip permission check if auto-permission enabled
-	if !s.autoPermission {
+	if s.autoPermission {
+		audit = backend.AuditAuto
+	} else {
 		// Check permission
 		decision := s.backend.permLayer.Check(name, string(inputJSON))
 
 		switch decision {
 		case permission.Deny:
+			audit = backend.AuditDeny
 			return s.toolError(id, "Permission denied")
 
-			case permission.Ask:
+		case permission.Ask:
 			// Update state to awaiting_permission
 			state := s.toolManager.Update(id, func(ts *backend.ToolState) {
 				ts.Status = "awaiting_permission"
@@ -372,20 +877,23 @@ func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (C
 			}
 
 			// Request permission (blocks until user responds)
-			optionID, err := s.backend.permLayer.Request(id, name, []backend.PermOption{
+			optionID, err := s.backend.permLayer.Request(s.id, id, name, string(inputJSON), []backend.PermOption{
 				{OptionID: "allow", Name: "Allow", Kind: "allow"},
 				{OptionID: "deny", Name: "Deny", Kind: "deny"},
 			})
 			if err != nil {
+				audit = backend.AuditUserDenied
 				return s.toolError(id, fmt.Sprintf("Permission request failed: %v", err))
 			}
 
 			if optionID != "allow" {
+				audit = backend.AuditUserDenied
 				s.toolManager.Update(id, func(ts *backend.ToolState) {
 					ts.Status = "error"
 				})
 				return s.toolError(id, "User denied permission")
 			}
+			audit = backend.AuditUserAllowed
 		}
 	}
 
@@ -395,8 +903,33 @@ func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (C
 	})
 	s.emitToolState(s.toolManager.Get(id))
 
-	// Execute the tool
-	result, err := s.backend.executor.Execute(s.ctx, name, input)
+	// Execute the tool, routing any streamed output and progress to this session
+	toolCtx := tools.WithOutputSink(s.ctx, func(chunk string) {
+		s.emit(backend.Event{
+			Type: backend.EventToolOutputChunk,
+			Data: backend.ToolOutputChunk{ToolCallID: id, Chunk: chunk},
+		})
+	})
+	toolCtx = tools.WithProgressSink(toolCtx, func(p tools.Progress) {
+		s.emit(backend.Event{
+			Type: backend.EventToolProgress,
+			Data: backend.ToolProgress{ToolCallID: id, FilesScanned: p.FilesScanned, Matches: p.Matches},
+		})
+	})
+	if s.opts.WorkspaceRoot != "" {
+		toolCtx = tools.WithWorkspaceRoot(toolCtx, s.opts.WorkspaceRoot)
+	}
+	if s.opts.CWD != "" {
+		toolCtx = tools.WithWorkDir(toolCtx, s.opts.CWD)
+	}
+	if s.opts.DiffContextLines != 0 {
+		toolCtx = tools.WithDiffContext(toolCtx, s.opts.DiffContextLines)
+	}
+	toolCtx = tools.WithBackgroundManager(toolCtx, s.background)
+	toolResult, err := s.backend.executor.Execute(toolCtx, name, input)
+	if errors.Is(err, tools.ErrToolNotFound) {
+		return s.unknownTool(id, name)
+	}
 	if err != nil {
 		s.toolManager.Update(id, func(ts *backend.ToolState) {
 			ts.Status = "error"
@@ -404,9 +937,20 @@ func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (C
 		return s.toolError(id, fmt.Sprintf("Execution failed: %v", err))
 	}
 
-	// Track file changes (only for Write/Edit tools)
-	if result.FilePath != "" && (name == "Write" || name == "Edit") {
-		s.fileStore.RecordChange(result.FilePath, result.OldContent, result.NewContent, result.Hunks)
+	// Track file changes (multi-file tools report Changes, editors a single FilePath)
+	changes := toolResult.Changes
+	if len(changes) == 0 && toolResult.FilePath != "" && (name == "Write" || name == "Edit" || name == "StructuredEdit") {
+		changes = []backend.FileChange{{
+			FilePath:        toolResult.FilePath,
+			OriginalContent: toolResult.OldContent,
+			CurrentContent:  toolResult.NewContent,
+			Hunks:           toolResult.Hunks,
+		}}
+	}
+	if len(changes) > 0 {
+		for _, c := range changes {
+			s.fileStore.RecordChange(c.FilePath, c.OriginalContent, c.CurrentContent, c.Hunks)
+		}
 		s.emit(backend.Event{
 			Type: backend.EventFileChanges,
 			Data: s.fileStore.GetAll(),
@@ -416,10 +960,11 @@ func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (C
 	// Update state to completed
 	state := s.toolManager.Update(id, func(ts *backend.ToolState) {
 		ts.Status = "completed"
-		if result.Content != "" {
+		if toolResult.Content != "" {
 			ts.Output = []backend.OutputBlock{{
-				Type:    "text",
-				Content: &backend.TextContent{Type: "text", Text: result.Content},
+				Type:     "text",
+				Content:  &backend.TextContent{Type: "text", Text: toolResult.Content},
+				MimeType: toolResult.MimeType(),
 			}}
 		}
 	})
@@ -431,11 +976,102 @@ func (s *AnthropicSession) executeTool(id, name string, input map[string]any) (C
 	return ContentBlock{
 		Type:      BlockTypeToolResult,
 		ToolUseID: id,
-		Content:   result.Content,
-		IsError:   result.IsError,
+		Content:   toolResultContent(toolResult),
+		IsError:   toolResult.IsError,
 	}, nil
 }
 
+// toolResultContent returns the tool_result content: the plain string, or
+// text and image blocks when the tool returned multi-part output
+func toolResultContent(r tools.ToolResult) any {
+	if len(r.Blocks) == 0 {
+		return r.Content
+	}
+	blocks := make([]ContentBlock, 0, len(r.Blocks))
+	for _, b := range r.Blocks {
+		switch b.Type {
+		case BlockTypeImage:
+			blocks = append(blocks, ContentBlock{
+				Type:   BlockTypeImage,
+				Source: &ImageSource{Type: "base64", MediaType: b.MimeType, Data: b.Data},
+			})
+		default:
+			if b.Text != "" {
+				blocks = append(blocks, ContentBlock{Type: BlockTypeText, Text: b.Text})
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		return r.Content
+	}
+	return blocks
+}
+
+// updatePlan translates TodoWrite input into plan entries and emits a plan update
+func (s *AnthropicSession) updatePlan(id string, input map[string]any) ContentBlock {
+	entries := parsePlanEntries(input)
+	s.mu.Lock()
+	s.plan = entries
+	s.mu.Unlock()
+	s.emit(backend.Event{Type: backend.EventPlanUpdate, Data: entries})
+
+	state := s.toolManager.Update(id, func(ts *backend.ToolState) {
+		ts.Status = "completed"
+	})
+	s.emitToolState(state)
+
+	return ContentBlock{
+		Type:      BlockTypeToolResult,
+		ToolUseID: id,
+		Content:   fmt.Sprintf("Plan updated with %d entries", len(entries)),
+	}
+}
+
+// parsePlanEntries reads the todos array, defaulting missing status/priority
+func parsePlanEntries(input map[string]any) []backend.PlanEntry {
+	todos, _ := input["todos"].([]any)
+	entries := make([]backend.PlanEntry, 0, len(todos))
+	for _, raw := range todos {
+		m, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		content, _ := m["content"].(string)
+		if content == "" {
+			continue
+		}
+		entry := backend.PlanEntry{Content: content, Status: "pending", Priority: "medium"}
+		if v, ok := m["status"].(string); ok && v != "" {
+			entry.Status = v
+		}
+		if v, ok := m["priority"].(string); ok && v != "" {
+			entry.Priority = v
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// recordAudit appends a tool call to the audit log, if enabled
+func (s *AnthropicSession) recordAudit(id, name string, inputJSON []byte, decision string, start time.Time, success bool) {
+	if s.audit == nil {
+		return
+	}
+	err := s.audit.Record(backend.AuditEntry{
+		Time:       start,
+		SessionID:  s.id,
+		ToolCallID: id,
+		Tool:       name,
+		InputHash:  backend.HashInput(inputJSON),
+		Decision:   decision,
+		DurationMs: time.Since(start).Milliseconds(),
+		Success:    success,
+	})
+	if err != nil {
+		slog.Error("audit log write failed", "session", s.id, "error", err)
+	}
+}
+
 // toolError creates a tool_result error block
 func (s *AnthropicSession) toolError(id, msg string) (ContentBlock, error) {
 	return ContentBlock{
@@ -446,6 +1082,24 @@ func (s *AnthropicSession) toolError(id, msg string) (ContentBlock, error) {
 	}, nil
 }
 
+// toolRegistered reports whether the executor knows name; executors that
+// cannot say are assumed to
+func (s *AnthropicSession) toolRegistered(name string) bool {
+	reg, ok := s.backend.executor.(interface{ Has(string) bool })
+	return !ok || reg.Has(name)
+}
+
+// unknownTool marks the call failed and returns an error result the model can
+// recover from
+func (s *AnthropicSession) unknownTool(id, name string) (ContentBlock, error) {
+	if state := s.toolManager.Update(id, func(ts *backend.ToolState) {
+		ts.Status = "error"
+	}); state != nil {
+		s.emitToolState(state)
+	}
+	return s.toolError(id, fmt.Sprintf("unknown tool %s", name))
+}
+
 // emit sends an event to the event channel
 func (s *AnthropicSession) emit(ev backend.Event) {
 	if s.opts.EventChan != nil {
@@ -456,24 +1110,19 @@ func (s *AnthropicSession) emit(ev backend.Event) {
 	}
 }
 
+// emitChunk emits a message or thought chunk unless it repeats the previous one
+// and de-duplication is on; history keeps the text either way
+func (s *AnthropicSession) emitChunk(eventType backend.EventType, text string) {
+	if s.chunkDedup.Keep(eventType, text) {
+		s.emit(backend.Event{Type: eventType, Data: text})
+	}
+}
+
 // emitToolState emits a copy of the tool state to avoid mutation issues
 func (s *AnthropicSession) emitToolState(state *backend.ToolState) {
 	if state == nil || s.suppressToolEvents {
 		return
 	}
-	// Copy the state to avoid race conditions with later mutations
-	copy := &backend.ToolState{
-		ID:                state.ID,
-		Status:            state.Status,
-		Title:             state.Title,
-		Kind:              state.Kind,
-		ToolName:          state.ToolName,
-		ParentID:          state.ParentID,
-		Input:             state.Input,
-		Output:            state.Output,
-		Diff:              state.Diff,
-		Diffs:             state.Diffs,
-		PermissionOptions: state.PermissionOptions,
-	}
-	s.emit(backend.Event{Type: backend.EventToolState, Data: copy})
+	// Deep copy so later mutations don't race with the consumer
+	s.emit(backend.Event{Type: backend.EventToolState, Data: state.Clone()})
 }
@@ -4,11 +4,22 @@ package anthropic
 func DefaultTools() []Tool {
 	return []Tool{
 		readTool(),
+		readManyTool(),
 		writeTool(),
 		editTool(),
+		structuredEditTool(),
+		applyPatchTool(),
 		bashTool(),
+		listBackgroundTool(),
+		readBackgroundTool(),
+		killBackgroundTool(),
 		globTool(),
 		grepTool(),
+		outlineTool(),
+		gitStatusTool(),
+		gitDiffTool(),
+		projectInfoTool(),
+		todoWriteTool(),
 	}
 }
 
@@ -37,6 +48,24 @@ func readTool() Tool {
 	}
 }
 
+func readManyTool() Tool {
+	return Tool{
+		Name:        "ReadMany",
+		Description: "Reads several files in one call. Each file appears under a \"==> path <==\" header with line numbers as in Read. Output is capped at 256KB; files past the cap are listed so you can Read them separately.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"file_paths": {
+					Type:        "array",
+					Description: "Absolute file paths or glob patterns (e.g. \"src/**/*.go\"), read in order",
+					Items:       &Property{Type: "string"},
+				},
+			},
+			Required: []string{"file_paths"},
+		},
+	}
+}
+
 func writeTool() Tool {
 	return Tool{
 		Name:        "Write",
@@ -88,6 +117,48 @@ func editTool() Tool {
 	}
 }
 
+func structuredEditTool() Tool {
+	return Tool{
+		Name:        "StructuredEdit",
+		Description: "Sets a value by key path in a JSON or YAML file, preserving key order. Prefer this over Edit for config files.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"file_path": {
+					Type:        "string",
+					Description: "The absolute path to the .json, .yaml or .yml file to modify",
+				},
+				"key_path": {
+					Type:        "string",
+					Description: "Dot-separated path to the key, e.g. \"server.ports.0\". Numeric segments index arrays; missing objects are created",
+				},
+				"value": {
+					Type:        "string",
+					Description: "The new value as JSON (e.g. 8080, true, \"text\", {\"a\": 1}). Text that is not valid JSON is stored as a string",
+				},
+			},
+			Required: []string{"file_path", "key_path", "value"},
+		},
+	}
+}
+
+func applyPatchTool() Tool {
+	return Tool{
+		Name:        "ApplyPatch",
+		Description: "Applies a unified diff to one or more files. Every hunk's context must match the current file exactly; if any hunk fails, no file is changed.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"patch": {
+					Type:        "string",
+					Description: "A unified diff with ---/+++ file headers (absolute paths, optional a/ b/ prefixes) and @@ hunks. Use /dev/null to create or delete files",
+				},
+			},
+			Required: []string{"patch"},
+		},
+	}
+}
+
 func bashTool() Tool {
 	return Tool{
 		Name:        "Bash",
@@ -103,12 +174,62 @@ func bashTool() Tool {
 					Type:        "number",
 					Description: "Optional timeout in milliseconds (default 120000, max 600000)",
 				},
+				"background": {
+					Type:        "boolean",
+					Description: "Run the command in the background and return a process id immediately (e.g. for dev servers)",
+					Default:     false,
+				},
 			},
 			Required: []string{"command"},
 		},
 	}
 }
 
+func listBackgroundTool() Tool {
+	return Tool{
+		Name:        "ListBackground",
+		Description: "Lists background processes started with Bash background: true, with their id, pid and status.",
+		InputSchema: InputSchema{
+			Type:       "object",
+			Properties: map[string]Property{},
+		},
+	}
+}
+
+func readBackgroundTool() Tool {
+	return Tool{
+		Name:        "ReadBackground",
+		Description: "Returns the status and recent combined stdout/stderr of a background process started with Bash background: true.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"id": {
+					Type:        "string",
+					Description: "The background process id returned by Bash (e.g. \"bg-1\")",
+				},
+			},
+			Required: []string{"id"},
+		},
+	}
+}
+
+func killBackgroundTool() Tool {
+	return Tool{
+		Name:        "KillBackground",
+		Description: "Kills a background process started with Bash background: true.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"id": {
+					Type:        "string",
+					Description: "The background process id returned by Bash (e.g. \"bg-1\")",
+				},
+			},
+			Required: []string{"id"},
+		},
+	}
+}
+
 func globTool() Tool {
 	return Tool{
 		Name:        "Glob",
@@ -124,12 +245,98 @@ func globTool() Tool {
 					Type:        "string",
 					Description: "The directory to search in. Defaults to current working directory.",
 				},
+				"limit": {
+					Type:        "number",
+					Description: "Return at most this many files, newest first",
+				},
+				"relative": {
+					Type:        "boolean",
+					Description: "Return paths relative to the search directory instead of absolute paths",
+				},
+				"exclude": {
+					Type:        "array",
+					Description: "Directory glob patterns to skip, matched against directory names and paths. Defaults to [\".git\", \"node_modules\", \"vendor\"]; pass [] to search everything.",
+					Items:       &Property{Type: "string"},
+				},
+				"follow_symlinks": {
+					Type:        "boolean",
+					Description: "Descend into symlinked directories; each directory is visited once, so link cycles are safe",
+				},
 			},
 			Required: []string{"pattern"},
 		},
 	}
 }
 
+func outlineTool() Tool {
+	return Tool{
+		Name:        "Outline",
+		Description: "Lists a source file's declarations (funcs, methods, types, consts, vars) with line numbers. Go files are parsed exactly; other languages use a keyword heuristic. Use it to locate code, then Read just those lines.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"file_path": {
+					Type:        "string",
+					Description: "The absolute path to the file to outline",
+				},
+			},
+			Required: []string{"file_path"},
+		},
+	}
+}
+
+func gitStatusTool() Tool {
+	return Tool{
+		Name:        "GitStatus",
+		Description: "Reports the git working tree state as JSON: branch, staged and unstaged changes, untracked files and conflicts. Read-only; prefer it to running git status through Bash.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"path": {
+					Type:        "string",
+					Description: "A directory inside the repository. Defaults to the session's working directory.",
+				},
+			},
+		},
+	}
+}
+
+func gitDiffTool() Tool {
+	return Tool{
+		Name:        "GitDiff",
+		Description: "Shows uncommitted changes as a unified diff and adds them to the review panel. Read-only.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"path": {
+					Type:        "string",
+					Description: "A directory inside the repository. Defaults to the session's working directory.",
+				},
+				"staged": {
+					Type:        "boolean",
+					Description: "Diff the index against HEAD instead of the working tree against the index",
+				},
+			},
+		},
+	}
+}
+
+func projectInfoTool() Tool {
+	return Tool{
+		Name:        "ProjectInfo",
+		Description: "Summarizes a project as JSON: git branch and change count, go.mod module, detected languages and frameworks, and top-level files. Read-only; call it first to orient yourself in an unfamiliar project.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"path": {
+					Type:        "string",
+					Description: "The project directory. Defaults to the session's working directory.",
+				},
+			},
+		},
+	}
+}
+
 func grepTool() Tool {
 	return Tool{
 		Name:        "Grep",
@@ -158,6 +365,22 @@ func grepTool() Tool {
 					Type:        "boolean",
 					Description: "Case insensitive search",
 				},
+				"fixed_strings": {
+					Type:        "boolean",
+					Description: "Treat pattern as a literal string instead of a regex",
+				},
+				"word": {
+					Type:        "boolean",
+					Description: "Match whole words only",
+				},
+				"invert": {
+					Type:        "boolean",
+					Description: "Select non-matching lines. In files_with_matches mode, lists files with at least one non-matching line",
+				},
+				"files_without_match": {
+					Type:        "boolean",
+					Description: "With invert in files_with_matches mode, list only files where no line matches",
+				},
 				"-A": {
 					Type:        "number",
 					Description: "Number of lines to show after each match (requires output_mode: content)",
@@ -170,12 +393,79 @@ func grepTool() Tool {
 					Type:        "number",
 					Description: "Number of lines to show before and after each match (requires output_mode: content)",
 				},
+				"show_column": {
+					Type:        "boolean",
+					Description: "Prefix match lines with line:column (requires output_mode: content)",
+				},
+				"per_file": {
+					Type:        "boolean",
+					Description: "Report path:count per file, highest first (requires output_mode: count)",
+				},
 				"head_limit": {
 					Type:        "number",
-					Description: "Limit output to first N entries",
+					Description: "Limit output to first N entries (matching lines in content mode)",
+				},
+				"exclude": {
+					Type:        "array",
+					Description: "Directory glob patterns to skip, matched against directory names and paths. Defaults to [\".git\", \"node_modules\", \"vendor\"]; pass [] to search everything.",
+					Items:       &Property{Type: "string"},
+				},
+				"follow_symlinks": {
+					Type:        "boolean",
+					Description: "Descend into symlinked directories; each directory is visited once, so link cycles are safe",
 				},
 			},
 			Required: []string{"pattern"},
 		},
 	}
 }
+
+// planToolName is handled by the session itself to emit plan updates
+const planToolName = "TodoWrite"
+
+func todoWriteTool() Tool {
+	return Tool{
+		Name:        planToolName,
+		Description: "Records the current task plan. Send the full list each time; use it to track progress on multi-step work.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"todos": {
+					Type:        "array",
+					Description: "The complete, updated list of plan entries",
+					Items: &Property{
+						Type: "object",
+						Properties: map[string]Property{
+							"content": {
+								Type:        "string",
+								Description: "What needs to be done",
+							},
+							"status": {
+								Type: "string",
+								Enum: []string{"pending", "in_progress", "completed"},
+							},
+							"priority": {
+								Type: "string",
+								Enum: []string{"high", "medium", "low"},
+							},
+						},
+						Required: []string{"content", "status"},
+					},
+				},
+			},
+			Required: []string{"todos"},
+		},
+	}
+}
+
+// webSearchToolType is the versioned type of Anthropic's server-side web search tool
+const webSearchToolType = "web_search_20250305"
+
+// webSearchTool returns the server-side web search tool; maxUses 0 leaves it unlimited
+func webSearchTool(maxUses int) Tool {
+	return Tool{
+		Type:    webSearchToolType,
+		Name:    "web_search",
+		MaxUses: maxUses,
+	}
+}
@@ -13,6 +13,8 @@ type MessagesRequest struct {
 	Stream      bool            `json:"stream,omitempty"`
 	Thinking    *ThinkingConfig `json:"thinking,omitempty"`
 	Metadata    *Metadata       `json:"metadata,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
 }
 
 // ToolChoice specifies how tools should be used
@@ -50,9 +52,9 @@ type Message struct {
 	Content []ContentBlock `json:"content"`
 }
 
-// ContentBlock types: text, tool_use, tool_result, thinking, server_tool_use, web_search_tool_result
+// ContentBlock types: text, image, tool_use, tool_result, thinking, server_tool_use, web_search_tool_result
 type ContentBlock struct {
-	Type string `json:"type"` // "text", "tool_use", "tool_result", "thinking", "server_tool_use", "web_search_tool_result"
+	Type string `json:"type"` // "text", "image", "tool_use", "tool_result", "thinking", "server_tool_use", "web_search_tool_result"
 
 	// text block
 	Text string `json:"text,omitempty"`
@@ -70,6 +72,16 @@ type ContentBlock struct {
 	// thinking block
 	Thinking  string `json:"thinking,omitempty"`
 	Signature string `json:"signature,omitempty"`
+
+	// image block
+	Source *ImageSource `json:"source,omitempty"`
+}
+
+// ImageSource is the inline data of an image block
+type ImageSource struct {
+	Type      string `json:"type"` // "base64"
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
 // Usage tracks token usage
@@ -91,6 +103,24 @@ type Tool struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description,omitempty"`
 	InputSchema InputSchema `json:"input_schema"`
+
+	// server tools (e.g. web_search) set Type and carry no schema
+	Type    string `json:"-"`
+	MaxUses int    `json:"-"`
+}
+
+// MarshalJSON encodes server tools as {type, name, max_uses} and client tools
+// with their input schema
+func (t Tool) MarshalJSON() ([]byte, error) {
+	if t.Type != "" {
+		return json.Marshal(struct {
+			Type    string `json:"type"`
+			Name    string `json:"name"`
+			MaxUses int    `json:"max_uses,omitempty"`
+		}{t.Type, t.Name, t.MaxUses})
+	}
+	type clientTool Tool
+	return json.Marshal(clientTool(t))
 }
 
 // InputSchema is JSON Schema for tool input
@@ -193,6 +223,12 @@ type APIError struct {
 	Message string `json:"message"`
 }
 
+// AuthRequired is emitted when the API rejects the key with a 401; the
+// message field matches the ACP event so the UI can show either
+type AuthRequired struct {
+	Message string `json:"message"`
+}
+
 // SSE event type constants
 const (
 	EventMessageStart     = "message_start"
@@ -219,6 +255,7 @@ const (
 	BlockTypeToolUse           = "tool_use"
 	BlockTypeToolResult        = "tool_result"
 	BlockTypeThinking          = "thinking"
+	BlockTypeImage             = "image"
 	BlockTypeServerToolUse     = "server_tool_use"
 	BlockTypeWebSearchResult   = "web_search_tool_result"
 )
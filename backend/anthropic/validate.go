@@ -0,0 +1,140 @@
+package anthropic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// toolSchema returns the advertised input schema for name, if it is one of
+// DefaultTools
+func toolSchema(name string) (InputSchema, bool) {
+	for _, t := range DefaultTools() {
+		if t.Name == name {
+			return t.InputSchema, true
+		}
+	}
+	return InputSchema{}, false
+}
+
+// validateInput checks input against schema's required fields, property types
+// and enums; properties the schema does not declare are left to the tool
+func validateInput(schema InputSchema, input map[string]any) error {
+	return validateObject("", schema.Properties, schema.Required, input)
+}
+
+func validateObject(path string, props map[string]Property, required []string, obj map[string]any) error {
+	for _, field := range required {
+		if v, ok := obj[field]; !ok || v == nil {
+			return fmt.Errorf("missing required field %q", joinPath(path, field))
+		}
+	}
+	// Sorted so the reported field is stable when several are wrong
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		prop, ok := props[k]
+		if !ok || obj[k] == nil {
+			continue
+		}
+		if err := validateValue(joinPath(path, k), prop, obj[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateValue(path string, prop Property, v any) error {
+	switch prop.Type {
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return typeError(path, "a string", v)
+		}
+		if len(prop.Enum) > 0 && !slices.Contains(prop.Enum, s) {
+			return fmt.Errorf("field %q must be one of %s, got %q", path, strings.Join(prop.Enum, ", "), s)
+		}
+	case "number", "integer":
+		f, ok := toFloat(v)
+		if !ok {
+			return typeError(path, "a number", v)
+		}
+		if prop.Type == "integer" && f != float64(int64(f)) {
+			return typeError(path, "an integer", v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return typeError(path, "a boolean", v)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return typeError(path, "an array", v)
+		}
+		if prop.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := validateValue(fmt.Sprintf("%s[%d]", path, i), *prop.Items, item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return typeError(path, "an object", v)
+		}
+		return validateObject(path, prop.Properties, prop.Required, obj)
+	}
+	return nil
+}
+
+// toFloat accepts decoded JSON numbers and the Go numeric types tests and
+// callers may pass directly
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func typeError(path, want string, got any) error {
+	return fmt.Errorf("field %q must be %s, got %s", path, want, jsonTypeName(got))
+}
+
+// jsonTypeName names v's JSON type for error messages
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
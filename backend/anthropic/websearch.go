@@ -0,0 +1,71 @@
+package anthropic
+
+import (
+	"fmt"
+	"strings"
+
+	"ccui/backend"
+)
+
+// startServerToolState records a server_tool_use block; the API runs it, so
+// it goes straight to running
+func (s *AnthropicSession) startServerToolState(id, name string) {
+	state := &backend.ToolState{
+		ID:       id,
+		Status:   "running",
+		Title:    name,
+		Kind:     "search",
+		ToolName: name,
+		ParentID: s.toolManager.CurrentParent(),
+	}
+	s.toolManager.Set(state)
+	s.emitToolState(state)
+}
+
+// updateToolState applies fn to the tool state and emits the result
+func (s *AnthropicSession) updateToolState(id string, fn func(*backend.ToolState)) {
+	if state := s.toolManager.Update(id, fn); state != nil {
+		s.emitToolState(state)
+	}
+}
+
+// finishWebSearch completes the server_tool_use state a web_search_tool_result answers
+func (s *AnthropicSession) finishWebSearch(cb ContentBlock) {
+	text, isError := summarizeWebSearch(cb.Content)
+	s.updateToolState(cb.ToolUseID, func(ts *backend.ToolState) {
+		ts.Status = "completed"
+		if isError {
+			ts.Status = "error"
+		}
+		ts.Output = []backend.OutputBlock{{
+			Type:    "text",
+			Content: &backend.TextContent{Type: "text", Text: text},
+		}}
+	})
+}
+
+// summarizeWebSearch renders web_search_tool_result content as one
+// "title - url" line per result, or the error code when the search failed
+func summarizeWebSearch(content any) (string, bool) {
+	switch c := content.(type) {
+	case map[string]any:
+		code, _ := c["error_code"].(string)
+		return fmt.Sprintf("web search failed: %s", code), true
+	case []any:
+		var lines []string
+		for _, item := range c {
+			r, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			title, _ := r["title"].(string)
+			url, _ := r["url"].(string)
+			lines = append(lines, fmt.Sprintf("%s - %s", title, url))
+		}
+		if len(lines) == 0 {
+			return "no results", false
+		}
+		return strings.Join(lines, "\n"), false
+	}
+	return "", false
+}
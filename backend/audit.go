@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Audit decisions recorded for each tool call
+const (
+	AuditAllow       = "allow"        // allowed by rule
+	AuditDeny        = "deny"         // denied by rule, denylist, or allowedTools
+	AuditUserAllowed = "user_allowed" // user approved an Ask
+	AuditUserDenied  = "user_denied"  // user rejected an Ask
+	AuditAuto        = "auto"         // auto-permission skipped the rules
+)
+
+// AuditEntry is one JSONL record of a tool call
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	SessionID  string    `json:"sessionId"`
+	ToolCallID string    `json:"toolCallId"`
+	Tool       string    `json:"tool"`
+	InputHash  string    `json:"inputHash"` // sha256 of the JSON input
+	Decision   string    `json:"decision"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+}
+
+// AuditLog appends tool call records to a JSONL file
+type AuditLog struct {
+	path string
+	file *os.File
+	enc  *json.Encoder
+	mu   sync.Mutex
+}
+
+// OpenAuditLog opens path for appending, creating parent directories
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create audit dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	return &AuditLog{path: path, file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Path returns the log file path
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Record writes one entry as a JSON line
+func (l *AuditLog) Record(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(entry)
+}
+
+// Close closes the underlying file
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// HashInput returns the hex sha256 of a tool input
+func HashInput(input []byte) string {
+	sum := sha256.Sum256(input)
+	return hex.EncodeToString(sum[:])
+}
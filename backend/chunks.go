@@ -0,0 +1,47 @@
+package backend
+
+import "sync"
+
+// ChunkDeduper drops a streamed chunk that exactly repeats the one before
+// it, as some agents resend chunks after a reconnect. A nil ChunkDeduper
+// keeps everything, since legitimate output can repeat too.
+type ChunkDeduper struct {
+	mu       sync.Mutex
+	lastType EventType
+	last     string
+	primed   bool
+}
+
+// NewChunkDeduper returns a deduper when enabled, else nil
+func NewChunkDeduper(enabled bool) *ChunkDeduper {
+	if !enabled {
+		return nil
+	}
+	return &ChunkDeduper{}
+}
+
+// Keep reports whether a chunk of eventType should be emitted, remembering
+// it as the previous chunk
+func (d *ChunkDeduper) Keep(eventType EventType, text string) bool {
+	if d == nil {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.primed && d.lastType == eventType && d.last == text {
+		return false
+	}
+	d.lastType, d.last, d.primed = eventType, text, true
+	return true
+}
+
+// Reset forgets the previous chunk, e.g. when a tool call or new block
+// separates two chunks so an identical one is no longer consecutive
+func (d *ChunkDeduper) Reset() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.primed = false
+	d.mu.Unlock()
+}
@@ -0,0 +1,177 @@
+package backend
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// DefaultDiffContextLines is the number of unchanged lines BuildHunks keeps
+// around each generated hunk
+const DefaultDiffContextLines = 3
+
+// ParseUnifiedDiff parses the hunks of a unified diff, ignoring file headers
+func ParseUnifiedDiff(diffText string) []PatchHunk {
+	if diffText == "" {
+		return nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(diffText))
+	var hunks []PatchHunk
+	var current *PatchHunk
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@@") {
+			oldStart, oldLines, newStart, newLines, ok := parseHunkHeader(line)
+			if !ok {
+				current = nil
+				continue
+			}
+			hunk := PatchHunk{
+				OldStart: oldStart,
+				OldLines: oldLines,
+				NewStart: newStart,
+				NewLines: newLines,
+			}
+			hunks = append(hunks, hunk)
+			current = &hunks[len(hunks)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(line, "\\") {
+			continue
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	return hunks
+}
+
+func parseHunkHeader(line string) (int, int, int, int, bool) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(line, "@@"))
+	trimmed = strings.TrimSuffix(trimmed, "@@")
+	trimmed = strings.TrimSpace(trimmed)
+	parts := strings.Split(trimmed, " ")
+	if len(parts) < 2 {
+		return 0, 0, 0, 0, false
+	}
+	oldStart, oldLines, ok := parseRange(strings.TrimPrefix(parts[0], "-"))
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	newStart, newLines, ok := parseRange(strings.TrimPrefix(parts[1], "+"))
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	return oldStart, oldLines, newStart, newLines, true
+}
+
+func parseRange(part string) (int, int, bool) {
+	if part == "" {
+		return 0, 0, false
+	}
+	pieces := strings.Split(part, ",")
+	start, err := strconv.Atoi(pieces[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	lines := 1
+	if len(pieces) > 1 {
+		lines, err = strconv.Atoi(pieces[1])
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, lines, true
+}
+
+// BuildHunks creates a single unified diff hunk spanning every change
+// between old and new content, padded with DefaultDiffContextLines of context
+func BuildHunks(oldContent, newContent string) []PatchHunk {
+	return BuildHunksWithContext(oldContent, newContent, DefaultDiffContextLines)
+}
+
+// BuildHunksWithContext is BuildHunks keeping contextLines unchanged lines
+// around the change; negative values mean none
+func BuildHunksWithContext(oldContent, newContent string, contextLines int) []PatchHunk {
+	oldLines := splitDiffLines(oldContent)
+	newLines := splitDiffLines(newContent)
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	// simple diff: find first difference and create single hunk
+	// for more complex diffs, consider using go-diff library
+	startOld, startNew := 0, 0
+	endOld, endNew := len(oldLines), len(newLines)
+
+	// find first differing line
+	for startOld < len(oldLines) && startNew < len(newLines) && oldLines[startOld] == newLines[startNew] {
+		startOld++
+		startNew++
+	}
+
+	// find last differing line (from end)
+	for endOld > startOld && endNew > startNew && oldLines[endOld-1] == newLines[endNew-1] {
+		endOld--
+		endNew--
+	}
+
+	// no differences
+	if startOld == endOld && startNew == endNew {
+		return nil
+	}
+
+	// build hunk lines
+	var lines []string
+
+	// context before
+	contextStart := startOld - contextLines
+	if contextStart < 0 {
+		contextStart = 0
+	}
+	for i := contextStart; i < startOld; i++ {
+		lines = append(lines, " "+oldLines[i])
+	}
+
+	// removed lines
+	for i := startOld; i < endOld; i++ {
+		lines = append(lines, "-"+oldLines[i])
+	}
+
+	// added lines
+	for i := startNew; i < endNew; i++ {
+		lines = append(lines, "+"+newLines[i])
+	}
+
+	// context after
+	contextEnd := endOld + contextLines
+	if contextEnd > len(oldLines) {
+		contextEnd = len(oldLines)
+	}
+	for i := endOld; i < contextEnd; i++ {
+		lines = append(lines, " "+oldLines[i])
+	}
+
+	hunk := PatchHunk{
+		OldStart: contextStart + 1, // 1-indexed
+		OldLines: endOld - contextStart + (contextEnd - endOld),
+		NewStart: contextStart + 1,
+		NewLines: endNew - contextStart + (contextEnd - endOld),
+		Lines:    lines,
+	}
+
+	return []PatchHunk{hunk}
+}
+
+// splitDiffLines splits content into lines, dropping the final newline
+func splitDiffLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
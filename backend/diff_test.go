@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"testing"
+)
+
+func TestParseUnifiedDiff(t *testing.T) {
+	diffText := "Index: /tmp/hello.md\n" +
+		"===================================================================\n" +
+		"--- /tmp/hello.md\n" +
+		"+++ /tmp/hello.md\n" +
+		"@@ -1,3 +1,5 @@\n" +
+		" # Hello\n" +
+		" \n" +
+		"-This is a simple hello markdown file.\n" +
+		"+This is a simple hello markdown file.\n" +
+		"+\n" +
+		"+Created by: Dan\n" +
+		"\\ No newline at end of file\n"
+	hunks := ParseUnifiedDiff(diffText)
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+	hunk := hunks[0]
+	if hunk.OldStart != 1 || hunk.OldLines != 3 || hunk.NewStart != 1 || hunk.NewLines != 5 {
+		t.Fatalf("unexpected hunk header: %+v", hunk)
+	}
+	if len(hunk.Lines) == 0 || hunk.Lines[0] != " # Hello" {
+		t.Fatalf("unexpected hunk lines: %+v", hunk.Lines)
+	}
+}
+
+func TestBuildHunks(t *testing.T) {
+	hunks := BuildHunks("a\nb", "a\nb\nc")
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+	if hunks[0].OldLines != 2 || hunks[0].NewLines != 3 {
+		t.Fatalf("unexpected hunk sizes: %+v", hunks[0])
+	}
+	expected := []string{" a", " b", "+c"}
+	if len(hunks[0].Lines) != len(expected) {
+		t.Fatalf("unexpected lines length: %d", len(hunks[0].Lines))
+	}
+	for i, line := range expected {
+		if hunks[0].Lines[i] != line {
+			t.Fatalf("line %d mismatch: %q", i, hunks[0].Lines[i])
+		}
+	}
+}
+
+func TestBuildHunks_NoChanges(t *testing.T) {
+	if hunks := BuildHunks("a\nb\n", "a\nb\n"); hunks != nil {
+		t.Fatalf("expected no hunks, got %+v", hunks)
+	}
+}
+
+func TestBuildHunks_ContextLines(t *testing.T) {
+	oldText := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	newText := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+
+	// given - default context
+	hunks := BuildHunks(oldText, newText)
+	if len(hunks) != 1 || len(hunks[0].Lines) != 8 {
+		t.Fatalf("expected 8 lines with 3 lines of context, got %+v", hunks)
+	}
+	if hunks[0].OldStart != 2 || hunks[0].OldLines != 7 {
+		t.Fatalf("unexpected default hunk header: %+v", hunks[0])
+	}
+
+	// when - context shrunk to 1
+	hunks = BuildHunksWithContext(oldText, newText, 1)
+
+	// then
+	if len(hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(hunks))
+	}
+	expected := []string{" 4", "-5", "+five", " 6"}
+	if len(hunks[0].Lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %+v", len(expected), hunks[0].Lines)
+	}
+	for i, line := range expected {
+		if hunks[0].Lines[i] != line {
+			t.Fatalf("line %d mismatch: %q", i, hunks[0].Lines[i])
+		}
+	}
+	if hunks[0].OldStart != 4 || hunks[0].OldLines != 3 || hunks[0].NewStart != 4 || hunks[0].NewLines != 3 {
+		t.Fatalf("unexpected hunk header: %+v", hunks[0])
+	}
+}
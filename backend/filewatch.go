@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileWatcher keeps a FileChangeStore's current content in step with edits
+// made outside the agent, so review diffs stay accurate. It watches the
+// directories of tracked files, since editors often save by replacing the
+// file rather than writing to it.
+type FileWatcher struct {
+	store    *FileChangeStore
+	watcher  *fsnotify.Watcher
+	onChange func([]FileChange) // called with the whole store after an external edit
+
+	mu   sync.Mutex
+	dirs map[string]bool // directories being watched
+	done chan struct{}
+}
+
+// NewFileWatcher watches every file store tracks now or records later.
+// onChange receives the store's changes after each external edit; Close
+// stops watching.
+func NewFileWatcher(store *FileChangeStore, onChange func([]FileChange)) (*FileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create file watcher: %w", err)
+	}
+	w := &FileWatcher{
+		store:    store,
+		watcher:  watcher,
+		onChange: onChange,
+		dirs:     make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+
+	store.mu.Lock()
+	store.onRecord = w.watch
+	store.mu.Unlock()
+	for _, c := range store.GetAll() {
+		w.watch(c.FilePath)
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// watch adds filePath's directory to the watch list once
+func (w *FileWatcher) watch(filePath string) {
+	dir := filepath.Dir(filePath)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.dirs[dir] {
+		return
+	}
+	// a directory that cannot be watched yet is retried on the next record
+	if err := w.watcher.Add(dir); err == nil {
+		w.dirs[dir] = true
+	}
+}
+
+// run applies write and create events on tracked files until Close
+func (w *FileWatcher) run() {
+	defer close(w.done)
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 || w.store.Get(ev.Name) == nil {
+				continue
+			}
+			// a delete or a save that is still in flight shows up on a later event
+			data, err := os.ReadFile(ev.Name)
+			if err != nil {
+				continue
+			}
+			if w.store.updateCurrent(ev.Name, string(data)) && w.onChange != nil {
+				w.onChange(w.store.GetAll())
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// Close stops watching and detaches from the store
+func (w *FileWatcher) Close() error {
+	w.store.mu.Lock()
+	w.store.onRecord = nil
+	w.store.mu.Unlock()
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcher_ExternalWriteUpdatesStore(t *testing.T) {
+	// given: a file the agent edited, and one it never touched
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	untracked := filepath.Join(dir, "other.go")
+	if err := os.WriteFile(path, []byte("a\nB\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewFileChangeStore()
+	store.RecordChange(path, "a\nb\n", "a\nB\n", BuildHunks("a\nb\n", "a\nB\n"))
+
+	updates := make(chan []FileChange, 4)
+	w, err := NewFileWatcher(store, func(changes []FileChange) { updates <- changes })
+	if err != nil {
+		t.Fatalf("NewFileWatcher: %v", err)
+	}
+	defer w.Close()
+
+	// when: the user edits both files outside the agent
+	if err := os.WriteFile(untracked, []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("a\nB\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// then: the tracked file's current content and hunks follow the disk
+	select {
+	case changes := <-updates:
+		if len(changes) != 1 || changes[0].CurrentContent != "a\nB\nc\n" {
+			t.Fatalf("expected updated change, got %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the external edit")
+	}
+	c := store.Get(path)
+	if c.OriginalContent != "a\nb\n" {
+		t.Errorf("expected original content kept, got %q", c.OriginalContent)
+	}
+	if len(c.Hunks) != 1 || c.Hunks[0].Lines[len(c.Hunks[0].Lines)-1] != "+c" {
+		t.Errorf("expected hunks for the external edit, got %+v", c.Hunks)
+	}
+	if store.Get(untracked) != nil {
+		t.Error("expected untracked file to stay untracked")
+	}
+}
+
+func TestFileWatcher_WatchesFilesRecordedLater(t *testing.T) {
+	// given: a watcher started before any change was recorded
+	dir := t.TempDir()
+	path := filepath.Join(dir, "late.txt")
+	store := NewFileChangeStore()
+	updates := make(chan []FileChange, 4)
+	w, err := NewFileWatcher(store, func(changes []FileChange) { updates <- changes })
+	if err != nil {
+		t.Fatalf("NewFileWatcher: %v", err)
+	}
+	defer w.Close()
+
+	// when: the agent writes the file, then the user changes it
+	if err := os.WriteFile(path, []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store.RecordChange(path, "", "one\n", BuildHunks("", "one\n"))
+	if err := os.WriteFile(path, []byte("two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	deadline := time.After(5 * time.Second)
+	for store.Get(path).CurrentContent != "two\n" {
+		select {
+		case <-updates:
+		case <-deadline:
+			t.Fatalf("timed out; current content %q", store.Get(path).CurrentContent)
+		}
+	}
+}
@@ -14,6 +14,15 @@ const (
 	EventPermissionRequest EventType = "permission_request"
 	EventPromptComplete    EventType = "prompt_complete"
 	EventFileChanges       EventType = "file_changes"
+	EventToolOutputChunk   EventType = "tool_output_chunk"
+	EventToolProgress      EventType = "tool_progress"
+	EventAgentTerminal     EventType = "agent_terminal"
+	EventAuthRequired      EventType = "auth_required"
+	EventAgentLog          EventType = "agent_log"
+	EventHistoryCompacted  EventType = "history_compacted"
+	EventReconnecting      EventType = "reconnecting"
+	EventCancelled         EventType = "cancelled"
+	EventUsage             EventType = "usage"
 )
 
 // Event from the backend
@@ -32,21 +41,50 @@ type SessionOpts struct {
 	AutoPermission     bool             // auto-approve all permissions
 	SuppressToolEvents bool             // don't emit tool state events
 	FileChangeStore    *FileChangeStore // optional shared store
+	ReadOnly           bool             // deny tools that modify files or run commands
+	WorkspaceRoot      string           // reject file paths outside this dir; empty allows any
+	DedupChunks        bool             // drop message/thought chunks that repeat the previous one
+	MaxTaskDepth       int              // cap on nested Task depth; 0 uses DefaultMaxTaskDepth
+	DiffContextLines   int              // unchanged lines around generated diff hunks; 0 uses the default, negative keeps none
+
+	// Agent overrides; empty uses the backend's defaults
+	AgentCommand string // ACP agent binary, run without the backend's extra args
+	Model        string
 }
 
 // Session represents an active agent session
 type Session interface {
 	SendPrompt(text string, allowedTools []string) error
 	SetMode(modeID string) error
+	SetModel(model string) error
 	Cancel()
 	Close() error
 
 	SessionID() string
 	CurrentMode() string
+	GetModel() string
 	AvailableModes() []SessionMode
 	FileChangeStore() *FileChangeStore
 }
 
+// ReasonCanceler is implemented by sessions that can say why a turn was
+// cancelled; CancelWithReason emits EventCancelled with the reason
+type ReasonCanceler interface {
+	CancelWithReason(reason string)
+}
+
+// SessionSnapshot is the state a UI needs to rebuild a session's view
+type SessionSnapshot struct {
+	Tools []*ToolState
+	Plan  []PlanEntry // last plan update, nil if none
+}
+
+// Snapshotter is implemented by sessions that can report their current
+// tool and plan state
+type Snapshotter interface {
+	Snapshot() SessionSnapshot
+}
+
 // AgentBackend creates and manages sessions
 type AgentBackend interface {
 	NewSession(ctx context.Context, opts SessionOpts) (Session, error)
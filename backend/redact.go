@@ -0,0 +1,36 @@
+package backend
+
+import "regexp"
+
+// Redactor rewrites tool output before it reaches the model
+type Redactor func(string) string
+
+// redactedText replaces each secret ScrubSecrets finds
+const redactedText = "[REDACTED]"
+
+// secretPatterns match common credential formats; a submatch, when present,
+// is the secret and the rest of the match is kept
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                                              // AWS access key id
+	regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`),          // AWS secret key
+	regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`),                                                // Anthropic
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),                                                    // OpenAI-style
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),                                             // GitHub
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),                                             // Slack
+	regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{20,}=*)`),                                  // bearer tokens
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), // PEM keys
+}
+
+// ScrubSecrets replaces API keys, tokens and private keys in s with [REDACTED]
+func ScrubSecrets(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			loc := re.FindStringSubmatchIndex(match)
+			if len(loc) < 4 || loc[2] < 0 {
+				return redactedText
+			}
+			return match[:loc[2]] + redactedText + match[loc[3]:]
+		})
+	}
+	return s
+}
@@ -0,0 +1,391 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ccui/backend"
+)
+
+// ApplyPatchTool applies a unified diff across one or more files
+type ApplyPatchTool struct{}
+
+// NewApplyPatchTool creates a new ApplyPatch tool
+func NewApplyPatchTool() *ApplyPatchTool {
+	return &ApplyPatchTool{}
+}
+
+// Name returns "ApplyPatch"
+func (a *ApplyPatchTool) Name() string {
+	return "ApplyPatch"
+}
+
+// filePatch is the section of a patch that targets a single file
+type filePatch struct {
+	oldPath string // "" for new files
+	newPath string // "" for deleted files
+	hunks   []backend.PatchHunk
+}
+
+// path returns the file the patch reads from or creates
+func (f filePatch) path() string {
+	if f.oldPath != "" {
+		return f.oldPath
+	}
+	return f.newPath
+}
+
+// target returns the file holding the result: the new path, or the old one
+// for deletions
+func (f filePatch) target() string {
+	if f.newPath != "" {
+		return f.newPath
+	}
+	return f.oldPath
+}
+
+// renamed reports whether the patch moves a file to a different path
+func (f filePatch) renamed() bool {
+	return f.oldPath != "" && f.newPath != "" && f.oldPath != f.newPath
+}
+
+// Execute applies every hunk or none; files are only written once all hunks
+// apply, and a failed write restores every file already touched
+func (a *ApplyPatchTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	// extract patch (required)
+	patch, ok := input["patch"].(string)
+	if !ok || strings.TrimSpace(patch) == "" {
+		return ToolResult{Content: "patch is required", IsError: true}, nil
+	}
+
+	files := splitPatch(normalizeLineEndings(patch))
+	if len(files) == 0 {
+		return ToolResult{Content: "patch contains no file changes", IsError: true}, nil
+	}
+
+	// every touched path must be inside the workspace before anything is read
+	for i := range files {
+		files[i].oldPath = resolvePath(ctx, files[i].oldPath)
+		files[i].newPath = resolvePath(ctx, files[i].newPath)
+	}
+	for _, f := range files {
+		for _, path := range []string{f.oldPath, f.newPath} {
+			if path == "" {
+				continue
+			}
+			if err := confinePath(ctx, path); err != nil {
+				return ToolResult{Content: err.Error(), IsError: true}, nil
+			}
+		}
+	}
+
+	// apply in memory first so a bad hunk leaves every file untouched
+	changes := make([]backend.FileChange, 0, len(files))
+	for _, f := range files {
+		if f.oldPath == "" || f.renamed() {
+			if _, err := os.Lstat(f.newPath); err == nil {
+				return ToolResult{Content: fmt.Sprintf("file already exists: %s", f.newPath), IsError: true}, nil
+			}
+		}
+
+		oldContent := ""
+		if f.oldPath != "" {
+			data, err := os.ReadFile(f.oldPath)
+			if err != nil {
+				return ToolResult{Content: fmt.Sprintf("failed to read file: %s", err), IsError: true}, nil
+			}
+			oldContent = string(data)
+		}
+
+		// hunks match on LF; the result is written back with the file's ending.
+		// A deletion's hunks must match the file and remove all of it.
+		ending := detectLineEnding(oldContent)
+		newContent, err := applyHunks(normalizeLineEndings(oldContent), f.hunks)
+		newContent = restoreLineEndings(newContent, ending)
+		if err != nil {
+			return ToolResult{Content: fmt.Sprintf("patch rejected for %s: %s", f.path(), err), IsError: true}, nil
+		}
+		if f.newPath == "" && newContent != "" {
+			return ToolResult{Content: fmt.Sprintf("patch rejected for %s: deletion does not remove the whole file", f.path()), IsError: true}, nil
+		}
+
+		changes = append(changes, backend.FileChange{
+			FilePath:        f.target(),
+			OriginalContent: oldContent,
+			CurrentContent:  newContent,
+			Hunks:           f.hunks,
+		})
+	}
+
+	// stage every write, then swap them in and remove deleted or renamed files
+	var writes []stagedWrite
+	var removes []string
+	var summary []string
+	for i, f := range files {
+		if f.newPath != "" {
+			writes = append(writes, stagedWrite{path: f.newPath, content: changes[i].CurrentContent})
+		}
+		if f.newPath == "" || f.renamed() {
+			removes = append(removes, f.oldPath)
+		}
+		switch {
+		case f.newPath == "":
+			summary = append(summary, fmt.Sprintf("deleted %s", f.oldPath))
+		case f.oldPath == "":
+			summary = append(summary, fmt.Sprintf("created %s (%d hunks)", f.newPath, len(f.hunks)))
+		case f.renamed():
+			summary = append(summary, fmt.Sprintf("renamed %s to %s (%d hunks)", f.oldPath, f.newPath, len(f.hunks)))
+		default:
+			summary = append(summary, fmt.Sprintf("patched %s (%d hunks)", f.newPath, len(f.hunks)))
+		}
+	}
+	if err := commitFiles(writes, removes); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	result := ToolResult{Content: strings.Join(summary, "\n"), Changes: changes}
+	if len(changes) == 1 {
+		result.FilePath = changes[0].FilePath
+		result.OldContent = changes[0].OriginalContent
+		result.NewContent = changes[0].CurrentContent
+		result.Hunks = changes[0].Hunks
+	}
+	return result, nil
+}
+
+// stagedWrite is a file's new content, written to a temp file beside it
+// before being renamed into place
+type stagedWrite struct {
+	path    string
+	content string
+	tmp     string
+}
+
+// fileBackup is a path's state before commitFiles touched it
+type fileBackup struct {
+	path    string
+	existed bool
+	data    []byte
+	mode    os.FileMode
+}
+
+// commitFiles stages every write, then renames them into place and removes
+// the given paths. Any failure removes the staged files and restores every
+// path to its prior state.
+func commitFiles(writes []stagedWrite, removes []string) (err error) {
+	defer func() {
+		for _, w := range writes {
+			if w.tmp != "" {
+				os.Remove(w.tmp)
+			}
+		}
+	}()
+
+	for i := range writes {
+		if err := stageWrite(&writes[i]); err != nil {
+			return err
+		}
+	}
+
+	var done []fileBackup
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(done) - 1; i >= 0; i-- {
+			restoreFile(done[i])
+		}
+	}()
+
+	for i := range writes {
+		w := &writes[i]
+		backup := backupFile(w.path)
+		if err := os.Rename(w.tmp, w.path); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		w.tmp = ""
+		done = append(done, backup)
+	}
+	for _, path := range removes {
+		backup := backupFile(path)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		done = append(done, backup)
+	}
+	return nil
+}
+
+// stageWrite writes w's content to a temp file in its directory, keeping the
+// mode of any file it replaces
+func stageWrite(w *stagedWrite) error {
+	dir := filepath.Dir(w.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(w.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(dir, ".ccui-patch-*")
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	w.tmp = tmp.Name()
+	_, err = tmp.WriteString(w.content)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// backupFile records path's current content, or that it does not exist
+func backupFile(path string) fileBackup {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileBackup{path: path}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileBackup{path: path}
+	}
+	return fileBackup{path: path, existed: true, data: data, mode: info.Mode().Perm()}
+}
+
+// restoreFile puts path back to its backed-up state
+func restoreFile(b fileBackup) {
+	if !b.existed {
+		os.Remove(b.path)
+		return
+	}
+	os.WriteFile(b.path, b.data, b.mode)
+	os.Chmod(b.path, b.mode)
+}
+
+// splitPatch divides a patch into per-file sections on ---/+++ header pairs
+func splitPatch(patch string) []filePatch {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	var files []filePatch
+	var section []string
+	var current *filePatch
+	flush := func() {
+		if current != nil {
+			current.hunks = trimHunks(backend.ParseUnifiedDiff(strings.Join(section, "\n")))
+			if len(current.hunks) > 0 {
+				files = append(files, *current)
+			}
+		}
+		section = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			flush()
+			current = &filePatch{
+				oldPath: patchPath(lines[i][4:], "a/"),
+				newPath: patchPath(lines[i+1][4:], "b/"),
+			}
+			i++
+			continue
+		}
+		section = append(section, lines[i])
+	}
+	flush()
+	return files
+}
+
+// patchPath extracts a path from a ---/+++ header, "" for /dev/null
+func patchPath(header, prefix string) string {
+	// drop a trailing tab-separated timestamp
+	if tab := strings.IndexByte(header, '\t'); tab >= 0 {
+		header = header[:tab]
+	}
+	header = strings.TrimSpace(header)
+	if header == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(header, prefix)
+}
+
+// trimHunks drops trailing lines past each hunk's declared counts, such as
+// "diff --git" lines that precede the next file's headers
+func trimHunks(hunks []backend.PatchHunk) []backend.PatchHunk {
+	for i := range hunks {
+		oldSeen, newSeen := 0, 0
+		n := 0
+		for n < len(hunks[i].Lines) && (oldSeen < hunks[i].OldLines || newSeen < hunks[i].NewLines) {
+			line := hunks[i].Lines[n]
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldSeen++
+			case strings.HasPrefix(line, "+"):
+				newSeen++
+			default: // context, including blank lines with the space stripped
+				oldSeen++
+				newSeen++
+			}
+			n++
+		}
+		hunks[i].Lines = hunks[i].Lines[:n]
+	}
+	return hunks
+}
+
+// applyHunks applies hunks in order, requiring every context and removed line to match
+func applyHunks(content string, hunks []backend.PatchHunk) (string, error) {
+	lines := splitLinesForDiff(content)
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+
+	var out []string
+	pos := 0 // next unconsumed line of the original
+	for i, h := range hunks {
+		start := h.OldStart - 1
+		if h.OldLines == 0 {
+			start = h.OldStart // pure insertion after OldStart
+		}
+		if start < pos || start > len(lines) {
+			return "", fmt.Errorf("hunk %d (@@ -%d,%d +%d,%d @@) is out of range", i+1, h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+		}
+		out = append(out, lines[pos:start]...)
+		pos = start
+
+		for _, line := range h.Lines {
+			op, text := ' ', line
+			if line != "" {
+				op, text = rune(line[0]), line[1:]
+			}
+			switch op {
+			case '+':
+				out = append(out, text)
+			case '-', ' ':
+				if pos >= len(lines) || lines[pos] != text {
+					return "", fmt.Errorf("hunk %d (@@ -%d,%d +%d,%d @@) does not match at line %d", i+1, h.OldStart, h.OldLines, h.NewStart, h.NewLines, pos+1)
+				}
+				if op == ' ' {
+					out = append(out, text)
+				}
+				pos++
+			}
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, "\n")
+	if trailingNewline {
+		result += "\n"
+	}
+	return result, nil
+}
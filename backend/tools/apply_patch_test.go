@@ -0,0 +1,332 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatchTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewApplyPatchTool()
+	a.Equal("ApplyPatch", tool.Name())
+}
+
+func TestApplyPatchTool_Execute_CleanApply(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - two files patched by one diff, plus a new file
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	utilPath := filepath.Join(dir, "util.go")
+	newPath := filepath.Join(dir, "sub", "new.txt")
+	r.NoError(os.WriteFile(mainPath, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0644))
+	r.NoError(os.WriteFile(utilPath, []byte("package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n"), 0644))
+
+	patch := "diff --git a" + mainPath + " b" + mainPath + "\n" +
+		"--- " + mainPath + "\n" +
+		"+++ " + mainPath + "\n" +
+		"@@ -3,3 +3,3 @@\n" +
+		" func main() {\n" +
+		"-\tprintln(\"hi\")\n" +
+		"+\tprintln(\"hello\")\n" +
+		" }\n" +
+		"--- " + utilPath + "\n" +
+		"+++ " + utilPath + "\n" +
+		"@@ -1,3 +1,4 @@\n" +
+		" package main\n" +
+		"+import \"fmt\"\n" +
+		"\n" +
+		" func a() {}\n" +
+		"@@ -7,1 +8,1 @@\n" +
+		"-func c() {}\n" +
+		"+func c() { fmt.Println() }\n" +
+		"--- /dev/null\n" +
+		"+++ " + newPath + "\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+first\n" +
+		"+second\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - every file updated and reported
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+
+	data, err := os.ReadFile(mainPath)
+	r.NoError(err)
+	a.Equal("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n", string(data))
+
+	data, err = os.ReadFile(utilPath)
+	r.NoError(err)
+	a.Equal("package main\nimport \"fmt\"\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() { fmt.Println() }\n", string(data))
+
+	data, err = os.ReadFile(newPath)
+	r.NoError(err)
+	a.Equal("first\nsecond\n", string(data))
+
+	r.Len(result.Changes, 3)
+	a.Equal(mainPath, result.Changes[0].FilePath)
+	a.Len(result.Changes[1].Hunks, 2)
+	a.Equal("", result.Changes[2].OriginalContent)
+	a.Contains(result.Content, "patched "+mainPath)
+	a.Contains(result.Content, "created "+newPath)
+}
+
+func TestApplyPatchTool_Execute_ContextMismatchRejected(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - first file applies cleanly, second has stale context
+	dir := t.TempDir()
+	goodPath := filepath.Join(dir, "good.txt")
+	badPath := filepath.Join(dir, "bad.txt")
+	r.NoError(os.WriteFile(goodPath, []byte("one\ntwo\n"), 0644))
+	r.NoError(os.WriteFile(badPath, []byte("alpha\nbeta\ngamma\n"), 0644))
+
+	patch := "--- " + goodPath + "\n" +
+		"+++ " + goodPath + "\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" one\n" +
+		"-two\n" +
+		"+2\n" +
+		"--- " + badPath + "\n" +
+		"+++ " + badPath + "\n" +
+		"@@ -1,1 +1,1 @@\n" +
+		"-alpha\n" +
+		"+ALPHA\n" +
+		"@@ -2,2 +2,2 @@\n" +
+		" BETA\n" +
+		"-gamma\n" +
+		"+GAMMA\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - rejected naming the failing hunk, no file changed
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, badPath)
+	a.Contains(result.Content, "hunk 2")
+	a.Contains(result.Content, "does not match at line 2")
+
+	data, err := os.ReadFile(goodPath)
+	r.NoError(err)
+	a.Equal("one\ntwo\n", string(data))
+	data, err = os.ReadFile(badPath)
+	r.NoError(err)
+	a.Equal("alpha\nbeta\ngamma\n", string(data))
+}
+
+func TestApplyPatchTool_Execute_DeleteFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gone.txt")
+	r.NoError(os.WriteFile(path, []byte("bye\n"), 0644))
+	patch := "--- " + path + "\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-bye\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	_, statErr := os.Stat(path)
+	a.True(os.IsNotExist(statErr))
+}
+
+func TestApplyPatchTool_Execute_CRLFFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a CRLF file and an LF patch
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crlf.txt")
+	r.NoError(os.WriteFile(path, []byte("one\r\ntwo\r\nthree\r\n"), 0644))
+	patch := "--- " + path + "\n+++ " + path + "\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - the hunk matches and the file keeps CRLF
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("one\r\n2\r\nthree\r\n", string(data))
+}
+
+func TestApplyPatchTool_Execute_DeleteRejectsMismatch(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - deletions whose hunks do not match or leave content behind
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keep.txt")
+	r.NoError(os.WriteFile(path, []byte("one\ntwo\n"), 0644))
+	patches := []string{
+		"--- " + path + "\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-other\n",
+		"--- " + path + "\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-one\n",
+	}
+
+	for _, patch := range patches {
+		// when
+		result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+		// then - rejected and the file is untouched
+		r.NoError(err)
+		a.True(result.IsError)
+		a.Contains(result.Content, "patch rejected")
+		data, err := os.ReadFile(path)
+		r.NoError(err)
+		a.Equal("one\ntwo\n", string(data))
+	}
+}
+
+func TestApplyPatchTool_Execute_RenameFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a patch moving old.txt to new.txt with an edit
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.txt")
+	newPath := filepath.Join(dir, "new.txt")
+	r.NoError(os.WriteFile(oldPath, []byte("one\ntwo\n"), 0644))
+	patch := "--- " + oldPath + "\n+++ " + newPath + "\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - the old path is gone and the change is recorded under the new one
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	_, statErr := os.Stat(oldPath)
+	a.True(os.IsNotExist(statErr))
+	data, err := os.ReadFile(newPath)
+	r.NoError(err)
+	a.Equal("one\n2\n", string(data))
+	a.Equal(newPath, result.FilePath)
+	a.Equal("one\ntwo\n", result.OldContent)
+	a.Contains(result.Content, "renamed "+oldPath+" to "+newPath)
+}
+
+func TestApplyPatchTool_Execute_CreateExistingRejected(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a new-file patch whose target already exists
+	dir := t.TempDir()
+	path := filepath.Join(dir, "exists.txt")
+	r.NoError(os.WriteFile(path, []byte("keep\n"), 0644))
+	patch := "--- /dev/null\n+++ " + path + "\n@@ -0,0 +1,1 @@\n+replaced\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - rejected, the file is untouched
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "file already exists")
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("keep\n", string(data))
+}
+
+func TestApplyPatchTool_Execute_FailedWriteLeavesFilesUntouched(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - the first file patches cleanly, the second is created under a
+	// regular file so its directory cannot be made
+	dir := t.TempDir()
+	goodPath := filepath.Join(dir, "good.txt")
+	blocker := filepath.Join(dir, "blocker")
+	r.NoError(os.WriteFile(goodPath, []byte("one\ntwo\n"), 0644))
+	r.NoError(os.WriteFile(blocker, []byte("file\n"), 0644))
+	patch := "--- " + goodPath + "\n+++ " + goodPath + "\n@@ -1,2 +1,2 @@\n one\n-two\n+2\n" +
+		"--- /dev/null\n+++ " + filepath.Join(blocker, "new.txt") + "\n@@ -0,0 +1,1 @@\n+new\n"
+
+	// when
+	result, err := NewApplyPatchTool().Execute(context.Background(), map[string]any{"patch": patch})
+
+	// then - nothing was written and no staged files are left behind
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "failed to create directory")
+	data, err := os.ReadFile(goodPath)
+	r.NoError(err)
+	a.Equal("one\ntwo\n", string(data))
+	entries, err := os.ReadDir(dir)
+	r.NoError(err)
+	a.Len(entries, 2)
+}
+
+func TestCommitFiles_RollsBackOnFailure(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - one write that succeeds and a removal that fails
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	created := filepath.Join(dir, "b.txt")
+	r.NoError(os.WriteFile(path, []byte("before\n"), 0600))
+	writes := []stagedWrite{{path: path, content: "after\n"}, {path: created, content: "new\n"}}
+
+	// when
+	err := commitFiles(writes, []string{filepath.Join(dir, "missing.txt")})
+
+	// then - the written file is restored with its mode and the new one removed
+	r.Error(err)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("before\n", string(data))
+	info, err := os.Stat(path)
+	r.NoError(err)
+	a.Equal(os.FileMode(0600), info.Mode().Perm())
+	_, statErr := os.Stat(created)
+	a.True(os.IsNotExist(statErr))
+	entries, err := os.ReadDir(dir)
+	r.NoError(err)
+	a.Len(entries, 1)
+}
+
+func TestApplyPatchTool_Execute_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   map[string]any
+		wantErr string
+	}{
+		{"missing patch", map[string]any{}, "patch is required"},
+		{"no file headers", map[string]any{"patch": "@@ -1 +1 @@\n-a\n+b\n"}, "no file changes"},
+		{"missing file", map[string]any{"patch": "--- /nonexistent/x\n+++ /nonexistent/x\n@@ -1 +1 @@\n-a\n+b\n"}, "failed to read file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			result, err := NewApplyPatchTool().Execute(context.Background(), tt.input)
+
+			r.NoError(err)
+			a.True(result.IsError)
+			a.Contains(result.Content, tt.wantErr)
+		})
+	}
+}
+
+func TestPatchPath(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("src/main.go", patchPath("a/src/main.go", "a/"))
+	a.Equal("src/main.go", patchPath("b/src/main.go\t2024-01-01 00:00:00", "b/"))
+	a.Equal("", patchPath("/dev/null", "a/"))
+	a.Equal("/abs/file.txt", patchPath("/abs/file.txt", "a/"))
+}
@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBackgroundOutput is how much recent output is kept per process
+const maxBackgroundOutput = 64 * 1024
+
+// BackgroundProcess describes a command started with background: true
+type BackgroundProcess struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	Dir       string    `json:"dir,omitempty"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"startedAt"`
+	Running   bool      `json:"running"`
+}
+
+type backgroundProc struct {
+	info BackgroundProcess
+	cmd  *exec.Cmd
+	out  *tailBuffer
+	done chan struct{}
+}
+
+// BackgroundManager tracks background processes so they can be listed, read
+// and killed. Each session gets its own via WithBackgroundManager.
+type BackgroundManager struct {
+	procs  map[string]*backgroundProc
+	nextID int
+	mu     sync.Mutex
+}
+
+// NewBackgroundManager creates an empty manager
+func NewBackgroundManager() *BackgroundManager {
+	return &BackgroundManager{procs: make(map[string]*backgroundProc)}
+}
+
+type backgroundManagerKey struct{}
+
+// WithBackgroundManager returns a context whose Bash and background tools use
+// m, so one session cannot see or kill another's processes
+func WithBackgroundManager(ctx context.Context, m *BackgroundManager) context.Context {
+	return context.WithValue(ctx, backgroundManagerKey{}, m)
+}
+
+// backgroundManager returns the context's manager, or fallback
+func backgroundManager(ctx context.Context, fallback *BackgroundManager) *BackgroundManager {
+	if m, ok := ctx.Value(backgroundManagerKey{}).(*BackgroundManager); ok && m != nil {
+		return m
+	}
+	return fallback
+}
+
+// Start runs command in dir (the current directory when empty), detached from
+// any request context, buffering its combined output
+func (m *BackgroundManager) Start(command, dir string) (BackgroundProcess, error) {
+	cmd := exec.Command("bash", "-c", command)
+	cmd.Dir = dir
+	out := &tailBuffer{max: maxBackgroundOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return BackgroundProcess{}, fmt.Errorf("start background process: %w", err)
+	}
+
+	m.mu.Lock()
+	m.nextID++
+	p := &backgroundProc{
+		info: BackgroundProcess{
+			ID:        fmt.Sprintf("bg-%d", m.nextID),
+			Command:   command,
+			Dir:       dir,
+			PID:       cmd.Process.Pid,
+			StartedAt: time.Now(),
+			Running:   true,
+		},
+		cmd:  cmd,
+		out:  out,
+		done: make(chan struct{}),
+	}
+	m.procs[p.info.ID] = p
+	info := p.info
+	m.mu.Unlock()
+
+	// reap the process so it never lingers as a zombie
+	go func() {
+		cmd.Wait()
+		m.mu.Lock()
+		p.info.Running = false
+		m.mu.Unlock()
+		close(p.done)
+	}()
+
+	return info, nil
+}
+
+// List returns all tracked processes ordered by start
+func (m *BackgroundManager) List() []BackgroundProcess {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]BackgroundProcess, 0, len(m.procs))
+	for _, p := range m.procs {
+		list = append(list, p.info)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+	return list
+}
+
+// Output returns the process's recent output and whether it is still running
+func (m *BackgroundManager) Output(id string) (string, bool, error) {
+	m.mu.Lock()
+	p, ok := m.procs[id]
+	running := ok && p.info.Running
+	m.mu.Unlock()
+	if !ok {
+		return "", false, fmt.Errorf("background process not found: %s", id)
+	}
+	return p.out.String(), running, nil
+}
+
+// Kill terminates a process and its children, waiting for it to exit. The
+// process stays tracked if it cannot be killed.
+func (m *BackgroundManager) Kill(id string) error {
+	m.mu.Lock()
+	p, ok := m.procs[id]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("background process not found: %s", id)
+	}
+
+	select {
+	case <-p.done:
+	default:
+		if err := killProcessGroup(p.cmd); err != nil {
+			return fmt.Errorf("kill %s: %w", id, err)
+		}
+		<-p.done
+	}
+
+	m.mu.Lock()
+	delete(m.procs, id)
+	m.mu.Unlock()
+	return nil
+}
+
+// KillAll terminates every tracked process
+func (m *BackgroundManager) KillAll() {
+	for _, p := range m.List() {
+		m.Kill(p.ID)
+	}
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	max       int
+	buf       []byte
+	truncated bool
+	mu        sync.Mutex
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the buffered output, marking when earlier output was dropped
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return "[earlier output truncated]\n" + string(b.buf)
+	}
+	return string(b.buf)
+}
+
+// ListBackgroundTool reports background processes started by Bash
+type ListBackgroundTool struct {
+	manager *BackgroundManager
+}
+
+// NewListBackgroundTool creates a ListBackground tool; manager is used when
+// the context carries none
+func NewListBackgroundTool(manager *BackgroundManager) *ListBackgroundTool {
+	return &ListBackgroundTool{manager: manager}
+}
+
+// Name returns "ListBackground"
+func (l *ListBackgroundTool) Name() string {
+	return "ListBackground"
+}
+
+// Execute lists processes as "id\tpid\tstatus\tcommand" lines
+func (l *ListBackgroundTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	manager := backgroundManager(ctx, l.manager)
+	if manager == nil {
+		return ToolResult{Content: "background processes are not enabled", IsError: true}, nil
+	}
+	procs := manager.List()
+	if len(procs) == 0 {
+		return ToolResult{Content: "no background processes"}, nil
+	}
+	lines := make([]string, len(procs))
+	for i, p := range procs {
+		status := "exited"
+		if p.Running {
+			status = "running"
+		}
+		lines[i] = fmt.Sprintf("%s\t%d\t%s\t%s", p.ID, p.PID, status, p.Command)
+	}
+	return ToolResult{Content: strings.Join(lines, "\n")}, nil
+}
+
+// KillBackgroundTool stops a background process started by Bash
+type KillBackgroundTool struct {
+	manager *BackgroundManager
+}
+
+// NewKillBackgroundTool creates a KillBackground tool; manager is used when
+// the context carries none
+func NewKillBackgroundTool(manager *BackgroundManager) *KillBackgroundTool {
+	return &KillBackgroundTool{manager: manager}
+}
+
+// Name returns "KillBackground"
+func (k *KillBackgroundTool) Name() string {
+	return "KillBackground"
+}
+
+// Execute kills the process with the given id
+func (k *KillBackgroundTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	id, ok := input["id"].(string)
+	if !ok || id == "" {
+		return ToolResult{Content: "id is required", IsError: true}, nil
+	}
+	manager := backgroundManager(ctx, k.manager)
+	if manager == nil {
+		return ToolResult{Content: "background processes are not enabled", IsError: true}, nil
+	}
+	if err := manager.Kill(id); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	return ToolResult{Content: fmt.Sprintf("killed %s", id)}, nil
+}
+
+// ReadBackgroundTool returns the buffered output of a background process
+type ReadBackgroundTool struct {
+	manager *BackgroundManager
+}
+
+// NewReadBackgroundTool creates a ReadBackground tool; manager is used when
+// the context carries none
+func NewReadBackgroundTool(manager *BackgroundManager) *ReadBackgroundTool {
+	return &ReadBackgroundTool{manager: manager}
+}
+
+// Name returns "ReadBackground"
+func (r *ReadBackgroundTool) Name() string {
+	return "ReadBackground"
+}
+
+// Execute returns the process status followed by its recent output
+func (r *ReadBackgroundTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	id, ok := input["id"].(string)
+	if !ok || id == "" {
+		return ToolResult{Content: "id is required", IsError: true}, nil
+	}
+	manager := backgroundManager(ctx, r.manager)
+	if manager == nil {
+		return ToolResult{Content: "background processes are not enabled", IsError: true}, nil
+	}
+	output, running, err := manager.Output(id)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	status := "exited"
+	if running {
+		status = "running"
+	}
+	return ToolResult{Content: fmt.Sprintf("%s %s\n%s", id, status, output)}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"time"
@@ -15,18 +16,59 @@ const (
 )
 
 // BashTool executes bash commands
-type BashTool struct{}
+type BashTool struct {
+	emitter    OutputEmitter      // optional, receives output as it arrives
+	background *BackgroundManager // optional, enables background: true
+}
+
+// BashOptions configures optional Bash tool behavior
+type BashOptions struct {
+	Emitter    OutputEmitter      // stream output as it is produced
+	Background *BackgroundManager // track background: true commands when the context carries no manager
+}
 
 // NewBashTool creates a new Bash tool
 func NewBashTool() *BashTool {
 	return &BashTool{}
 }
 
+// NewStreamingBashTool creates a Bash tool that also emits output incrementally
+func NewStreamingBashTool(emitter OutputEmitter) *BashTool {
+	return &BashTool{emitter: emitter}
+}
+
+// NewBashToolWithOptions creates a Bash tool with optional streaming and background support
+func NewBashToolWithOptions(opts BashOptions) *BashTool {
+	return &BashTool{emitter: opts.Emitter, background: opts.Background}
+}
+
 // Name returns "Bash"
 func (b *BashTool) Name() string {
 	return "Bash"
 }
 
+// bashTimeoutGrace lets Bash report its own timeout, with partial output,
+// before a registry deadline taken from Timeout passes
+const bashTimeoutGrace = 5 * time.Second
+
+// bashTimeoutMs extracts the optional timeout, defaulting to 120000ms with a
+// max of 600000ms
+func bashTimeoutMs(input map[string]any) int {
+	timeoutMs := defaultTimeoutMs
+	if v, ok := input["timeout"].(float64); ok && v > 0 {
+		timeoutMs = int(v)
+		if timeoutMs > maxTimeoutMs {
+			timeoutMs = maxTimeoutMs
+		}
+	}
+	return timeoutMs
+}
+
+// Timeout implements TimedTool: the command's timeout plus a grace period
+func (b *BashTool) Timeout(input map[string]any) time.Duration {
+	return time.Duration(bashTimeoutMs(input))*time.Millisecond + bashTimeoutGrace
+}
+
 // Execute runs a bash command with optional timeout
 func (b *BashTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
 	// extract command (required)
@@ -35,15 +77,21 @@ func (b *BashTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		return ToolResult{Content: "command is required", IsError: true}, nil
 	}
 
-	// extract timeout (optional, defaults to 120000ms, max 600000ms)
-	timeoutMs := defaultTimeoutMs
-	if v, ok := input["timeout"].(float64); ok && v > 0 {
-		timeoutMs = int(v)
-		if timeoutMs > maxTimeoutMs {
-			timeoutMs = maxTimeoutMs
+	// background commands return immediately with a handle
+	if v, ok := input["background"].(bool); ok && v {
+		manager := backgroundManager(ctx, b.background)
+		if manager == nil {
+			return ToolResult{Content: "background processes are not enabled", IsError: true}, nil
 		}
+		proc, err := manager.Start(command, WorkDir(ctx))
+		if err != nil {
+			return ToolResult{Content: err.Error(), IsError: true}, nil
+		}
+		return ToolResult{Content: fmt.Sprintf("started %s (pid %d) in background", proc.ID, proc.PID)}, nil
 	}
 
+	timeoutMs := bashTimeoutMs(input)
+
 	// create context with timeout
 	timeout := time.Duration(timeoutMs) * time.Millisecond
 	cmdCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -51,11 +99,16 @@ func (b *BashTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 
 	// run command via bash -c
 	cmd := exec.CommandContext(cmdCtx, "bash", "-c", command)
+	cmd.Dir = WorkDir(ctx)
 
 	// capture combined stdout+stderr
 	var output bytes.Buffer
-	cmd.Stdout = &output
-	cmd.Stderr = &output
+	var w io.Writer = &output
+	if b.emitter != nil {
+		w = &streamWriter{ctx: ctx, emitter: b.emitter, buf: &output}
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
 
 	// execute
 	err := cmd.Run()
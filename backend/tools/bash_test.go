@@ -3,7 +3,10 @@ package tools
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -214,3 +217,260 @@ func TestBashTool_Execute_ContextCancellation(t *testing.T) {
 	a.True(result.IsError)
 }
 
+
+// chunkRecorder collects emitted output chunks with their arrival times
+type chunkRecorder struct {
+	mu     sync.Mutex
+	chunks []string
+	times  []time.Time
+}
+
+func (c *chunkRecorder) EmitOutput(ctx context.Context, chunk string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks = append(c.chunks, chunk)
+	c.times = append(c.times, time.Now())
+}
+
+func TestBashTool_Execute_StreamsOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep command differs on Windows")
+	}
+
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a streaming tool and a command that prints with delays
+	rec := &chunkRecorder{}
+	tool := NewStreamingBashTool(rec)
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command": "echo one; sleep 0.2; echo two >&2; sleep 0.2; echo three",
+	})
+
+	// then - chunks arrive separately and the final result is still combined
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal("one\ntwo\nthree", result.Content)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	r.Len(rec.chunks, 3)
+	a.Equal([]string{"one\n", "two\n", "three\n"}, rec.chunks)
+	a.Greater(rec.times[2].Sub(rec.times[0]), 300*time.Millisecond)
+}
+
+func TestContextOutputEmitter_RoutesToSink(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a context carrying a sink
+	var got []string
+	ctx := WithOutputSink(context.Background(), func(chunk string) {
+		got = append(got, chunk)
+	})
+	tool := NewStreamingBashTool(ContextOutputEmitter{})
+
+	// when
+	result, err := tool.Execute(ctx, map[string]any{"command": "echo hi"})
+
+	// then - output delivered through the context; no sink is a no-op
+	r.NoError(err)
+	a.Equal("hi", result.Content)
+	a.Equal([]string{"hi\n"}, got)
+	ContextOutputEmitter{}.EmitOutput(context.Background(), "dropped")
+}
+
+func TestBashTool_Execute_Background(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep command differs on Windows")
+	}
+
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a Bash tool with background support
+	mgr := NewBackgroundManager()
+	defer mgr.KillAll()
+	tool := NewBashToolWithOptions(BashOptions{Background: mgr})
+
+	// when - start a long sleep in the background
+	start := time.Now()
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command":    "sleep 30",
+		"background": true,
+	})
+
+	// then - returns immediately with a handle
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	a.Less(time.Since(start), 2*time.Second)
+	a.Contains(result.Content, "bg-1")
+
+	procs := mgr.List()
+	r.Len(procs, 1)
+	a.True(procs[0].Running)
+	a.Equal("sleep 30", procs[0].Command)
+
+	listed, err := NewListBackgroundTool(mgr).Execute(context.Background(), nil)
+	r.NoError(err)
+	a.Contains(listed.Content, "bg-1")
+	a.Contains(listed.Content, "running")
+
+	// when - kill it
+	killed, err := NewKillBackgroundTool(mgr).Execute(context.Background(), map[string]any{"id": "bg-1"})
+
+	// then - process is gone
+	r.NoError(err)
+	a.False(killed.IsError, killed.Content)
+	a.Empty(mgr.List())
+	a.Error(mgr.Kill("bg-1"), "killing twice reports not found")
+}
+
+func TestBashTool_Execute_BackgroundDisabled(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - plain Bash tool
+	tool := NewBashTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"command":    "sleep 1",
+		"background": true,
+	})
+
+	// then
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "not enabled")
+}
+
+func TestBackgroundManager_KillAll(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep command differs on Windows")
+	}
+
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - processes including one with a child
+	mgr := NewBackgroundManager()
+	_, err := mgr.Start("sleep 30", "")
+	r.NoError(err)
+	_, err = mgr.Start("sleep 30 & wait", "")
+	r.NoError(err)
+
+	// when
+	mgr.KillAll()
+
+	// then
+	a.Empty(mgr.List())
+}
+
+func TestBashTool_Execute_BackgroundOutputAndWorkDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash commands differ on Windows")
+	}
+
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a session context with its own manager and working directory
+	dir := t.TempDir()
+	mgr := NewBackgroundManager()
+	defer mgr.KillAll()
+	ctx := WithWorkDir(WithBackgroundManager(context.Background(), mgr), dir)
+
+	// when - a background command prints its directory and exits
+	result, err := NewBashTool().Execute(ctx, map[string]any{
+		"command":    "pwd; echo oops >&2",
+		"background": true,
+	})
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+
+	// then - its output can be read back once it exits
+	read := NewReadBackgroundTool(nil)
+	r.Eventually(func() bool {
+		out, err := read.Execute(ctx, map[string]any{"id": "bg-1"})
+		return err == nil && strings.HasPrefix(out.Content, "bg-1 exited")
+	}, 2*time.Second, 10*time.Millisecond)
+	out, err := read.Execute(ctx, map[string]any{"id": "bg-1"})
+	r.NoError(err)
+	resolved, _ := filepath.EvalSymlinks(dir)
+	a.Contains(out.Content, resolved)
+	a.Contains(out.Content, "oops")
+}
+
+func TestBackgroundTools_ScopedToContextManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep command differs on Windows")
+	}
+
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - two sessions sharing one set of tools
+	first, second := NewBackgroundManager(), NewBackgroundManager()
+	defer first.KillAll()
+	defer second.KillAll()
+	firstCtx := WithBackgroundManager(context.Background(), first)
+	secondCtx := WithBackgroundManager(context.Background(), second)
+	bash := NewBashTool()
+	_, err := bash.Execute(firstCtx, map[string]any{"command": "sleep 30", "background": true})
+	r.NoError(err)
+
+	// when - the second session lists and tries to kill bg-1
+	listed, err := NewListBackgroundTool(nil).Execute(secondCtx, nil)
+	r.NoError(err)
+	killed, err := NewKillBackgroundTool(nil).Execute(secondCtx, map[string]any{"id": "bg-1"})
+	r.NoError(err)
+
+	// then - it sees nothing and the first session's process survives
+	a.Equal("no background processes", listed.Content)
+	a.True(killed.IsError)
+	r.Len(first.List(), 1)
+	a.True(first.List()[0].Running)
+}
+
+func TestBackgroundManager_KillExitedRemoves(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a process that has already exited
+	mgr := NewBackgroundManager()
+	proc, err := mgr.Start("true", "")
+	r.NoError(err)
+	r.Eventually(func() bool { return !mgr.List()[0].Running }, 2*time.Second, 10*time.Millisecond)
+
+	// when
+	r.NoError(mgr.Kill(proc.ID))
+
+	// then
+	a.Empty(mgr.List())
+}
+
+func TestTailBuffer_KeepsRecentOutput(t *testing.T) {
+	a := assert.New(t)
+
+	// given
+	buf := &tailBuffer{max: 8}
+
+	// when
+	buf.Write([]byte("0123456789"))
+	buf.Write([]byte("ab"))
+
+	// then - only the last 8 bytes, marked as truncated
+	a.Equal("[earlier output truncated]\n456789ab", buf.String())
+}
+
+func TestBashTool_Timeout(t *testing.T) {
+	a := assert.New(t)
+	tool := NewBashTool()
+
+	// then - the registry deadline outlasts the command's own timeout
+	a.Equal(120*time.Second+bashTimeoutGrace, tool.Timeout(map[string]any{}))
+	a.Equal(5*time.Second+bashTimeoutGrace, tool.Timeout(map[string]any{"timeout": float64(5000)}))
+	a.Equal(600*time.Second+bashTimeoutGrace, tool.Timeout(map[string]any{"timeout": float64(9999999)}))
+}
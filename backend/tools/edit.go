@@ -29,6 +29,10 @@ func (e *EditTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	if !ok || filePath == "" {
 		return ToolResult{Content: "file_path is required", IsError: true}, nil
 	}
+	filePath = resolvePath(ctx, filePath)
+	if err := confinePath(ctx, filePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
 
 	// extract old_string (required, non-empty)
 	oldString, ok := input["old_string"].(string)
@@ -58,7 +62,12 @@ func (e *EditTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	if err != nil {
 		return ToolResult{Content: fmt.Sprintf("failed to read file: %s", err), IsError: true}, nil
 	}
-	oldContent := string(data)
+	// match on LF internally; untouched lines keep their bytes on write
+	raw := string(data)
+	ending := detectLineEnding(raw)
+	oldContent := normalizeLineEndings(raw)
+	oldString = normalizeLineEndings(oldString)
+	newString = normalizeLineEndings(newString)
 
 	// count occurrences
 	count := strings.Count(oldContent, oldString)
@@ -75,99 +84,29 @@ func (e *EditTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	}
 
 	// perform replacement
-	var newContent string
+	n := 1
 	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, newString)
-	} else {
-		newContent = strings.Replace(oldContent, oldString, newString, 1)
+		n = -1
 	}
+	updated := replaceNormalized(raw, oldString, newString, n, ending)
 
 	// write file
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
+	if err := os.WriteFile(filePath, []byte(updated), 0644); err != nil {
 		return ToolResult{Content: fmt.Sprintf("failed to write file: %s", err), IsError: true}, nil
 	}
 
 	// generate diff hunks
-	hunks := generateHunks(oldContent, newContent)
+	hunks := backend.BuildHunksWithContext(oldContent, normalizeLineEndings(updated), DiffContext(ctx))
 
 	return ToolResult{
 		Content:    fmt.Sprintf("edited %s", filePath),
 		FilePath:   filePath,
-		OldContent: oldContent,
-		NewContent: newContent,
+		OldContent: raw,
+		NewContent: updated,
 		Hunks:      hunks,
 	}, nil
 }
 
-// generateHunks creates unified diff hunks from old and new content
-func generateHunks(oldContent, newContent string) []backend.PatchHunk {
-	oldLines := splitLinesForDiff(oldContent)
-	newLines := splitLinesForDiff(newContent)
-
-	// simple diff: find first difference and create single hunk
-	// for more complex diffs, consider using go-diff library
-	startOld, startNew := 0, 0
-	endOld, endNew := len(oldLines), len(newLines)
-
-	// find first differing line
-	for startOld < len(oldLines) && startNew < len(newLines) && oldLines[startOld] == newLines[startNew] {
-		startOld++
-		startNew++
-	}
-
-	// find last differing line (from end)
-	for endOld > startOld && endNew > startNew && oldLines[endOld-1] == newLines[endNew-1] {
-		endOld--
-		endNew--
-	}
-
-	// no differences
-	if startOld == endOld && startNew == endNew {
-		return nil
-	}
-
-	// build hunk lines
-	var lines []string
-
-	// context before (up to 3 lines)
-	contextStart := startOld - 3
-	if contextStart < 0 {
-		contextStart = 0
-	}
-	for i := contextStart; i < startOld; i++ {
-		lines = append(lines, " "+oldLines[i])
-	}
-
-	// removed lines
-	for i := startOld; i < endOld; i++ {
-		lines = append(lines, "-"+oldLines[i])
-	}
-
-	// added lines
-	for i := startNew; i < endNew; i++ {
-		lines = append(lines, "+"+newLines[i])
-	}
-
-	// context after (up to 3 lines)
-	contextEnd := endOld + 3
-	if contextEnd > len(oldLines) {
-		contextEnd = len(oldLines)
-	}
-	for i := endOld; i < contextEnd; i++ {
-		lines = append(lines, " "+oldLines[i])
-	}
-
-	hunk := backend.PatchHunk{
-		OldStart: contextStart + 1, // 1-indexed
-		OldLines: endOld - contextStart + (contextEnd - endOld),
-		NewStart: contextStart + 1,
-		NewLines: endNew - contextStart + (contextEnd - endOld),
-		Lines:    lines,
-	}
-
-	return []backend.PatchHunk{hunk}
-}
-
 // splitLinesForDiff splits content into lines for diff generation
 func splitLinesForDiff(content string) []string {
 	if content == "" {
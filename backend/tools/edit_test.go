@@ -319,3 +319,86 @@ func TestEditTool_Execute_DeleteContent(t *testing.T) {
 	a.False(result.IsError)
 	a.Equal("keep this keep this too\n", result.NewContent)
 }
+
+func TestEditTool_Execute_PreservesCRLF(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - CRLF file, edit strings with LF
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crlf.txt")
+	r.NoError(os.WriteFile(path, []byte("line1\r\nline2\r\nline3\r\n"), 0644))
+
+	tool := NewEditTool()
+
+	// when - replacement spans a line break
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path":  path,
+		"old_string": "line2\nline3",
+		"new_string": "second\nthird",
+	})
+
+	// then - CRLF kept on disk, hunks free of carriage returns
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("line1\r\nsecond\r\nthird\r\n", string(data))
+
+	r.Len(result.Hunks, 1)
+	a.Equal([]string{" line1", "-line2", "-line3", "+second", "+third"}, result.Hunks[0].Lines)
+	a.Equal("line1\r\nline2\r\nline3\r\n", result.OldContent)
+	a.Equal("line1\r\nsecond\r\nthird\r\n", result.NewContent)
+}
+
+func TestEditTool_Execute_MixedLineEndings(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a file mixing CRLF and LF lines
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mixed.txt")
+	original := "a\r\nb\nc\r\nd\r\ne\n"
+	r.NoError(os.WriteFile(path, []byte(original), 0644))
+
+	// when - one edit within a line, one spanning a CRLF break
+	tool := NewEditTool()
+	_, err := tool.Execute(context.Background(), map[string]any{"file_path": path, "old_string": "b", "new_string": "B"})
+	r.NoError(err)
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": path, "old_string": "c\nd", "new_string": "x\ny\nz"})
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+
+	// then - untouched lines keep their bytes, the replaced span keeps its ending
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("a\r\nB\nx\r\ny\r\nz\r\ne\n", string(data))
+	a.Equal("a\r\nB\nc\r\nd\r\ne\n", result.OldContent)
+	a.Equal(string(data), result.NewContent)
+	r.Len(result.Hunks, 1)
+	a.Equal([]string{" a", " B", "-c", "-d", "+x", "+y", "+z", " e"}, result.Hunks[0].Lines)
+}
+
+func TestEditTool_Execute_DiffContext(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a change in the middle of a file and a session asking for 1 line of context
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ctx.txt")
+	r.NoError(os.WriteFile(path, []byte("a\nb\nc\nd\ne\nf\ng\n"), 0644))
+	ctx := WithDiffContext(context.Background(), 1)
+
+	// when
+	result, err := NewEditTool().Execute(ctx, map[string]any{
+		"file_path":  path,
+		"old_string": "d",
+		"new_string": "D",
+	})
+
+	// then - only the neighbouring lines surround the change
+	r.NoError(err)
+	r.Len(result.Hunks, 1)
+	a.Equal([]string{" c", "-d", "+D", " e"}, result.Hunks[0].Lines)
+}
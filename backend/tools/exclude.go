@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// DefaultExcludeDirs are pruned from Glob and Grep walks unless the call
+// passes its own exclude list; an empty list searches everything
+var DefaultExcludeDirs = []string{".git", "node_modules", "vendor"}
+
+// walkOptions controls a Glob or Grep directory walk
+type walkOptions struct {
+	exclude        []string // directory patterns pruned below the root
+	followSymlinks bool     // descend into symlinked directories, once each
+}
+
+// walkInput reads exclude and follow_symlinks from a tool input
+func walkInput(input map[string]any) walkOptions {
+	follow, _ := input["follow_symlinks"].(bool)
+	return walkOptions{exclude: excludeInput(input), followSymlinks: follow}
+}
+
+// excludeInput returns the exclude patterns from input, or the defaults when
+// the key is absent
+func excludeInput(input map[string]any) []string {
+	raw, ok := input["exclude"].([]any)
+	if !ok {
+		if _, set := input["exclude"]; set {
+			return nil // explicit null also disables exclusion
+		}
+		return DefaultExcludeDirs
+	}
+	patterns := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && s != "" {
+			patterns = append(patterns, s)
+		}
+	}
+	return patterns
+}
+
+// excludedDir reports whether dir, relative to the walk root, matches a
+// pattern by its base name or its whole relative path
+func excludedDir(rel string, patterns []string) bool {
+	rel = filepath.ToSlash(rel)
+	base := rel[strings.LastIndex(rel, "/")+1:]
+	for _, p := range patterns {
+		if ok, _ := doublestar.Match(p, base); ok {
+			return true
+		}
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inExcludedDir reports whether any directory between root and path is excluded
+func inExcludedDir(root, path string, patterns []string) bool {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		if excludedDir(strings.Join(parts[:i+1], "/"), patterns) {
+			return true
+		}
+	}
+	return false
+}
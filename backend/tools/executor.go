@@ -3,7 +3,9 @@ package tools
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"ccui/backend"
 )
@@ -13,12 +15,31 @@ var ErrToolNotFound = errors.New("tool not found")
 
 // ToolResult returned by tool execution
 type ToolResult struct {
-	Content    string             // output text
-	IsError    bool               // true if tool reports an error
-	FilePath   string             // for file-modifying tools
-	OldContent string             // original content before edit
-	NewContent string             // content after edit
-	Hunks      []backend.PatchHunk // diff hunks for file changes
+	Content     string               // output text
+	ContentType string               // MIME type of Content, empty means text/plain
+	IsError     bool                 // true if tool reports an error
+	FilePath    string               // for file-modifying tools
+	OldContent  string               // original content before edit
+	NewContent  string               // content after edit
+	Hunks       []backend.PatchHunk  // diff hunks for file changes
+	Changes     []backend.FileChange // per-file results for multi-file tools
+	Blocks      []ResultBlock        // multi-part output sent to the model; Content stays the UI text
+}
+
+// ResultBlock is one part of a multi-part tool result
+type ResultBlock struct {
+	Type     string // "text" or "image"
+	Text     string // text blocks
+	MimeType string // image blocks, e.g. image/png
+	Data     string // image blocks, base64 encoded
+}
+
+// MimeType returns the result's content type, defaulting to text/plain
+func (r ToolResult) MimeType() string {
+	if r.ContentType == "" {
+		return backend.MimeTextPlain
+	}
+	return r.ContentType
 }
 
 // Tool interface for individual tool implementations
@@ -32,10 +53,21 @@ type ToolExecutor interface {
 	Execute(ctx context.Context, name string, input map[string]any) (ToolResult, error)
 }
 
+// TimedTool is implemented by tools that choose their own deadline from
+// their input, like Bash's timeout; the registry's max still applies
+type TimedTool interface {
+	Timeout(input map[string]any) time.Duration
+}
+
 // Registry stores tools and dispatches execution
 type Registry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools        map[string]Tool
+	timeout      time.Duration            // default per-execution deadline, zero disables
+	maxTimeout   time.Duration            // ceiling on every deadline, zero disables
+	toolTimeouts map[string]time.Duration // per-tool deadlines replacing the default
+	stats        statsRecorder
+	chain        []Middleware // outermost first
+	mu           sync.RWMutex
 }
 
 // NewRegistry creates an empty tool registry
@@ -43,6 +75,55 @@ func NewRegistry() *Registry {
 	return &Registry{tools: make(map[string]Tool)}
 }
 
+// NewRegistryWithTimeout creates an empty registry that bounds each execution
+func NewRegistryWithTimeout(timeout time.Duration) *Registry {
+	return &Registry{tools: make(map[string]Tool), timeout: timeout}
+}
+
+// SetTimeouts sets the deadline for tools without their own and the
+// ceiling no execution may exceed; zero disables either
+func (r *Registry) SetTimeouts(defaultTimeout, maxTimeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.timeout, r.maxTimeout = defaultTimeout, maxTimeout
+}
+
+// SetToolTimeout gives one tool its own deadline, still capped by the
+// ceiling; zero removes the override
+func (r *Registry) SetToolTimeout(name string, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if timeout <= 0 {
+		delete(r.toolTimeouts, name)
+		return
+	}
+	if r.toolTimeouts == nil {
+		r.toolTimeouts = make(map[string]time.Duration)
+	}
+	r.toolTimeouts[name] = timeout
+}
+
+// timeoutFor returns the deadline for one execution: the tool's override,
+// else what a TimedTool asks for, else the default, capped by the ceiling
+func (r *Registry) timeoutFor(tool Tool, input map[string]any) time.Duration {
+	r.mu.RLock()
+	timeout, ok := r.toolTimeouts[tool.Name()]
+	defaultTimeout, maxTimeout := r.timeout, r.maxTimeout
+	r.mu.RUnlock()
+	if !ok {
+		timeout = defaultTimeout
+		if timed, isTimed := tool.(TimedTool); isTimed {
+			if d := timed.Timeout(input); d > 0 {
+				timeout = d
+			}
+		}
+	}
+	if maxTimeout > 0 && (timeout <= 0 || timeout > maxTimeout) {
+		timeout = maxTimeout
+	}
+	return timeout
+}
+
 // Register adds a tool to the registry
 func (r *Registry) Register(tool Tool) {
 	r.mu.Lock()
@@ -58,8 +139,28 @@ func (r *Registry) Has(name string) bool {
 	return ok
 }
 
-// Execute runs the named tool with the given input
+// Use appends a middleware; the first added runs outermost
+func (r *Registry) Use(mw Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chain = append(r.chain, mw)
+}
+
+// Execute runs the named tool with the given input through the middleware chain
 func (r *Registry) Execute(ctx context.Context, name string, input map[string]any) (ToolResult, error) {
+	r.mu.RLock()
+	chain := r.chain
+	r.mu.RUnlock()
+
+	var next ToolExecutor = ExecutorFunc(r.execute)
+	for i := len(chain) - 1; i >= 0; i-- {
+		next = chain[i](next)
+	}
+	return next.Execute(ctx, name, input)
+}
+
+// execute runs the named tool directly, recording its stats
+func (r *Registry) execute(ctx context.Context, name string, input map[string]any) (ToolResult, error) {
 	r.mu.RLock()
 	tool, ok := r.tools[name]
 	r.mu.RUnlock()
@@ -67,7 +168,58 @@ func (r *Registry) Execute(ctx context.Context, name string, input map[string]an
 	if !ok {
 		return ToolResult{}, ErrToolNotFound
 	}
-	return tool.Execute(ctx, input)
+
+	start := time.Now()
+	var result ToolResult
+	var err error
+	if timeout := r.timeoutFor(tool, input); timeout <= 0 {
+		result, err = tool.Execute(ctx, input)
+	} else {
+		result, err = executeWithTimeout(ctx, tool, input, timeout)
+	}
+	r.stats.record(name, time.Since(start), err != nil || result.IsError)
+	return result, err
+}
+
+// Stats returns execution metrics per tool name for tools that have run
+func (r *Registry) Stats() map[string]ToolStat {
+	return r.stats.snapshot()
+}
+
+// executeWithTimeout runs tool under a deadline, returning a timeout result if
+// it is exceeded even when the tool does not observe cancellation
+func executeWithTimeout(ctx context.Context, tool Tool, input map[string]any, timeout time.Duration) (ToolResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := tool.Execute(ctx, input)
+		done <- outcome{result, err}
+	}()
+
+	timeoutResult := ToolResult{
+		Content: fmt.Sprintf("%s timed out after %s", tool.Name(), timeout),
+		IsError: true,
+	}
+	select {
+	case o := <-done:
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return timeoutResult, nil
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return timeoutResult, nil
+		}
+		// parent cancelled: let the tool report its own cancellation
+		o := <-done
+		return o.result, o.err
+	}
 }
 
 // Tools returns all registered tools
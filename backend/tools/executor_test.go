@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"ccui/backend"
 
@@ -171,3 +172,238 @@ func TestToolResult_WithFileDiff(t *testing.T) {
 	a.Len(result.Hunks, 1)
 	a.Equal(1, result.Hunks[0].OldStart)
 }
+
+// slowTool blocks until its delay elapses, optionally ignoring cancellation
+type slowTool struct {
+	delay       time.Duration
+	ignoreCtx   bool
+	sawCanceled chan struct{}
+}
+
+func (s *slowTool) Name() string { return "Slow" }
+
+func (s *slowTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	if s.ignoreCtx {
+		time.Sleep(s.delay)
+		return ToolResult{Content: "finished"}, nil
+	}
+	select {
+	case <-time.After(s.delay):
+		return ToolResult{Content: "finished"}, nil
+	case <-ctx.Done():
+		close(s.sawCanceled)
+		return ToolResult{Content: "cancelled", IsError: true}, nil
+	}
+}
+
+func TestRegistry_Execute_Timeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		ignoreCtx bool
+	}{
+		{"cooperative tool", false},
+		{"uncooperative tool", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			// given - registry with a short timeout and a slow tool
+			reg := NewRegistryWithTimeout(20 * time.Millisecond)
+			tool := &slowTool{delay: time.Second, ignoreCtx: tt.ignoreCtx, sawCanceled: make(chan struct{})}
+			reg.Register(tool)
+
+			// when
+			start := time.Now()
+			result, err := reg.Execute(context.Background(), "Slow", nil)
+
+			// then - timeout result returned promptly
+			r.NoError(err)
+			a.True(result.IsError)
+			a.Contains(result.Content, "timed out")
+			a.Less(time.Since(start), 500*time.Millisecond)
+			if !tt.ignoreCtx {
+				select {
+				case <-tool.sawCanceled:
+				case <-time.After(time.Second):
+					t.Fatal("expected tool to observe cancellation")
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_Execute_WithinTimeout(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - timeout longer than the tool runs
+	reg := NewRegistryWithTimeout(time.Second)
+	reg.Register(&slowTool{delay: time.Millisecond, sawCanceled: make(chan struct{})})
+
+	// when
+	result, err := reg.Execute(context.Background(), "Slow", nil)
+
+	// then - normal result
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal("finished", result.Content)
+}
+
+// timedSlowTool is a slowTool that asks for its own deadline
+type timedSlowTool struct {
+	slowTool
+	timeout time.Duration
+}
+
+func (s *timedSlowTool) Timeout(input map[string]any) time.Duration { return s.timeout }
+
+func TestRegistry_Execute_MaxTimeoutCapsLongerDeadlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(reg *Registry)
+	}{
+		{"default timeout", func(reg *Registry) {
+			reg.Register(&slowTool{delay: time.Second, ignoreCtx: true})
+		}},
+		{"per-tool override", func(reg *Registry) {
+			reg.Register(&slowTool{delay: time.Second, ignoreCtx: true})
+			reg.SetToolTimeout("Slow", time.Minute)
+		}},
+		{"tool's own timeout", func(reg *Registry) {
+			reg.Register(&timedSlowTool{slowTool: slowTool{delay: time.Second, ignoreCtx: true}, timeout: time.Minute})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			// given - a long default, a short ceiling and a tool that ignores cancellation
+			reg := NewRegistry()
+			reg.SetTimeouts(time.Minute, 20*time.Millisecond)
+			tt.register(reg)
+
+			// when
+			start := time.Now()
+			result, err := reg.Execute(context.Background(), "Slow", nil)
+
+			// then - the ceiling cuts it off
+			r.NoError(err)
+			a.True(result.IsError)
+			a.Contains(result.Content, "timed out")
+			a.Less(time.Since(start), 500*time.Millisecond)
+		})
+	}
+}
+
+func TestRegistry_Execute_ToolTimeoutOverridesDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(reg *Registry)
+	}{
+		{"per-tool override", func(reg *Registry) {
+			reg.Register(&slowTool{delay: 50 * time.Millisecond, sawCanceled: make(chan struct{})})
+			reg.SetToolTimeout("Slow", time.Second)
+		}},
+		{"tool's own timeout", func(reg *Registry) {
+			reg.Register(&timedSlowTool{slowTool: slowTool{delay: 50 * time.Millisecond, sawCanceled: make(chan struct{})}, timeout: time.Second})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			// given - a default shorter than the tool runs, extended for this tool
+			reg := NewRegistry()
+			reg.SetTimeouts(10*time.Millisecond, time.Minute)
+			tt.register(reg)
+
+			// when
+			result, err := reg.Execute(context.Background(), "Slow", nil)
+
+			// then - it finishes normally
+			r.NoError(err)
+			a.False(result.IsError)
+			a.Equal("finished", result.Content)
+		})
+	}
+}
+
+func TestRegistry_SetToolTimeout_ZeroRestoresDefault(t *testing.T) {
+	a := assert.New(t)
+
+	// given - an override that has been cleared
+	reg := NewRegistry()
+	reg.SetTimeouts(20*time.Millisecond, time.Minute)
+	reg.Register(&slowTool{delay: time.Second, sawCanceled: make(chan struct{})})
+	reg.SetToolTimeout("Slow", time.Minute)
+	reg.SetToolTimeout("Slow", 0)
+
+	// when
+	result, _ := reg.Execute(context.Background(), "Slow", nil)
+
+	// then - the default applies again
+	a.True(result.IsError)
+	a.Contains(result.Content, "timed out")
+}
+
+func TestRegistry_Stats(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - tools that succeed, report an error result, and fail
+	reg := NewRegistry()
+	reg.Register(&mockTool{name: "Read", result: ToolResult{Content: "ok"}})
+	reg.Register(&mockTool{name: "Edit", result: ToolResult{Content: "no match", IsError: true}})
+	reg.Register(&mockTool{name: "Bash", err: errors.New("boom")})
+
+	// when - run them several times, plus an unknown tool
+	for i := 0; i < 3; i++ {
+		reg.Execute(context.Background(), "Read", nil)
+	}
+	reg.Execute(context.Background(), "Edit", nil)
+	reg.Execute(context.Background(), "Edit", nil)
+	reg.Execute(context.Background(), "Bash", nil)
+	reg.Execute(context.Background(), "Missing", nil)
+
+	// then - calls and errors counted per tool; unknown tools not tracked
+	stats := reg.Stats()
+	r.Len(stats, 3)
+	a.Equal(3, stats["Read"].Calls)
+	a.Equal(0, stats["Read"].Errors)
+	a.Equal(2, stats["Edit"].Calls)
+	a.Equal(2, stats["Edit"].Errors)
+	a.Equal(1, stats["Bash"].Calls)
+	a.Equal(1, stats["Bash"].Errors)
+	a.LessOrEqual(stats["Read"].P50Ms, stats["Read"].P95Ms)
+	a.LessOrEqual(stats["Read"].P95Ms, stats["Read"].MaxMs)
+}
+
+func TestRegistry_Stats_TimeoutCountsAsError(t *testing.T) {
+	a := assert.New(t)
+
+	// given - registry with a short timeout and a slow tool
+	reg := NewRegistryWithTimeout(20 * time.Millisecond)
+	reg.Register(&slowTool{delay: time.Second, sawCanceled: make(chan struct{})})
+
+	// when
+	reg.Execute(context.Background(), "Slow", nil)
+
+	// then - the timeout is an error and its duration recorded
+	stat := reg.Stats()["Slow"]
+	a.Equal(1, stat.Errors)
+	a.GreaterOrEqual(stat.MaxMs, 20.0)
+}
+
+func TestPercentile(t *testing.T) {
+	a := assert.New(t)
+
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	a.Equal(time.Duration(5), percentile(sorted, 50))
+	a.Equal(time.Duration(10), percentile(sorted, 95))
+	a.Equal(time.Duration(0), percentile(nil, 50))
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package tools
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID identifies a file independent of the path it was reached by
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIDOf returns the device and inode of info, false if unavailable
+func fileIDOf(path string, info fs.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package tools
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileID identifies a file by its symlink-free path; Windows has no inodes
+type fileID struct {
+	path string
+}
+
+// fileIDOf resolves path's links, false if that fails
+func fileIDOf(path string, info fs.FileInfo) (fileID, bool) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	return fileID{path: real}, true
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ccui/backend"
+)
+
+// maxGitDiffOutput caps the diff text returned to the model; every file
+// still reaches the review panel through Changes
+const maxGitDiffOutput = 100 * 1024
+
+// GitDiffTool reports working tree changes as a diff and as file changes,
+// so the review panel shows edits ccui didn't make
+type GitDiffTool struct{}
+
+// NewGitDiffTool creates a new GitDiff tool
+func NewGitDiffTool() *GitDiffTool {
+	return &GitDiffTool{}
+}
+
+// Name returns "GitDiff"
+func (g *GitDiffTool) Name() string {
+	return "GitDiff"
+}
+
+// gitFileDiff is one file's section of a git diff
+type gitFileDiff struct {
+	path string // relative to the repository root
+	text string
+}
+
+// Execute diffs the working tree against the index, or with staged the
+// index against HEAD, in path or the session's directory
+func (g *GitDiffTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	dir, err := gitDir(ctx, input)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	staged, _ := input["staged"].(bool)
+
+	root, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	root = strings.TrimSpace(root)
+
+	// fixed prefixes and no renames keep the headers parseable whatever the
+	// repository's diff config says; external diff and textconv drivers are
+	// commands the repository could name, so neither runs
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-textconv", "--no-renames", "--src-prefix=a/", "--dst-prefix=b/"}
+	if staged {
+		args = append(args, "--staged")
+	}
+	diff, err := runGit(ctx, root, args...)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	if diff == "" {
+		return ToolResult{Content: "no changes"}, nil
+	}
+
+	var changes []backend.FileChange
+	for _, f := range splitGitDiff(diff) {
+		hunks := backend.ParseUnifiedDiff(f.text)
+		if len(hunks) == 0 {
+			continue // binary or mode-only change
+		}
+		original, current := g.contents(ctx, root, f.path, staged)
+		changes = append(changes, backend.FileChange{
+			FilePath:        filepath.Join(root, filepath.FromSlash(f.path)),
+			OriginalContent: original,
+			CurrentContent:  current,
+			Hunks:           hunks,
+		})
+	}
+
+	content := diff
+	if len(content) > maxGitDiffOutput {
+		content = content[:maxGitDiffOutput] + fmt.Sprintf("\n[diff truncated: showing %d of %d bytes]", maxGitDiffOutput, len(diff))
+	}
+	return ToolResult{Content: content, Changes: changes}, nil
+}
+
+// contents returns a file's old and new text on either side of the diff;
+// a side where the file doesn't exist is empty
+func (g *GitDiffTool) contents(ctx context.Context, root, path string, staged bool) (string, string) {
+	show := func(rev string) string {
+		out, _ := runGit(ctx, root, "show", rev+":"+path)
+		return out
+	}
+	if staged {
+		return show("HEAD"), show("")
+	}
+	current, _ := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	return show(""), string(current)
+}
+
+// splitGitDiff splits git diff output into per-file sections, naming each by
+// its new path, or its old one when deleted
+func splitGitDiff(diff string) []gitFileDiff {
+	var files []gitFileDiff
+	var current *gitFileDiff
+	var text strings.Builder
+	inHunks := false // past the headers, where "--- a/" may be a removed line
+	flush := func() {
+		if current != nil {
+			current.text = text.String()
+			files = append(files, *current)
+		}
+		text.Reset()
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current, inHunks = &gitFileDiff{}, false
+		}
+		if current == nil {
+			continue
+		}
+		text.WriteString(line)
+		switch {
+		case inHunks:
+		case strings.HasPrefix(line, "@@"):
+			inHunks = true
+		case strings.HasPrefix(line, "--- a/") && current.path == "":
+			current.path = diffHeaderPath(line, "--- a/")
+		case strings.HasPrefix(line, "+++ b/"):
+			current.path = diffHeaderPath(line, "+++ b/")
+		}
+	}
+	flush()
+	return files
+}
+
+// diffHeaderPath extracts the path from a ---/+++ line; git appends a tab
+// to names containing spaces
+func diffHeaderPath(line, prefix string) string {
+	return strings.TrimRight(strings.TrimPrefix(line, prefix), "\t\r\n")
+}
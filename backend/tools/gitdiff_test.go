@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitDiffTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewGitDiffTool()
+	a.Equal("GitDiff", tool.Name())
+}
+
+func TestGitDiffTool_Execute_Modified(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a committed file edited in the working tree
+	dir := gitRepo(t)
+	path := filepath.Join(dir, "tracked.txt")
+	r.NoError(os.WriteFile(path, []byte("v2\n"), 0644))
+
+	tool := NewGitDiffTool()
+
+	// when
+	result, err := tool.Execute(WithWorkDir(context.Background(), dir), map[string]any{})
+
+	// then - the diff text and a file change with its hunk
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	a.Contains(result.Content, "+v2")
+	r.Len(result.Changes, 1)
+	change := result.Changes[0]
+	root, _ := filepath.EvalSymlinks(dir)
+	gotPath, _ := filepath.EvalSymlinks(change.FilePath)
+	a.Equal(filepath.Join(root, "tracked.txt"), gotPath)
+	a.Equal("v1\n", change.OriginalContent)
+	a.Equal("v2\n", change.CurrentContent)
+	r.Len(change.Hunks, 1)
+	a.Equal([]string{"-v1", "+v2"}, change.Hunks[0].Lines)
+}
+
+func TestGitDiffTool_Execute_IgnoresTextconv(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a repository whose config names a textconv command for .txt files
+	dir := gitRepo(t)
+	marker := filepath.Join(dir, "textconv-ran")
+	r.NoError(os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte("*.txt diff=conv\n"), 0644))
+	out, err := exec.Command("git", "-C", dir, "config", "diff.conv.textconv", "touch "+marker+" && cat").CombinedOutput()
+	r.NoError(err, string(out))
+	r.NoError(os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v2\n"), 0644))
+
+	// when
+	result, err := NewGitDiffTool().Execute(WithWorkDir(context.Background(), dir), map[string]any{})
+
+	// then - the raw diff is shown and the command never ran
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	a.Contains(result.Content, "+v2")
+	a.NoFileExists(marker)
+}
+
+func TestGitDiffTool_Execute_Staged(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a new file staged, and an unstaged edit to another
+	dir := gitRepo(t)
+	r.NoError(os.WriteFile(filepath.Join(dir, "added.txt"), []byte("hello\n"), 0644))
+	r.NoError(exec.Command("git", "-C", dir, "add", "added.txt").Run())
+	r.NoError(os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v2\n"), 0644))
+
+	tool := NewGitDiffTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"path": dir, "staged": true})
+
+	// then - only the staged file, new so with no original
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	r.Len(result.Changes, 1)
+	a.Equal("added.txt", filepath.Base(result.Changes[0].FilePath))
+	a.Equal("", result.Changes[0].OriginalContent)
+	a.Equal("hello\n", result.Changes[0].CurrentContent)
+}
+
+func TestGitDiffTool_Execute_Clean(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - nothing changed
+	dir := gitRepo(t)
+	tool := NewGitDiffTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"path": dir})
+
+	// then
+	r.NoError(err)
+	a.Equal("no changes", result.Content)
+	a.Empty(result.Changes)
+}
+
+func TestSplitGitDiff_RemovedLineLikeHeader(t *testing.T) {
+	a := assert.New(t)
+
+	// given - a removed line whose text starts with "-- a/"
+	diff := "diff --git a/x.md b/x.md\n--- a/x.md\n+++ b/x.md\n@@ -1 +1 @@\n--- a/other.md\n+kept\n"
+
+	// when
+	files := splitGitDiff(diff)
+
+	// then - the file keeps its own name
+	a.Len(files, 1)
+	a.Equal("x.md", files[0].path)
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds a git run on a huge or slow repository
+const gitTimeout = 30 * time.Second
+
+// GitStatusTool reports a repository's working tree state by running
+// git status itself, so the agent needs no Bash access for it
+type GitStatusTool struct{}
+
+// NewGitStatusTool creates a new GitStatus tool
+func NewGitStatusTool() *GitStatusTool {
+	return &GitStatusTool{}
+}
+
+// Name returns "GitStatus"
+func (g *GitStatusTool) Name() string {
+	return "GitStatus"
+}
+
+// GitStatus is the parsed output of git status --porcelain
+type GitStatus struct {
+	Branch     string          `json:"branch,omitempty"` // branch header, e.g. "main...origin/main [ahead 1]"
+	Staged     []GitFileStatus `json:"staged"`
+	Unstaged   []GitFileStatus `json:"unstaged"`
+	Untracked  []string        `json:"untracked"`
+	Conflicted []string        `json:"conflicted,omitempty"`
+}
+
+// GitFileStatus is one changed path
+type GitFileStatus struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"origPath,omitempty"` // source of a rename or copy entry
+	Status   string `json:"status"`             // modified, added, deleted, renamed, copied, typechange
+}
+
+// gitStatusNames maps porcelain status letters to names
+var gitStatusNames = map[byte]string{
+	'M': "modified",
+	'A': "added",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'T': "typechange",
+}
+
+// Execute runs git status in path, defaulting to the session's directory
+func (g *GitStatusTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	dir, err := gitDir(ctx, input)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	out, err := runGit(ctx, dir, "status", "--porcelain=v1", "-z", "--branch", "--untracked-files=all")
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	data, err := json.MarshalIndent(ParseGitStatus(out), "", "  ")
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	return ToolResult{Content: string(data)}, nil
+}
+
+// gitDir returns the input's path, else the session's directory, checked
+// against the workspace root
+func gitDir(ctx context.Context, input map[string]any) (string, error) {
+	dir := WorkDir(ctx)
+	if v, ok := input["path"].(string); ok && v != "" {
+		dir = resolvePath(ctx, v)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	if err := confinePath(ctx, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// runGit runs a read-only git subcommand in dir and returns its stdout,
+// with paths unquoted. fsmonitor is disabled because a repository's config could name any
+// command for it; optional locks are skipped so a read never blocks a
+// concurrent git command.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmdCtx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, "git", append([]string{"-c", "core.fsmonitor=false", "-c", "core.quotePath=false", "-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s cancelled", args[0])
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// ParseGitStatus parses NUL-separated git status --porcelain=v1 output, with
+// or without the --branch header
+func ParseGitStatus(out string) GitStatus {
+	status := GitStatus{Staged: []GitFileStatus{}, Unstaged: []GitFileStatus{}, Untracked: []string{}}
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if strings.HasPrefix(entry, "## ") {
+			status.Branch = strings.TrimPrefix(entry, "## ")
+			continue
+		}
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		switch {
+		case x == '?' && y == '?':
+			status.Untracked = append(status.Untracked, path)
+			continue
+		case x == '!' && y == '!':
+			continue
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			status.Conflicted = append(status.Conflicted, path)
+			continue
+		}
+
+		// a rename or copy is followed by its source path
+		var orig string
+		if (x == 'R' || x == 'C' || y == 'R' || y == 'C') && i+1 < len(fields) {
+			i++
+			orig = fields[i]
+		}
+		if name, ok := gitStatusNames[x]; ok {
+			status.Staged = append(status.Staged, GitFileStatus{Path: path, OrigPath: orig, Status: name})
+		}
+		if name, ok := gitStatusNames[y]; ok {
+			status.Unstaged = append(status.Unstaged, GitFileStatus{Path: path, OrigPath: orig, Status: name})
+		}
+	}
+	return status
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo initializes a repository with one committed file
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v: %s", args, out)
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v1\n"), 0644))
+	run("add", "tracked.txt")
+	run("commit", "-q", "-m", "init")
+	return dir
+}
+
+func TestGitStatusTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewGitStatusTool()
+	a.Equal("GitStatus", tool.Name())
+}
+
+func TestGitStatusTool_Execute_Untracked(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a repo with a new file and an unstaged edit
+	dir := gitRepo(t)
+	r.NoError(os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v2\n"), 0644))
+
+	tool := NewGitStatusTool()
+
+	// when - run in the session's directory
+	result, err := tool.Execute(WithWorkDir(context.Background(), dir), map[string]any{})
+
+	// then - structured lists
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	var status GitStatus
+	r.NoError(json.Unmarshal([]byte(result.Content), &status))
+	a.Equal([]string{"new.txt"}, status.Untracked)
+	a.Equal([]GitFileStatus{{Path: "tracked.txt", Status: "modified"}}, status.Unstaged)
+	a.Empty(status.Staged)
+	a.Contains(status.Branch, "main")
+}
+
+func TestGitStatusTool_Execute_NotARepo(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tool := NewGitStatusTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"path": t.TempDir()})
+
+	// then
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "git status failed")
+}
+
+func TestParseGitStatus(t *testing.T) {
+	a := assert.New(t)
+
+	// given - porcelain -z output with a staged rename, a staged and
+	// unstaged edit, a conflict and an untracked file
+	out := "## main...origin/main [ahead 1]\x00R  new.go\x00old.go\x00MM both.go\x00UU conflict.go\x00?? scratch.txt\x00"
+
+	// when
+	status := ParseGitStatus(out)
+
+	// then
+	a.Equal("main...origin/main [ahead 1]", status.Branch)
+	a.Equal([]GitFileStatus{
+		{Path: "new.go", OrigPath: "old.go", Status: "renamed"},
+		{Path: "both.go", Status: "modified"},
+	}, status.Staged)
+	a.Equal([]GitFileStatus{{Path: "both.go", Status: "modified"}}, status.Unstaged)
+	a.Equal([]string{"conflict.go"}, status.Conflicted)
+	a.Equal([]string{"scratch.txt"}, status.Untracked)
+}
@@ -2,7 +2,7 @@ package tools
 
 import (
 	"context"
-	"io/fs"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -12,19 +12,27 @@ import (
 )
 
 // GlobTool finds files matching glob patterns
-type GlobTool struct{}
+type GlobTool struct {
+	index *FileIndex // optional cache of directory walks
+}
 
 // NewGlobTool creates a new Glob tool
 func NewGlobTool() *GlobTool {
 	return &GlobTool{}
 }
 
+// NewGlobToolWithIndex creates a Glob tool that reuses walks from a shared index
+func NewGlobToolWithIndex(index *FileIndex) *GlobTool {
+	return &GlobTool{index: index}
+}
+
 // Name returns "Glob"
 func (g *GlobTool) Name() string {
 	return "Glob"
 }
 
-// Execute finds files matching the pattern, sorted by modification time (newest first)
+// Execute finds files matching the pattern, sorted by modification time
+// (newest first), as absolute paths unless relative is set
 func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
 	// extract pattern (required)
 	pattern, ok := input["pattern"].(string)
@@ -37,6 +45,10 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	if v, ok := input["path"].(string); ok && v != "" {
 		basePath = v
 	}
+	basePath = resolvePath(ctx, basePath)
+	if err := confinePath(ctx, basePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
 
 	// resolve to absolute path
 	absPath, err := filepath.Abs(basePath)
@@ -44,6 +56,13 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		return ToolResult{Content: err.Error(), IsError: true}, nil
 	}
 
+	// extract limit and relative output
+	limit := 0
+	if v, ok := input["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	relative, _ := input["relative"].(bool)
+
 	// verify path exists
 	if _, err := os.Stat(absPath); err != nil {
 		return ToolResult{Content: err.Error(), IsError: true}, nil
@@ -55,14 +74,11 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		modTime int64
 	}
 	var matches []fileEntry
+	progress := newProgressReporter(ctx)
 
-	err = filepath.WalkDir(absPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // skip errors, continue walking
-		}
-		if d.IsDir() {
-			return nil
-		}
+	err = walkFiles(ctx, g.index, absPath, walkInput(input), func(path string) error {
+		found := len(matches)
+		defer func() { progress.scanned(len(matches) - found) }()
 
 		// get relative path for matching
 		relPath, err := filepath.Rel(absPath, path)
@@ -81,7 +97,8 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		}
 
 		if matched {
-			info, err := d.Info()
+			// lstat rather than cached info so edits to file contents re-sort
+			info, err := os.Lstat(path)
 			if err != nil {
 				return nil
 			}
@@ -92,6 +109,10 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		}
 		return nil
 	})
+	progress.finish()
+	if ctx.Err() != nil {
+		return ToolResult{Content: "glob cancelled", IsError: true}, nil
+	}
 	if err != nil {
 		return ToolResult{Content: err.Error(), IsError: true}, nil
 	}
@@ -106,13 +127,27 @@ func (g *GlobTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		return ToolResult{Content: ""}, nil
 	}
 
+	total := len(matches)
+	if limit > 0 && total > limit {
+		matches = matches[:limit]
+	}
+
 	var sb strings.Builder
 	for i, m := range matches {
-		sb.WriteString(m.path)
+		path := m.path
+		if relative {
+			if rel, err := filepath.Rel(absPath, path); err == nil {
+				path = rel
+			}
+		}
+		sb.WriteString(path)
 		if i < len(matches)-1 {
 			sb.WriteByte('\n')
 		}
 	}
+	if len(matches) < total {
+		fmt.Fprintf(&sb, "\n[results truncated: showing %d of %d files]", len(matches), total)
+	}
 
 	return ToolResult{Content: sb.String()}, nil
 }
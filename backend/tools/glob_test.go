@@ -124,6 +124,221 @@ func TestGlobTool_Execute_SortedByModTime(t *testing.T) {
 	a.Contains(lines[2], "oldest.txt")
 }
 
+func TestGlobTool_Execute_Limit(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - three files with distinct mod times
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"oldest.txt", "middle.txt", "newest.txt"} {
+		path := filepath.Join(dir, name)
+		r.NoError(os.WriteFile(path, []byte("x"), 0644))
+		mtime := now.Add(time.Duration(i-2) * time.Hour)
+		r.NoError(os.Chtimes(path, mtime, mtime))
+	}
+
+	tool := NewGlobTool()
+
+	// when - limit to two results
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern": "*.txt",
+		"path":    dir,
+		"limit":   float64(2),
+	})
+
+	// then - the newest two are kept, with a truncation note
+	r.NoError(err)
+	a.False(result.IsError)
+	lines := splitLines(result.Content)
+	r.Len(lines, 3)
+	a.Contains(lines[0], "newest.txt")
+	a.Contains(lines[1], "middle.txt")
+	a.Equal("[results truncated: showing 2 of 3 files]", lines[2])
+
+	// when - the limit exceeds the matches
+	result, err = tool.Execute(context.Background(), map[string]any{
+		"pattern": "*.txt",
+		"path":    dir,
+		"limit":   float64(5),
+	})
+
+	// then - everything, no note
+	r.NoError(err)
+	a.Len(splitLines(result.Content), 3)
+	a.NotContains(result.Content, "truncated")
+}
+
+func TestGlobTool_Execute_Relative(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a nested file
+	dir := t.TempDir()
+	r.NoError(os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0755))
+	r.NoError(os.WriteFile(filepath.Join(dir, "src", "pkg", "main.go"), []byte("x"), 0644))
+
+	tool := NewGlobTool()
+
+	// when - relative output requested
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":  "**/*.go",
+		"path":     dir,
+		"relative": true,
+	})
+
+	// then - path relative to the search base
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal(filepath.Join("src", "pkg", "main.go"), result.Content)
+
+	// when - default output
+	result, err = tool.Execute(context.Background(), map[string]any{
+		"pattern": "**/*.go",
+		"path":    dir,
+	})
+
+	// then - absolute path
+	r.NoError(err)
+	a.Equal(filepath.Join(dir, "src", "pkg", "main.go"), result.Content)
+}
+
+// excludeTree creates src/, node_modules/, vendor/, .git/ and build/out/ each
+// holding one .go file
+func excludeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "node_modules/pkg", "vendor", ".git", "build/out"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, sub, "file.go"), []byte("package x"), 0644))
+	}
+	return dir
+}
+
+func TestGlobTool_Execute_Exclude(t *testing.T) {
+	dir := excludeTree(t)
+	for name, tool := range map[string]*GlobTool{"walk": NewGlobTool(), "index": NewGlobToolWithIndex(NewFileIndex())} {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			// when - default exclusions
+			result, err := tool.Execute(context.Background(), map[string]any{
+				"pattern":  "**/*.go",
+				"path":     dir,
+				"relative": true,
+			})
+
+			// then - dependency and VCS directories are skipped
+			r.NoError(err)
+			a.ElementsMatch([]string{filepath.Join("src", "file.go"), filepath.Join("build", "out", "file.go")}, splitLines(result.Content))
+
+			// when - a custom list by path pattern
+			result, err = tool.Execute(context.Background(), map[string]any{
+				"pattern":  "**/*.go",
+				"path":     dir,
+				"relative": true,
+				"exclude":  []any{"build/*"},
+			})
+
+			// then - only that list applies
+			r.NoError(err)
+			lines := splitLines(result.Content)
+			a.Len(lines, 4)
+			a.NotContains(result.Content, "out")
+
+			// when - an empty list disables exclusion
+			result, err = tool.Execute(context.Background(), map[string]any{
+				"pattern": "**/*.go",
+				"path":    dir,
+				"exclude": []any{},
+			})
+
+			// then - everything
+			r.NoError(err)
+			a.Len(splitLines(result.Content), 5)
+		})
+	}
+}
+
+// symlinkCycleTree creates a/file.go with a/loop linking back to the root
+// and alias linking to a
+func symlinkCycleTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "file.go"), []byte("package a"), 0644))
+	if err := os.Symlink(dir, filepath.Join(dir, "a", "loop")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	require.NoError(t, os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "alias")))
+	return dir
+}
+
+func TestGlobTool_Execute_FollowSymlinks(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a symlink cycle and an alias of the same directory
+	dir := symlinkCycleTree(t)
+	tool := NewGlobTool()
+
+	// when - following symlinks
+	done := make(chan ToolResult, 1)
+	go func() {
+		result, _ := tool.Execute(context.Background(), map[string]any{
+			"pattern":         "**/*.go",
+			"path":            dir,
+			"follow_symlinks": true,
+		})
+		done <- result
+	}()
+
+	// then - the walk terminates and reports the file once
+	select {
+	case result := <-done:
+		a.False(result.IsError)
+		a.Equal([]string{filepath.Join(dir, "a", "file.go")}, splitLines(result.Content))
+	case <-time.After(5 * time.Second):
+		r.Fail("walk did not terminate")
+	}
+
+	// when - links are not followed
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern": "**/*.go",
+		"path":    dir,
+	})
+
+	// then - only the real file
+	r.NoError(err)
+	a.Equal(filepath.Join(dir, "a", "file.go"), result.Content)
+}
+
+func TestGlobTool_Execute_Progress(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - two matching files among four
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt", "d.txt"} {
+		r.NoError(os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644))
+	}
+	var reports []Progress
+	ctx := WithProgressSink(context.Background(), func(p Progress) {
+		reports = append(reports, p)
+	})
+
+	tool := NewGlobTool()
+
+	// when
+	_, err := tool.Execute(ctx, map[string]any{"pattern": "*.go", "path": dir})
+
+	// then - the final report counts every file walked and matched
+	r.NoError(err)
+	r.NotEmpty(reports)
+	a.Equal(Progress{FilesScanned: 4, Matches: 2}, reports[len(reports)-1])
+}
+
 func TestGlobTool_Execute_NoMatches(t *testing.T) {
 	a := assert.New(t)
 	r := require.New(t)
@@ -210,3 +425,31 @@ func splitNonEmpty(s string, sep byte) []string {
 	}
 	return result
 }
+
+func TestGlobTool_Execute_Cancelled(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a tree of files and an already cancelled context
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		r.NoError(os.WriteFile(filepath.Join(dir, "f"+string(rune('a'+i%26))+".go"), []byte("x"), 0644))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tool := NewGlobTool()
+
+	// when
+	start := time.Now()
+	result, err := tool.Execute(ctx, map[string]any{
+		"pattern": "**/*.go",
+		"path":    dir,
+	})
+
+	// then - cancellation result returned promptly
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Equal("glob cancelled", result.Content)
+	a.Less(time.Since(start), time.Second)
+}
@@ -7,15 +7,36 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 )
 
+// ctxCheckInterval is how many lines are scanned between cancellation checks
+const ctxCheckInterval = 1024
+
+// defaultMaxContentBytes caps content mode output to protect the context window
+const defaultMaxContentBytes = 50000
+
 // GrepTool searches files for patterns using regex
-type GrepTool struct{}
+type GrepTool struct {
+	maxContentBytes int        // content mode output cap, zero or less disables
+	index           *FileIndex // optional cache of directory walks and patterns
+}
 
 // NewGrepTool creates a new Grep tool
 func NewGrepTool() *GrepTool {
-	return &GrepTool{}
+	return &GrepTool{maxContentBytes: defaultMaxContentBytes}
+}
+
+// NewGrepToolWithLimit creates a Grep tool with a custom content mode byte cap
+func NewGrepToolWithLimit(maxContentBytes int) *GrepTool {
+	return &GrepTool{maxContentBytes: maxContentBytes}
+}
+
+// NewGrepToolWithIndex creates a Grep tool that reuses walks and compiled
+// patterns from a shared index
+func NewGrepToolWithIndex(index *FileIndex) *GrepTool {
+	return &GrepTool{maxContentBytes: defaultMaxContentBytes, index: index}
 }
 
 // Name returns "Grep"
@@ -37,20 +58,46 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		caseInsensitive = v
 	}
 
+	// fixed_strings (-F) treats the pattern as literal text
+	fixedStrings := false
+	for _, key := range []string{"fixed_strings", "-F"} {
+		if v, ok := input[key].(bool); ok && v {
+			fixedStrings = true
+		}
+	}
+
+	// word (-w) only matches whole words
+	word := false
+	for _, key := range []string{"word", "-w"} {
+		if v, ok := input[key].(bool); ok && v {
+			word = true
+		}
+	}
+
 	// compile regex
+	if fixedStrings {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if word {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
 	if caseInsensitive {
 		pattern = "(?i)" + pattern
 	}
-	re, err := regexp.Compile(pattern)
+	re, err := g.compile(pattern)
 	if err != nil {
 		return ToolResult{Content: fmt.Sprintf("invalid regex: %v", err), IsError: true}, nil
 	}
 
-	// extract path (optional, defaults to cwd)
+	// extract path (optional, defaults to the session's directory)
 	searchPath := "."
 	if v, ok := input["path"].(string); ok && v != "" {
 		searchPath = v
 	}
+	searchPath = resolvePath(ctx, searchPath)
+	if err := confinePath(ctx, searchPath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
 
 	// verify path exists
 	info, err := os.Stat(searchPath)
@@ -84,6 +131,32 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		contextBefore = int(v)
 	}
 
+	// show_column prefixes match lines with line:col (content mode)
+	showColumn := false
+	if v, ok := input["show_column"].(bool); ok {
+		showColumn = v
+	}
+
+	// per_file reports path:count lines in count mode
+	perFile := false
+	if v, ok := input["per_file"].(bool); ok {
+		perFile = v
+	}
+
+	// invert (-v) selects non-matching lines. In files_with_matches mode this
+	// lists files with at least one non-matching line, or with
+	// files_without_match set, files where no line matches at all.
+	invert := false
+	for _, key := range []string{"invert", "-v"} {
+		if v, ok := input[key].(bool); ok && v {
+			invert = true
+		}
+	}
+	filesWithoutMatch := false
+	if v, ok := input["files_without_match"].(bool); ok {
+		filesWithoutMatch = v
+	}
+
 	// extract head_limit
 	headLimit := 0
 	if v, ok := input["head_limit"].(float64); ok && v > 0 {
@@ -92,13 +165,22 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 
 	var results []string
 	var totalCount int
+	var fileCounts []fileCount
+
+	// content mode limits: head_limit counts output lines, not files
+	var contentLines, contentBytes int
+	truncated := false
+
+	progress := newProgressReporter(ctx)
 
 	// search function for a single file
 	searchFile := func(filePath string) error {
-		// check head_limit early
-		if headLimit > 0 && len(results) >= headLimit {
+		// check head_limit early; content mode counts lines as it writes them
+		if headLimit > 0 && outputMode != "content" && len(results) >= headLimit {
 			return filepath.SkipAll
 		}
+		matchCount := 0 // matching lines, reported once the file is done
+		defer func() { progress.scanned(matchCount) }()
 
 		data, err := os.ReadFile(filePath)
 		if err != nil {
@@ -115,12 +197,41 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		}
 
 		lines := strings.Split(string(data), "\n")
+		// don't treat the empty string after a final newline as a line
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
 		var matches []int
+		columns := make(map[int]int) // line index -> 1-indexed match column
 
 		for i, line := range lines {
-			if re.MatchString(line) {
+			// check for cancellation periodically on large files
+			if i%ctxCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			loc := re.FindStringIndex(line)
+			if invert {
+				if loc == nil {
+					matches = append(matches, i)
+				}
+				continue
+			}
+			if loc != nil {
 				matches = append(matches, i)
+				columns[i] = loc[0] + 1
+			}
+		}
+
+		matchCount = len(matches)
+
+		// invert + files_without_match: every line must be non-matching
+		if invert && filesWithoutMatch && outputMode == "files_with_matches" {
+			if len(matches) == len(lines) {
+				results = append(results, filePath)
 			}
+			return nil
 		}
 
 		if len(matches) == 0 {
@@ -133,8 +244,15 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 
 		case "count":
 			totalCount += len(matches)
+			fileCounts = append(fileCounts, fileCount{path: filePath, count: len(matches)})
 
 		case "content":
+			// this file's lines would all fall past the limit
+			if headLimit > 0 && contentLines >= headLimit {
+				truncated = true
+				return filepath.SkipAll
+			}
+
 			// collect lines with context
 			includedLines := make(map[int]bool)
 			for _, matchIdx := range matches {
@@ -155,26 +273,39 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 			var sb strings.Builder
 			sb.WriteString(filePath)
 			sb.WriteString(":\n")
+			contentBytes += sb.Len()
+			written := 0
 			for i := 0; i < len(lines); i++ {
-				if includedLines[i] {
-					sb.WriteString(fmt.Sprintf("%d\t%s\n", i+1, lines[i]))
+				if !includedLines[i] {
+					continue
+				}
+				line := fmt.Sprintf("%d\t%s\n", i+1, lines[i])
+				if col, isMatch := columns[i]; showColumn && isMatch {
+					line = fmt.Sprintf("%d:%d\t%s\n", i+1, col, lines[i])
 				}
+				if (headLimit > 0 && contentLines >= headLimit) ||
+					(g.maxContentBytes > 0 && contentBytes+len(line) > g.maxContentBytes) {
+					truncated = true
+					break
+				}
+				sb.WriteString(line)
+				contentLines++
+				contentBytes += len(line)
+				written++
+			}
+			if written > 0 {
+				results = append(results, strings.TrimSuffix(sb.String(), "\n"))
+			}
+			if truncated {
+				return filepath.SkipAll
 			}
-			results = append(results, strings.TrimSuffix(sb.String(), "\n"))
 		}
 
 		return nil
 	}
 
 	if info.IsDir() {
-		err = filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return nil // skip errors
-			}
-			if d.IsDir() {
-				return nil
-			}
-
+		err = walkFiles(ctx, g.index, searchPath, walkInput(input), func(path string) error {
 			// apply glob filter
 			if globPattern != "" {
 				matched, err := matchGlob(globPattern, searchPath, path)
@@ -188,7 +319,11 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	} else {
 		err = searchFile(searchPath)
 	}
+	progress.finish()
 
+	if ctx.Err() != nil {
+		return ToolResult{Content: "search cancelled", IsError: true}, nil
+	}
 	if err != nil && err != filepath.SkipAll {
 		return ToolResult{Content: err.Error(), IsError: true}, nil
 	}
@@ -197,7 +332,30 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	var output string
 	switch outputMode {
 	case "count":
-		output = fmt.Sprintf("%d", totalCount)
+		if !perFile {
+			output = fmt.Sprintf("%d", totalCount)
+			break
+		}
+		// highest counts first, ties by path for stable output
+		sort.Slice(fileCounts, func(i, j int) bool {
+			if fileCounts[i].count != fileCounts[j].count {
+				return fileCounts[i].count > fileCounts[j].count
+			}
+			return fileCounts[i].path < fileCounts[j].path
+		})
+		if headLimit > 0 && len(fileCounts) > headLimit {
+			fileCounts = fileCounts[:headLimit]
+		}
+		lines := make([]string, len(fileCounts))
+		for i, fc := range fileCounts {
+			lines[i] = fmt.Sprintf("%s:%d", fc.path, fc.count)
+		}
+		output = strings.Join(lines, "\n")
+	case "content":
+		output = strings.Join(results, "\n")
+		if truncated {
+			output += fmt.Sprintf("\n[results truncated: showing %d lines]", contentLines)
+		}
 	default:
 		if headLimit > 0 && len(results) > headLimit {
 			results = results[:headLimit]
@@ -208,6 +366,20 @@ func (g *GrepTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	return ToolResult{Content: output}, nil
 }
 
+// compile compiles pattern, through the index when one is set
+func (g *GrepTool) compile(pattern string) (*regexp.Regexp, error) {
+	if g.index != nil {
+		return g.index.Regexp(pattern)
+	}
+	return regexp.Compile(pattern)
+}
+
+// fileCount is a per-file match count for count mode
+type fileCount struct {
+	path  string
+	count int
+}
+
 // matchGlob checks if path matches glob pattern relative to base
 func matchGlob(pattern, base, path string) (bool, error) {
 	relPath, err := filepath.Rel(base, path)
@@ -2,10 +2,12 @@ package tools
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,6 +94,97 @@ func TestGrepTool_Execute_GlobFilter(t *testing.T) {
 	a.NotContains(result.Content, "bar.txt")
 }
 
+func TestGrepTool_Execute_Exclude(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - matching files in source and dependency directories
+	dir := excludeTree(t)
+	tool := NewGrepTool()
+
+	// when - default exclusions
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern": "package",
+		"path":    dir,
+	})
+
+	// then - excluded directories' files don't appear
+	r.NoError(err)
+	a.Contains(result.Content, filepath.Join("src", "file.go"))
+	a.NotContains(result.Content, "node_modules")
+	a.NotContains(result.Content, "vendor")
+	a.NotContains(result.Content, ".git")
+
+	// when - a custom list
+	result, err = tool.Execute(context.Background(), map[string]any{
+		"pattern": "package",
+		"path":    dir,
+		"exclude": []any{"src", "node_*"},
+	})
+
+	// then - only those are skipped
+	r.NoError(err)
+	a.NotContains(result.Content, "src")
+	a.NotContains(result.Content, "node_modules")
+	a.Contains(result.Content, "vendor")
+	a.Contains(result.Content, ".git")
+}
+
+func TestGrepTool_Execute_FollowSymlinks(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a symlink cycle and an alias of the same directory
+	dir := symlinkCycleTree(t)
+	tool := NewGrepToolWithIndex(NewFileIndex())
+
+	// when - following symlinks
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":         "package",
+		"path":            dir,
+		"output_mode":     "count",
+		"follow_symlinks": true,
+	})
+
+	// then - the one matching line is counted once
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal("1", result.Content)
+}
+
+func TestGrepTool_Execute_Progress(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - five files, three with two matching lines each
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		body := "nothing\n"
+		if i < 3 {
+			body = "needle\nhay\nneedle\n"
+		}
+		r.NoError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(body), 0644))
+	}
+	var reports []Progress
+	ctx := WithProgressSink(context.Background(), func(p Progress) {
+		reports = append(reports, p)
+	})
+
+	tool := NewGrepTool()
+
+	// when
+	result, err := tool.Execute(ctx, map[string]any{"pattern": "needle", "path": dir})
+
+	// then - counts only grow and the last report covers the whole search
+	r.NoError(err)
+	a.False(result.IsError)
+	r.NotEmpty(reports)
+	for i := 1; i < len(reports); i++ {
+		a.GreaterOrEqual(reports[i].FilesScanned, reports[i-1].FilesScanned)
+	}
+	a.Equal(Progress{FilesScanned: 5, Matches: 6}, reports[len(reports)-1])
+}
+
 func TestGrepTool_Execute_MissingPattern(t *testing.T) {
 	a := assert.New(t)
 	r := require.New(t)
@@ -372,3 +465,394 @@ func TestGrepTool_Execute_HeadLimit(t *testing.T) {
 	lines := strings.Split(strings.TrimSpace(result.Content), "\n")
 	a.Equal(3, len(lines))
 }
+
+func TestGrepTool_Execute_Cancelled(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a tree of matching files and an already cancelled context
+	dir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		r.NoError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte("match\n"), 0644))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tool := NewGrepTool()
+
+	// when
+	start := time.Now()
+	result, err := tool.Execute(ctx, map[string]any{
+		"pattern": "match",
+		"path":    dir,
+	})
+
+	// then - cancellation result returned promptly
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Equal("search cancelled", result.Content)
+	a.Less(time.Since(start), time.Second)
+}
+
+func TestGrepTool_Execute_CancelledSingleFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a single large file and an already cancelled context
+	dir := t.TempDir()
+	file := filepath.Join(dir, "big.txt")
+	r.NoError(os.WriteFile(file, []byte(strings.Repeat("match\n", 10000)), 0644))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tool := NewGrepTool()
+
+	// when
+	result, err := tool.Execute(ctx, map[string]any{
+		"pattern":     "match",
+		"path":        file,
+		"output_mode": "count",
+	})
+
+	// then - line loop stops rather than counting
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Equal("search cancelled", result.Content)
+}
+
+func TestGrepTool_Execute_ContentHeadLimitCountsLines(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - one file with many matching lines
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "many.txt"), []byte(strings.Repeat("match\n", 20)), 0644))
+
+	tool := NewGrepTool()
+
+	// when - content mode with head_limit
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":     "match",
+		"path":        dir,
+		"output_mode": "content",
+		"head_limit":  float64(5),
+	})
+
+	// then - only 5 match lines plus the header and truncation marker
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal(5, strings.Count(result.Content, "\tmatch"))
+	a.Contains(result.Content, "[results truncated: showing 5 lines]")
+}
+
+func TestGrepTool_Execute_ContentHeadLimitAcrossFiles(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - more files than head_limit, one matching line each
+	dir := t.TempDir()
+	for i := 0; i < 5; i++ {
+		r.NoError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte("match\n"), 0644))
+	}
+
+	// when - the limit is reached exactly at a file boundary
+	result, err := NewGrepTool().Execute(context.Background(), map[string]any{
+		"pattern":     "match",
+		"path":        dir,
+		"output_mode": "content",
+		"head_limit":  float64(3),
+	})
+
+	// then - three lines and the marker for the files left out
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal(3, strings.Count(result.Content, "\tmatch"))
+	a.Contains(result.Content, "[results truncated: showing 3 lines]")
+}
+
+func TestGrepTool_Execute_ContentHeadLimitExact(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - exactly head_limit matching lines across files
+	dir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		r.NoError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte("match\nother\n"), 0644))
+	}
+
+	// when
+	result, err := NewGrepTool().Execute(context.Background(), map[string]any{
+		"pattern":     "match",
+		"path":        dir,
+		"output_mode": "content",
+		"head_limit":  float64(3),
+	})
+
+	// then - nothing was cut, so no marker
+	r.NoError(err)
+	a.Equal(3, strings.Count(result.Content, "\tmatch"))
+	a.NotContains(result.Content, "truncated")
+}
+
+func TestGrepTool_Execute_ContentByteCap(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - many files with matches and a small byte cap
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		r.NoError(os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)), []byte(strings.Repeat("needle in a haystack\n", 10)), 0644))
+	}
+
+	tool := NewGrepToolWithLimit(500)
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":     "needle",
+		"path":        dir,
+		"output_mode": "content",
+	})
+
+	// then - output stays near the cap and ends with a clear marker
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Contains(result.Content, "[results truncated:")
+	body := result.Content[:strings.LastIndex(result.Content, "\n[results truncated")]
+	a.LessOrEqual(len(body), 500)
+}
+
+func TestGrepTool_Execute_ContentNoTruncation(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - small result well under the default cap
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "a.txt"), []byte("match\nother\n"), 0644))
+
+	// when
+	result, err := NewGrepTool().Execute(context.Background(), map[string]any{
+		"pattern":     "match",
+		"path":        dir,
+		"output_mode": "content",
+	})
+
+	// then - no marker
+	r.NoError(err)
+	a.NotContains(result.Content, "truncated")
+}
+
+func TestGrepTool_Execute_ShowColumn(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - match not at line start, with a context line
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\tfunc Hello() {}\n"), 0644))
+
+	tool := NewGrepTool()
+
+	// when - content mode with show_column and context
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":     "Hello",
+		"path":        dir,
+		"output_mode": "content",
+		"show_column": true,
+		"-B":          float64(1),
+	})
+
+	// then - match line reports 1-indexed column, context line unchanged
+	r.NoError(err)
+	a.Contains(result.Content, "2:7\t\tfunc Hello() {}")
+	a.Contains(result.Content, "1\tpackage a")
+}
+
+func TestGrepTool_Execute_ShowColumnDefaultOff(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "a.go"), []byte("xx Hello\n"), 0644))
+
+	// when - no show_column
+	result, err := NewGrepTool().Execute(context.Background(), map[string]any{
+		"pattern":     "Hello",
+		"path":        dir,
+		"output_mode": "content",
+	})
+
+	// then - default line format
+	r.NoError(err)
+	a.Contains(result.Content, "1\txx Hello")
+	a.NotContains(result.Content, "1:4")
+}
+
+func TestGrepTool_Execute_CountPerFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - files with differing match counts
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "one.txt"), []byte("x\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "three.txt"), []byte("x\nx\nx\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "two.txt"), []byte("x\ny\nx\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "none.txt"), []byte("y\n"), 0644))
+
+	tool := NewGrepTool()
+
+	// when - count mode per file
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":     "x",
+		"path":        dir,
+		"output_mode": "count",
+		"per_file":    true,
+	})
+
+	// then - path:count lines sorted by count descending
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal(strings.Join([]string{
+		filepath.Join(dir, "three.txt") + ":3",
+		filepath.Join(dir, "two.txt") + ":2",
+		filepath.Join(dir, "one.txt") + ":1",
+	}, "\n"), result.Content)
+
+	// aggregate behaviour unchanged without per_file
+	result, err = tool.Execute(context.Background(), map[string]any{
+		"pattern":     "x",
+		"path":        dir,
+		"output_mode": "count",
+	})
+	r.NoError(err)
+	a.Equal("6", result.Content)
+}
+
+func TestGrepTool_Execute_FixedStrings(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a literal with metacharacters and a regex-only lookalike
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "literal.txt"), []byte("call A.B(x)\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "lookalike.txt"), []byte("call axb(x)\n"), 0644))
+
+	tool := NewGrepTool()
+
+	// when - fixed_strings combined with -i
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"pattern":       "a.b(",
+		"path":          dir,
+		"fixed_strings": true,
+		"-i":            true,
+	})
+
+	// then - only the literal match, no regex compile error
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Contains(result.Content, "literal.txt")
+	a.NotContains(result.Content, "lookalike.txt")
+}
+
+func TestGrepTool_Execute_Word(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - standalone word and the word embedded in another
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "standalone.txt"), []byte("call Foo() now\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "embedded.txt"), []byte("call foobar() now\n"), 0644))
+
+	tool := NewGrepTool()
+
+	tests := []struct {
+		name  string
+		input map[string]any
+	}{
+		{"regex with -i", map[string]any{"pattern": "foo", "word": true, "-i": true}},
+		{"fixed strings with -i", map[string]any{"pattern": "foo", "word": true, "-i": true, "fixed_strings": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			tt.input["path"] = dir
+			result, err := tool.Execute(context.Background(), tt.input)
+
+			// then - only the standalone occurrence matches
+			r.NoError(err)
+			a.False(result.IsError)
+			a.Contains(result.Content, "standalone.txt")
+			a.NotContains(result.Content, "embedded.txt")
+		})
+	}
+}
+
+func TestGrepTool_Execute_InvertContent(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "a.go"), []byte("// comment\ncode()\n// another\n"), 0644))
+
+	// when - content mode inverted
+	result, err := NewGrepTool().Execute(context.Background(), map[string]any{
+		"pattern":     "^//",
+		"path":        dir,
+		"output_mode": "content",
+		"invert":      true,
+	})
+
+	// then - only the non-matching line, no phantom trailing line
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal(filepath.Join(dir, "a.go")+":\n2\tcode()", result.Content)
+}
+
+func TestGrepTool_Execute_InvertFiles(t *testing.T) {
+	r := require.New(t)
+
+	// given - a file mixing lines, one fully matching, one never matching
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "mixed.txt"), []byte("todo\ndone\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "all.txt"), []byte("todo\ntodo\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "none.txt"), []byte("done\n"), 0644))
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "files with a non-matching line",
+			input:   map[string]any{"invert": true},
+			want:    []string{"mixed.txt", "none.txt"},
+			notWant: []string{"all.txt"},
+		},
+		{
+			name:    "files without any match",
+			input:   map[string]any{"-v": true, "files_without_match": true},
+			want:    []string{"none.txt"},
+			notWant: []string{"mixed.txt", "all.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+
+			// when
+			tt.input["pattern"] = "todo"
+			tt.input["path"] = dir
+			result, err := NewGrepTool().Execute(context.Background(), tt.input)
+
+			// then
+			r.NoError(err)
+			for _, w := range tt.want {
+				a.Contains(result.Content, w)
+			}
+			for _, nw := range tt.notWant {
+				a.NotContains(result.Content, nw)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package tools
+
+import (
+	"container/list"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// FileIndex caches directory walks and compiled patterns across tool calls.
+// A cached tree is reused while every directory in it keeps its mtime, which
+// changes whenever an entry is added, removed, or renamed.
+type FileIndex struct {
+	trees   *lruCache[*indexedTree]
+	regexps *lruCache[*regexp.Regexp]
+	mu      sync.Mutex
+}
+
+// Cache bounds; beyond them the least recently used entry is evicted
+const (
+	maxIndexedTrees  = 64
+	maxCachedRegexps = 256
+)
+
+// racyWindow is how recent a directory mtime may be before a cached tree is
+// distrusted; filesystems with coarse timestamps can hide a change made in the
+// same tick as the walk.
+const racyWindow = 2 * time.Second
+
+// indexedTree is one cached walk rooted at a path
+type indexedTree struct {
+	dirs  map[string]time.Time // directory -> mtime when indexed
+	files []string             // non-directory paths in walk order
+	racy  bool                 // a directory changed too close to the walk to trust
+}
+
+// NewFileIndex creates an empty index
+func NewFileIndex() *FileIndex {
+	return &FileIndex{
+		trees:   newLRUCache[*indexedTree](maxIndexedTrees),
+		regexps: newLRUCache[*regexp.Regexp](maxCachedRegexps),
+	}
+}
+
+// Files returns the non-directory paths under root in WalkDir order,
+// rebuilding the cached tree if any directory changed
+func (x *FileIndex) Files(ctx context.Context, root string) ([]string, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	// relative roots produce relative paths, so key on both spellings
+	key := root + "\x00" + abs
+
+	x.mu.Lock()
+	tree, _ := x.trees.get(key)
+	x.mu.Unlock()
+	if tree != nil && tree.fresh() {
+		return tree.files, nil
+	}
+
+	tree, err = buildTree(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	x.mu.Lock()
+	x.trees.put(key, tree)
+	x.mu.Unlock()
+	return tree.files, nil
+}
+
+// Regexp returns a compiled pattern, reusing earlier compilations
+func (x *FileIndex) Regexp(pattern string) (*regexp.Regexp, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if re, ok := x.regexps.get(pattern); ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	x.regexps.put(pattern, re)
+	return re, nil
+}
+
+// lruCache maps keys to values, evicting the least recently used entry once
+// it holds max; callers synchronize access
+type lruCache[V any] struct {
+	max   int
+	order *list.List // most recently used first; elements hold *lruEntry[V]
+	items map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRUCache[V any](max int) *lruCache[V] {
+	return &lruCache[V]{max: max, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get returns key's value and marks it recently used
+func (c *lruCache[V]) get(key string) (V, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry[V]).value, true
+}
+
+// put stores value under key, evicting the oldest entry when full
+func (c *lruCache[V]) put(key string, value V) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+// len returns the number of cached entries
+func (c *lruCache[V]) len() int {
+	return c.order.Len()
+}
+
+// fresh reports whether no directory in the tree has changed
+func (t *indexedTree) fresh() bool {
+	if t.racy {
+		return false
+	}
+	for dir, modTime := range t.dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func buildTree(ctx context.Context, root string) (*indexedTree, error) {
+	tree := &indexedTree{dirs: make(map[string]time.Time)}
+	cutoff := time.Now().Add(-racyWindow)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil // skip errors, matching the uncached walk
+		}
+		if d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				tree.dirs[path] = info.ModTime()
+				if info.ModTime().After(cutoff) {
+					tree.racy = true
+				}
+			}
+			return nil
+		}
+		tree.files = append(tree.files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// walkFiles calls fn for each non-directory path under root, using idx when set,
+// skipping directories below root that match opts.exclude. Following symlinks
+// bypasses idx, whose cached trees do not.
+// fn may return filepath.SkipAll to stop early.
+func walkFiles(ctx context.Context, idx *FileIndex, root string, opts walkOptions, fn func(path string) error) error {
+	exclude := opts.exclude
+	if opts.followSymlinks {
+		return walkFollowingSymlinks(ctx, root, exclude, fn)
+	}
+	if idx == nil {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				return nil // skip errors
+			}
+			if d.IsDir() {
+				if path != root && len(exclude) > 0 {
+					if rel, err := filepath.Rel(root, path); err == nil && excludedDir(rel, exclude) {
+						return filepath.SkipDir
+					}
+				}
+				return nil
+			}
+			return fn(path)
+		})
+	}
+
+	files, err := idx.Files(ctx, root)
+	if err != nil {
+		return err
+	}
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// the cached tree is shared, so exclusions filter rather than prune
+		if len(exclude) > 0 && inExcludedDir(root, path, exclude) {
+			continue
+		}
+		if err := fn(path); err != nil {
+			if err == filepath.SkipAll {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// walkFollowingSymlinks walks like filepath.WalkDir but descends into
+// symlinked directories. Each directory is entered once by file ID, so link
+// cycles terminate and a tree reachable by several links is reported once.
+func walkFollowingSymlinks(ctx context.Context, root string, exclude []string, fn func(path string) error) error {
+	visited := make(map[fileID]bool)
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil // skip errors, matching the plain walk
+		}
+		if id, ok := fileIDOf(dir, info); ok {
+			if visited[id] {
+				return nil
+			}
+			visited[id] = true
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil
+		}
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			path := filepath.Join(dir, entry.Name())
+			isDir := entry.IsDir()
+			if entry.Type()&fs.ModeSymlink != 0 {
+				target, err := os.Stat(path)
+				if err != nil {
+					continue // dangling link
+				}
+				isDir = target.IsDir()
+			}
+			if !isDir {
+				if err := fn(path); err != nil {
+					return err
+				}
+				continue
+			}
+			if len(exclude) > 0 {
+				if rel, err := filepath.Rel(root, path); err == nil && excludedDir(rel, exclude) {
+					continue
+				}
+			}
+			if err := walk(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil && err != filepath.SkipAll {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staleTime is an mtime old enough for the index to trust
+var staleTime = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+// makeSearchTree creates dirs*files text files and backdates every directory
+// so the index trusts its mtimes
+func makeSearchTree(tb testing.TB, dirs, files int) string {
+	tb.Helper()
+	root := tb.TempDir()
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("pkg%d", d), "sub")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			content := fmt.Sprintf("package pkg%d\n\nfunc F%d() int { return %d }\n// TODO: cleanup\n", d, f, f)
+			ext := ".go"
+			if f%3 == 0 {
+				ext = ".txt"
+			}
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d%s", f, ext)), []byte(content), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	backdateDirs(tb, root, staleTime)
+	return root
+}
+
+func backdateDirs(tb testing.TB, root string, when time.Time) {
+	tb.Helper()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, when, when)
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestFileIndex_MatchesUncached(t *testing.T) {
+	r := require.New(t)
+
+	// given - a tree searched with and without a shared index
+	root := makeSearchTree(t, 4, 6)
+	index := NewFileIndex()
+	grepInputs := []map[string]any{
+		{"pattern": "TODO", "path": root},
+		{"pattern": "return [0-2]", "path": root, "output_mode": "content"},
+		{"pattern": "func", "path": root, "output_mode": "count", "per_file": true},
+		{"pattern": "package", "path": root, "glob": "*.txt"},
+		{"pattern": "F1", "path": root, "-w": true, "head_limit": float64(2)},
+	}
+	globInputs := []map[string]any{
+		{"pattern": "**/*.go", "path": root},
+		{"pattern": "pkg1/**", "path": root},
+	}
+
+	// when/then - repeated cached runs match the uncached output
+	for round := 0; round < 2; round++ {
+		for _, input := range grepInputs {
+			want, err := NewGrepTool().Execute(context.Background(), input)
+			r.NoError(err)
+			got, err := NewGrepToolWithIndex(index).Execute(context.Background(), input)
+			r.NoError(err)
+			r.Equal(want, got, "grep %v", input)
+		}
+		for _, input := range globInputs {
+			want, err := NewGlobTool().Execute(context.Background(), input)
+			r.NoError(err)
+			got, err := NewGlobToolWithIndex(index).Execute(context.Background(), input)
+			r.NoError(err)
+			r.Equal(want, got, "glob %v", input)
+		}
+	}
+}
+
+func TestFileIndex_InvalidatesOnDirectoryChange(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - an indexed tree
+	root := makeSearchTree(t, 1, 2)
+	index := NewFileIndex()
+	before, err := index.Files(context.Background(), root)
+	r.NoError(err)
+
+	// when - a file is added to a nested directory
+	added := filepath.Join(root, "pkg0", "sub", "new.go")
+	r.NoError(os.WriteFile(added, []byte("package pkg0\n"), 0644))
+	after, err := index.Files(context.Background(), root)
+
+	// then - the new file is listed
+	r.NoError(err)
+	a.Len(after, len(before)+1)
+	a.Contains(after, added)
+}
+
+func TestFileIndex_ReusesUnchangedTree(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - an indexed tree
+	root := makeSearchTree(t, 1, 2)
+	index := NewFileIndex()
+	before, err := index.Files(context.Background(), root)
+	r.NoError(err)
+
+	// when - a file appears but directory mtimes are restored
+	hidden := filepath.Join(root, "pkg0", "sub", "hidden.go")
+	r.NoError(os.WriteFile(hidden, []byte("package pkg0\n"), 0644))
+	backdateDirs(t, root, staleTime)
+	after, err := index.Files(context.Background(), root)
+
+	// then - the cached listing is returned without re-walking
+	r.NoError(err)
+	a.Equal(before, after)
+}
+
+func TestFileIndex_DistrustsRecentMtimes(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a freshly created tree indexed immediately
+	root := t.TempDir()
+	index := NewFileIndex()
+	_, err := index.Files(context.Background(), root)
+	r.NoError(err)
+
+	// when - a file is added within the same timestamp tick
+	added := filepath.Join(root, "a.txt")
+	r.NoError(os.WriteFile(added, []byte("a"), 0644))
+	files, err := index.Files(context.Background(), root)
+
+	// then - the tree is re-walked
+	r.NoError(err)
+	a.Equal([]string{added}, files)
+}
+
+func TestFileIndex_Regexp(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	index := NewFileIndex()
+
+	// when - the same pattern is compiled twice
+	first, err := index.Regexp("fo+")
+	r.NoError(err)
+	second, err := index.Regexp("fo+")
+	r.NoError(err)
+	_, badErr := index.Regexp("[")
+
+	// then - the compiled pattern is shared and errors are returned
+	a.Same(first, second)
+	a.Error(badErr)
+}
+
+func TestFileIndex_EvictsLeastRecentlyUsed(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	index := NewFileIndex()
+	first, err := index.Regexp("p0")
+	r.NoError(err)
+
+	// when - more patterns than the cache holds, touching the first along the way
+	for i := 1; i <= maxCachedRegexps; i++ {
+		_, err := index.Regexp(fmt.Sprintf("p%d", i))
+		r.NoError(err)
+		if i == maxCachedRegexps/2 {
+			_, err := index.Regexp("p0")
+			r.NoError(err)
+		}
+	}
+
+	// then - the cache stays bounded, dropping the oldest untouched entry
+	a.Equal(maxCachedRegexps, index.regexps.len())
+	again, err := index.Regexp("p0")
+	r.NoError(err)
+	a.Same(first, again)
+	_, ok := index.regexps.get("p1")
+	a.False(ok)
+
+	// when - more roots are walked than the cache holds
+	for i := 0; i <= maxIndexedTrees; i++ {
+		_, err := index.Files(context.Background(), t.TempDir())
+		r.NoError(err)
+	}
+
+	// then - trees are bounded too
+	a.Equal(maxIndexedTrees, index.trees.len())
+}
+
+func benchmarkGrep(b *testing.B, tool *GrepTool) {
+	root := makeSearchTree(b, 50, 20)
+	input := map[string]any{"pattern": "NOMATCH", "path": root, "glob": "*.md"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tool.Execute(context.Background(), input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGrep_Uncached(b *testing.B) {
+	benchmarkGrep(b, NewGrepTool())
+}
+
+func BenchmarkGrep_Cached(b *testing.B) {
+	benchmarkGrep(b, NewGrepToolWithIndex(NewFileIndex()))
+}
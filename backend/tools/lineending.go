@@ -0,0 +1,76 @@
+package tools
+
+import "strings"
+
+const (
+	lineEndingLF   = "\n"
+	lineEndingCRLF = "\r\n"
+)
+
+// detectLineEnding returns the dominant line ending in content, defaulting to LF
+func detectLineEnding(content string) string {
+	crlf := strings.Count(content, lineEndingCRLF)
+	lf := strings.Count(content, lineEndingLF) - crlf
+	if crlf > lf {
+		return lineEndingCRLF
+	}
+	return lineEndingLF
+}
+
+// normalizeLineEndings converts CRLF line endings to LF
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(content, lineEndingCRLF, lineEndingLF)
+}
+
+// restoreLineEndings converts LF-normalized content back to ending
+func restoreLineEndings(content, ending string) string {
+	if ending == lineEndingLF {
+		return content
+	}
+	return strings.ReplaceAll(content, lineEndingLF, ending)
+}
+
+// replaceNormalized replaces the first n occurrences (all when n < 0) of old
+// in raw, matching as if raw's CRLFs were LF. Text outside the matches keeps
+// its bytes; each replacement takes the line ending of the span it replaces,
+// or fallback when that span has no line break.
+func replaceNormalized(raw, old, new string, n int, fallback string) string {
+	norm := normalizeLineEndings(raw)
+	// rawIndex[i] is the offset in raw of norm[i]; a CRLF's LF maps to its CR
+	rawIndex := make([]int, 0, len(norm)+1)
+	for i := 0; i < len(raw); i++ {
+		rawIndex = append(rawIndex, i)
+		if strings.HasPrefix(raw[i:], lineEndingCRLF) {
+			i++
+		}
+	}
+	rawIndex = append(rawIndex, len(raw))
+
+	var sb strings.Builder
+	last := 0
+	for replaced := 0; n < 0 || replaced < n; replaced++ {
+		i := strings.Index(norm[last:], old)
+		if i < 0 {
+			break
+		}
+		start, end := last+i, last+i+len(old)
+		span := raw[rawIndex[start]:rawIndex[end]]
+		ending := fallback
+		if strings.Contains(span, lineEndingLF) {
+			ending = detectLineEnding(span)
+		}
+		sb.WriteString(raw[rawIndex[last]:rawIndex[start]])
+		sb.WriteString(restoreLineEndings(new, ending))
+		last = end
+	}
+	sb.WriteString(raw[rawIndex[last]:])
+	return sb.String()
+}
+
+// lineEndingName returns a display name for ending
+func lineEndingName(ending string) string {
+	if ending == lineEndingCRLF {
+		return "CRLF"
+	}
+	return "LF"
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"ccui/backend"
+)
+
+// Middleware wraps tool execution; it may short-circuit by returning
+// without calling next
+type Middleware func(next ToolExecutor) ToolExecutor
+
+// ExecutorFunc adapts a function to ToolExecutor
+type ExecutorFunc func(ctx context.Context, name string, input map[string]any) (ToolResult, error)
+
+// Execute calls f
+func (f ExecutorFunc) Execute(ctx context.Context, name string, input map[string]any) (ToolResult, error) {
+	return f(ctx, name, input)
+}
+
+// LoggingMiddleware logs each execution with its duration and outcome
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next ToolExecutor) ToolExecutor {
+		return ExecutorFunc(func(ctx context.Context, name string, input map[string]any) (ToolResult, error) {
+			start := time.Now()
+			result, err := next.Execute(ctx, name, input)
+			elapsed := time.Since(start)
+			switch {
+			case err != nil:
+				logger.Warn("tool failed", "tool", name, "duration", elapsed, "error", err)
+			case result.IsError:
+				logger.Info("tool returned error", "tool", name, "duration", elapsed)
+			default:
+				logger.Debug("tool executed", "tool", name, "duration", elapsed)
+			}
+			return result, err
+		})
+	}
+}
+
+// RedactMiddleware passes result text through redact before it is returned,
+// so secrets in tool output never reach the model
+func RedactMiddleware(redact backend.Redactor) Middleware {
+	return func(next ToolExecutor) ToolExecutor {
+		return ExecutorFunc(func(ctx context.Context, name string, input map[string]any) (ToolResult, error) {
+			result, err := next.Execute(ctx, name, input)
+			result.Content = redact(result.Content)
+			result.Blocks = slices.Clone(result.Blocks)
+			for i := range result.Blocks {
+				result.Blocks[i].Text = redact(result.Blocks[i].Text)
+			}
+			return result, err
+		})
+	}
+}
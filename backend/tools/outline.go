@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// OutlineTool lists a file's declarations with line numbers so the agent can
+// find code without reading the whole file
+type OutlineTool struct{}
+
+// NewOutlineTool creates a new Outline tool
+func NewOutlineTool() *OutlineTool {
+	return &OutlineTool{}
+}
+
+// Name returns "Outline"
+func (o *OutlineTool) Name() string {
+	return "Outline"
+}
+
+// outlineEntry is one declaration in an outline
+type outlineEntry struct {
+	line int
+	text string
+}
+
+// Execute parses Go files with go/parser and other languages with a
+// declaration-keyword heuristic
+func (o *OutlineTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return ToolResult{Content: "file_path is required", IsError: true}, nil
+	}
+	filePath = resolvePath(ctx, filePath)
+	if err := confinePath(ctx, filePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	var entries []outlineEntry
+	parsed := false
+	if filepath.Ext(filePath) == ".go" {
+		// unparsable Go falls back to the heuristic
+		entries, err = goOutline(filePath, data)
+		parsed = err == nil
+	}
+	if !parsed {
+		entries = heuristicOutline(string(data))
+	}
+	if len(entries) == 0 {
+		return ToolResult{Content: "no declarations found"}, nil
+	}
+
+	var sb strings.Builder
+	for i, e := range entries {
+		fmt.Fprintf(&sb, "%d: %s", e.line, e.text)
+		if i < len(entries)-1 {
+			sb.WriteByte('\n')
+		}
+	}
+	return ToolResult{Content: sb.String()}, nil
+}
+
+// goOutline lists top-level funcs, methods, types, consts and vars
+func goOutline(path string, src []byte) ([]outlineEntry, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []outlineEntry
+	add := func(pos token.Pos, text string) {
+		entries = append(entries, outlineEntry{line: fset.Position(pos).Line, text: text})
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sig := strings.TrimPrefix(nodeString(fset, d.Type), "func")
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				add(d.Pos(), fmt.Sprintf("method (%s) %s%s", nodeString(fset, d.Recv.List[0].Type), d.Name.Name, sig))
+			} else {
+				add(d.Pos(), "func "+d.Name.Name+sig)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Pos(), "type "+s.Name.Name+" "+typeKind(fset, s))
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						add(name.Pos(), d.Tok.String()+" "+name.Name)
+					}
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// typeKind names a type declaration's underlying form; a defined type over
+// a named or pointer type shows that type, e.g. "string" or "*Node"
+func typeKind(fset *token.FileSet, s *ast.TypeSpec) string {
+	if s.Assign.IsValid() {
+		return "alias"
+	}
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return "array"
+		}
+		return "slice"
+	case *ast.ChanType:
+		return "chan"
+	}
+	return nodeString(fset, s.Type)
+}
+
+// nodeString prints node as Go source on one line
+func nodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// declPattern matches declaration lines in common languages: JS/TS, Python,
+// Rust, Java-like classes and shell functions
+var declPattern = regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:pub(?:\([^)]*\))?\s+)?(?:public\s+|private\s+|protected\s+)?(?:static\s+)?(?:abstract\s+)?(?:async\s+)?(?:class|interface|type|enum|function|def|fn|struct|trait|impl|module|func)\s+[A-Za-z_$]`)
+
+// maxOutlineLine caps how much of a matched line is shown
+const maxOutlineLine = 120
+
+// heuristicOutline lists lines that look like declarations, keeping their
+// indentation so nesting stays visible
+func heuristicOutline(src string) []outlineEntry {
+	var entries []outlineEntry
+	for i, line := range strings.Split(normalizeLineEndings(src), "\n") {
+		if !declPattern.MatchString(line) {
+			continue
+		}
+		text := strings.TrimRight(line, " \t{:")
+		if len(text) > maxOutlineLine {
+			text = text[:maxOutlineLine] + "..."
+		}
+		entries = append(entries, outlineEntry{line: i + 1, text: text})
+	}
+	return entries
+}
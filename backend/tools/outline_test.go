@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlineTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewOutlineTool()
+	a.Equal("Outline", tool.Name())
+}
+
+func TestOutlineTool_Execute_Go(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a Go file with funcs, a method, types and values
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shapes.go")
+	src := `package shapes
+
+import "math"
+
+const Pi = math.Pi
+
+// Shape has an area
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	R float64
+}
+
+func (c *Circle) Area() float64 {
+	return Pi * c.R * c.R
+}
+
+func NewCircle(r float64) *Circle {
+	return &Circle{R: r}
+}
+
+var unit = NewCircle(1)
+`
+	r.NoError(os.WriteFile(path, []byte(src), 0644))
+
+	tool := NewOutlineTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": path})
+
+	// then - each declaration with its line
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal([]string{
+		"5: const Pi",
+		"8: type Shape interface",
+		"12: type Circle struct",
+		"16: method (*Circle) Area() float64",
+		"20: func NewCircle(r float64) *Circle",
+		"24: var unit",
+	}, splitLines(result.Content))
+}
+
+func TestOutlineTool_Execute_GoTypeKinds(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - defined types, a real alias, and an array next to a slice
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kinds.go")
+	src := `package kinds
+
+import "time"
+
+type ID string
+
+type Stamp time.Time
+
+type Clock = time.Time
+
+type Digest [32]byte
+
+type IDs []ID
+`
+	r.NoError(os.WriteFile(path, []byte(src), 0644))
+
+	tool := NewOutlineTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": path})
+
+	// then - only the = form is an alias, and a fixed length is an array
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal([]string{
+		"5: type ID string",
+		"7: type Stamp time.Time",
+		"9: type Clock alias",
+		"11: type Digest array",
+		"13: type IDs slice",
+	}, splitLines(result.Content))
+}
+
+func TestOutlineTool_Execute_Heuristic(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a TypeScript file
+	dir := t.TempDir()
+	path := filepath.Join(dir, "api.ts")
+	src := "import x from 'y'\n\nexport interface Options {\n  id: string\n}\n\nexport async function load(opts: Options) {\n  return x\n}\n\nclass Cache {\n}\n"
+	r.NoError(os.WriteFile(path, []byte(src), 0644))
+
+	tool := NewOutlineTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": path})
+
+	// then - declaration lines by keyword
+	r.NoError(err)
+	a.Equal([]string{
+		"3: export interface Options",
+		"7: export async function load(opts: Options)",
+		"11: class Cache",
+	}, splitLines(result.Content))
+}
+
+func TestOutlineTool_Execute_BrokenGoFallsBack(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - Go that does not parse
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.go")
+	r.NoError(os.WriteFile(path, []byte("package x\n\nfunc Half(\n"), 0644))
+
+	tool := NewOutlineTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"file_path": path})
+
+	// then - the heuristic still finds the func
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal("3: func Half(", result.Content)
+}
+
+func TestOutlineTool_Execute_MissingPath(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	tool := NewOutlineTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{})
+
+	// then
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "file_path is required")
+}
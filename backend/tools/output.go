@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// OutputEmitter receives tool output as it is produced
+type OutputEmitter interface {
+	EmitOutput(ctx context.Context, chunk string)
+}
+
+// OutputEmitterFunc adapts a function to OutputEmitter
+type OutputEmitterFunc func(ctx context.Context, chunk string)
+
+// EmitOutput calls f
+func (f OutputEmitterFunc) EmitOutput(ctx context.Context, chunk string) {
+	f(ctx, chunk)
+}
+
+type outputSinkKey struct{}
+
+// WithOutputSink returns a context whose tool output is delivered to sink.
+// Callers use it to route chunks from a shared tool to the right session.
+func WithOutputSink(ctx context.Context, sink func(chunk string)) context.Context {
+	return context.WithValue(ctx, outputSinkKey{}, sink)
+}
+
+// ContextOutputEmitter forwards output to the sink set by WithOutputSink
+type ContextOutputEmitter struct{}
+
+// EmitOutput sends chunk to the context's sink, if any
+func (ContextOutputEmitter) EmitOutput(ctx context.Context, chunk string) {
+	if sink, ok := ctx.Value(outputSinkKey{}).(func(string)); ok {
+		sink(chunk)
+	}
+}
+
+// streamWriter copies output into buf while emitting each write
+type streamWriter struct {
+	ctx     context.Context
+	emitter OutputEmitter
+	buf     *bytes.Buffer
+	mu      sync.Mutex
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	w.emitter.EmitOutput(w.ctx, string(p))
+	return len(p), nil
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"time"
+)
+
+// Progress is a running search's counts so far
+type Progress struct {
+	FilesScanned int
+	Matches      int
+}
+
+type progressSinkKey struct{}
+
+// WithProgressSink returns a context whose searching tools periodically
+// report progress to sink. Callers use it to tie reports to a tool call.
+func WithProgressSink(ctx context.Context, sink func(Progress)) context.Context {
+	return context.WithValue(ctx, progressSinkKey{}, sink)
+}
+
+// progressInterval throttles reports from one tool call
+const progressInterval = 250 * time.Millisecond
+
+// progressReporter counts a search's files and matches, reporting at most
+// once per progressInterval; a nil reporter counts nothing
+type progressReporter struct {
+	sink func(Progress)
+	now  Progress
+	last time.Time
+}
+
+// newProgressReporter returns a reporter for ctx's sink, or nil without one
+func newProgressReporter(ctx context.Context) *progressReporter {
+	sink, ok := ctx.Value(progressSinkKey{}).(func(Progress))
+	if !ok {
+		return nil
+	}
+	return &progressReporter{sink: sink, last: time.Now()}
+}
+
+// scanned records one file and its matches, reporting if the interval passed
+func (r *progressReporter) scanned(matches int) {
+	if r == nil {
+		return
+	}
+	r.now.FilesScanned++
+	r.now.Matches += matches
+	if time.Since(r.last) >= progressInterval {
+		r.last = time.Now()
+		r.sink(r.now)
+	}
+}
+
+// finish reports the final counts
+func (r *progressReporter) finish() {
+	if r != nil {
+		r.sink(r.now)
+	}
+}
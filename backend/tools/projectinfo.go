@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxProjectEntries caps the top-level listing of a very flat directory
+const maxProjectEntries = 200
+
+// ProjectInfoTool summarizes a project directory (git branch, Go module,
+// languages, frameworks and top-level entries) so the agent can orient
+// itself in one call
+type ProjectInfoTool struct{}
+
+// NewProjectInfoTool creates a new ProjectInfo tool
+func NewProjectInfoTool() *ProjectInfoTool {
+	return &ProjectInfoTool{}
+}
+
+// Name returns "ProjectInfo"
+func (p *ProjectInfoTool) Name() string {
+	return "ProjectInfo"
+}
+
+// ProjectInfo is the ProjectInfo tool's JSON result
+type ProjectInfo struct {
+	Root       string   `json:"root"`
+	Branch     string   `json:"branch,omitempty"`  // empty outside a git repository
+	Changes    int      `json:"changes,omitempty"` // staged, unstaged and untracked paths
+	Module     string   `json:"module,omitempty"`  // go.mod module path
+	GoVersion  string   `json:"goVersion,omitempty"`
+	Languages  []string `json:"languages"`
+	Frameworks []string `json:"frameworks"`
+	Entries    []string `json:"entries"` // top-level files; directories end in "/"
+	Truncated  bool     `json:"truncated,omitempty"`
+}
+
+// projectMarkers maps top-level files (or globs) to the language they signal
+var projectMarkers = []struct {
+	pattern, language string
+}{
+	{"go.mod", "Go"},
+	{"package.json", "JavaScript"},
+	{"tsconfig.json", "TypeScript"},
+	{"Cargo.toml", "Rust"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"setup.py", "Python"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java"},
+	{"build.gradle.kts", "Kotlin"},
+	{"Gemfile", "Ruby"},
+	{"composer.json", "PHP"},
+	{"*.csproj", "C#"},
+	{"CMakeLists.txt", "C/C++"},
+	{"mix.exs", "Elixir"},
+}
+
+// goFrameworks maps go.mod requirements to framework names
+var goFrameworks = map[string]string{
+	"github.com/wailsapp/wails/v2": "Wails",
+	"github.com/gin-gonic/gin":     "Gin",
+	"github.com/labstack/echo/v4":  "Echo",
+	"github.com/gofiber/fiber/v2":  "Fiber",
+	"github.com/go-chi/chi/v5":     "Chi",
+	"github.com/spf13/cobra":       "Cobra",
+	"github.com/mark3labs/mcp-go":  "MCP",
+	"google.golang.org/grpc":       "gRPC",
+	"github.com/gorilla/mux":       "Gorilla",
+}
+
+// jsFrameworks maps package.json dependencies to framework names
+var jsFrameworks = map[string]string{
+	"react":         "React",
+	"svelte":        "Svelte",
+	"vue":           "Vue",
+	"next":          "Next.js",
+	"nuxt":          "Nuxt",
+	"@angular/core": "Angular",
+	"vite":          "Vite",
+	"express":       "Express",
+	"tailwindcss":   "Tailwind CSS",
+	"vitest":        "Vitest",
+	"jest":          "Jest",
+}
+
+// Execute reports on path, defaulting to the session's directory
+func (p *ProjectInfoTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	dir, err := gitDir(ctx, input)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	info := ProjectInfo{Root: dir, Languages: []string{}, Frameworks: []string{}, Entries: []string{}}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			if excludedDir(name, DefaultExcludeDirs) {
+				continue
+			}
+			name += "/"
+		}
+		if len(info.Entries) == maxProjectEntries {
+			info.Truncated = true
+			break
+		}
+		info.Entries = append(info.Entries, name)
+	}
+
+	for _, m := range projectMarkers {
+		if matches, _ := filepath.Glob(filepath.Join(dir, m.pattern)); len(matches) > 0 && !slices.Contains(info.Languages, m.language) {
+			info.Languages = append(info.Languages, m.language)
+		}
+	}
+	addFramework := func(name string) {
+		if name != "" && !slices.Contains(info.Frameworks, name) {
+			info.Frameworks = append(info.Frameworks, name)
+		}
+	}
+	var requires []string
+	info.Module, info.GoVersion, requires = parseGoMod(filepath.Join(dir, "go.mod"))
+	for _, req := range requires {
+		addFramework(goFrameworks[req])
+	}
+	// Wails and similar layouts keep the web app in frontend/
+	for _, pkg := range []string{"package.json", filepath.Join("frontend", "package.json")} {
+		for _, dep := range packageDeps(filepath.Join(dir, pkg)) {
+			addFramework(jsFrameworks[dep])
+		}
+	}
+
+	// git is optional; a plain directory still gets the rest of the report
+	if _, err := exec.LookPath("git"); err == nil {
+		if out, err := runGit(ctx, dir, "status", "--porcelain=v1", "-z", "--branch", "--untracked-files=normal"); err == nil {
+			status := ParseGitStatus(out)
+			info.Branch = branchName(status.Branch)
+			info.Changes = len(status.Staged) + len(status.Unstaged) + len(status.Untracked) + len(status.Conflicted)
+		}
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+	return ToolResult{Content: string(data)}, nil
+}
+
+// branchName extracts the local branch from a git status --branch header such
+// as "main...origin/main [ahead 1]" or "No commits yet on main"
+func branchName(header string) string {
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	header = strings.TrimPrefix(header, "Initial commit on ")
+	if i := strings.Index(header, "..."); i >= 0 {
+		header = header[:i]
+	}
+	if i := strings.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	return header
+}
+
+// parseGoMod returns the module path, go version and required module paths
+// of a go.mod file; a missing or unreadable file yields zero values
+func parseGoMod(path string) (module, goVersion string, requires []string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", nil
+	}
+	defer f.Close()
+	inRequire := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inRequire && fields[0] == ")":
+			inRequire = false
+		case inRequire:
+			requires = append(requires, fields[0])
+		case fields[0] == "module" && len(fields) > 1:
+			module = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) > 1:
+			goVersion = fields[1]
+		case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) > 1:
+			requires = append(requires, fields[1])
+		}
+	}
+	return module, goVersion, requires
+}
+
+// packageDeps returns the dependency and devDependency names of a
+// package.json, sorted
+func packageDeps(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]any `json:"dependencies"`
+		DevDependencies map[string]any `json:"devDependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	var deps []string
+	for name := range pkg.Dependencies {
+		deps = append(deps, name)
+	}
+	for name := range pkg.DevDependencies {
+		deps = append(deps, name)
+	}
+	slices.Sort(deps)
+	return deps
+}
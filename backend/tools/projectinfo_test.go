@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectInfoTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewProjectInfoTool()
+	a.Equal("ProjectInfo", tool.Name())
+}
+
+func TestProjectInfoTool_Execute_GoProject(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a Go module with a Svelte frontend on branch main
+	dir := gitRepo(t)
+	goMod := "module example.com/widget\n\ngo 1.23\n\nrequire (\n\tgithub.com/wailsapp/wails/v2 v2.10.1 // indirect\n)\n"
+	r.NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644))
+	r.NoError(os.MkdirAll(filepath.Join(dir, "frontend"), 0755))
+	r.NoError(os.WriteFile(filepath.Join(dir, "frontend", "package.json"), []byte(`{"devDependencies":{"svelte":"^3"}}`), 0644))
+	r.NoError(os.MkdirAll(filepath.Join(dir, "node_modules"), 0755))
+
+	tool := NewProjectInfoTool()
+
+	// when - run in the session's directory
+	result, err := tool.Execute(WithWorkDir(context.Background(), dir), map[string]any{})
+
+	// then - module, branch and layout are reported, excluded dirs are not
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	var info ProjectInfo
+	r.NoError(json.Unmarshal([]byte(result.Content), &info))
+	a.Equal("example.com/widget", info.Module)
+	a.Equal("1.23", info.GoVersion)
+	a.Equal("main", info.Branch)
+	a.Equal(2, info.Changes) // go.mod and frontend/package.json are untracked
+	a.Equal([]string{"Go"}, info.Languages)
+	a.Equal([]string{"Wails", "Svelte"}, info.Frameworks)
+	a.ElementsMatch([]string{"frontend/", "go.mod", "tracked.txt"}, info.Entries)
+}
+
+func TestProjectInfoTool_Execute_NotARepo(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a plain directory
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]\n"), 0644))
+
+	// when
+	result, err := NewProjectInfoTool().Execute(context.Background(), map[string]any{"path": dir})
+
+	// then - the rest of the report still comes back
+	r.NoError(err)
+	r.False(result.IsError, result.Content)
+	var info ProjectInfo
+	r.NoError(json.Unmarshal([]byte(result.Content), &info))
+	a.Empty(info.Branch)
+	a.Equal([]string{"Rust"}, info.Languages)
+	a.Equal([]string{"Cargo.toml"}, info.Entries)
+}
+
+func TestBranchName(t *testing.T) {
+	tests := []struct{ header, want string }{
+		{"main", "main"},
+		{"main...origin/main [ahead 1]", "main"},
+		{"No commits yet on dev", "dev"},
+		{"HEAD (no branch)", "HEAD"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, branchName(tt.header), tt.header)
+	}
+}
@@ -27,6 +27,10 @@ func (r *ReadTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 	if !ok || filePath == "" {
 		return ToolResult{Content: "file_path is required", IsError: true}, nil
 	}
+	filePath = resolvePath(ctx, filePath)
+	if err := confinePath(ctx, filePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
 
 	// extract optional offset (1-indexed line number)
 	offset := 1
@@ -51,8 +55,9 @@ func (r *ReadTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 		return ToolResult{Content: ""}, nil
 	}
 
-	// split into lines
-	content := string(data)
+	// split into lines, normalizing CRLF for display
+	ending := detectLineEnding(string(data))
+	content := normalizeLineEndings(string(data))
 	lines := strings.Split(content, "\n")
 
 	// handle trailing newline - don't count empty line after final \n
@@ -84,6 +89,9 @@ func (r *ReadTool) Execute(ctx context.Context, input map[string]any) (ToolResul
 
 	// trim final newline for cleaner output
 	result := strings.TrimSuffix(sb.String(), "\n")
+	if ending != lineEndingLF {
+		result += fmt.Sprintf("\n\n(line endings: %s)", lineEndingName(ending))
+	}
 
 	return ToolResult{Content: result}, nil
 }
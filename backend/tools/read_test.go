@@ -200,3 +200,25 @@ func TestReadTool_Execute_OffsetBeyondFile(t *testing.T) {
 	a.False(result.IsError)
 	a.Equal("", result.Content)
 }
+
+func TestReadTool_Execute_CRLF(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - CRLF file
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crlf.txt")
+	r.NoError(os.WriteFile(path, []byte("first\r\nsecond\r\n"), 0644))
+
+	tool := NewReadTool()
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path": path,
+	})
+
+	// then - carriage returns stripped, ending reported
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Equal("1\tfirst\n2\tsecond\n\n(line endings: CRLF)", result.Content)
+}
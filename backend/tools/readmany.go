@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Limits for one ReadMany call; past them the remaining files are listed
+// as skipped so the agent can Read them individually
+const (
+	defaultReadManyBytes = 256 * 1024
+	maxReadManyFiles     = 50
+)
+
+// ReadManyTool reads several files in one call, each under a header and
+// numbered like Read
+type ReadManyTool struct {
+	read     *ReadTool
+	maxBytes int // total output cap
+}
+
+// NewReadManyTool creates a new ReadMany tool
+func NewReadManyTool() *ReadManyTool {
+	return &ReadManyTool{read: NewReadTool(), maxBytes: defaultReadManyBytes}
+}
+
+// Name returns "ReadMany"
+func (r *ReadManyTool) Name() string {
+	return "ReadMany"
+}
+
+// Execute reads each file_paths entry, expanding globs, until the size cap
+func (r *ReadManyTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	raw, _ := input["file_paths"].([]any)
+	var patterns []string
+	for _, v := range raw {
+		if s, ok := v.(string); ok && s != "" {
+			patterns = append(patterns, s)
+		}
+	}
+	if len(patterns) == 0 {
+		return ToolResult{Content: "file_paths is required", IsError: true}, nil
+	}
+
+	paths, err := expandReadPaths(ctx, patterns)
+	if err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	var sb strings.Builder
+	var skipped []string
+	for i, path := range paths {
+		if ctx.Err() != nil {
+			return ToolResult{}, ctx.Err()
+		}
+		if i >= maxReadManyFiles {
+			skipped = append(skipped, paths[i:]...)
+			break
+		}
+		result, err := r.read.Execute(ctx, map[string]any{"file_path": path})
+		if err != nil {
+			return ToolResult{}, err
+		}
+		section := fmt.Sprintf("==> %s <==\n", path)
+		if result.IsError {
+			section += "error: "
+		}
+		section += result.Content + "\n\n"
+		if sb.Len()+len(section) > r.maxBytes {
+			if sb.Len() > 0 {
+				skipped = append(skipped, paths[i:]...)
+				break
+			}
+			// a lone oversized file is cut rather than dropped
+			section = strings.ToValidUTF8(section[:r.maxBytes], "") + "\n(truncated; Read with offset and limit for the rest)\n\n"
+		}
+		sb.WriteString(section)
+	}
+
+	content := strings.TrimSuffix(sb.String(), "\n\n")
+	if len(skipped) > 0 {
+		content += fmt.Sprintf("\n\n(output limit reached; not read: %s)", strings.Join(skipped, ", "))
+	}
+	return ToolResult{Content: content}, nil
+}
+
+// expandReadPaths resolves patterns to files in order, without duplicates.
+// Plain paths pass through so Read reports their errors; globs expand
+// against the session's directory and must match something.
+func expandReadPaths(ctx context.Context, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[{") {
+			add(pattern)
+			continue
+		}
+		pattern = resolvePath(ctx, pattern)
+		matches, err := doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", pattern)
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return paths, nil
+}
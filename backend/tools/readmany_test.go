@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManyTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewReadManyTool()
+	a.Equal("ReadMany", tool.Name())
+}
+
+func TestReadManyTool_Execute_ThreeFiles(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - three files
+	dir := t.TempDir()
+	var paths []any
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		r.NoError(os.WriteFile(path, []byte("first "+name+"\nsecond "+name+"\n"), 0644))
+		paths = append(paths, path)
+	}
+
+	// when
+	result, err := NewReadManyTool().Execute(context.Background(), map[string]any{"file_paths": paths})
+
+	// then - each file appears in order under its header with line numbers
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	last := -1
+	for _, p := range paths {
+		path := p.(string)
+		name := filepath.Base(path)
+		idx := strings.Index(result.Content, "==> "+path+" <==\n1\tfirst "+name+"\n2\tsecond "+name)
+		a.Greater(idx, last, "missing or out of order: %s", path)
+		last = idx
+	}
+}
+
+func TestReadManyTool_Execute_GlobRelativeToWorkDir(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - matches in a nested directory and a non-match
+	dir := t.TempDir()
+	r.NoError(os.MkdirAll(filepath.Join(dir, "pkg"), 0755))
+	r.NoError(os.WriteFile(filepath.Join(dir, "pkg", "x.go"), []byte("package pkg\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644))
+	r.NoError(os.WriteFile(filepath.Join(dir, "notes.md"), []byte("notes\n"), 0644))
+
+	// when - a glob plus a path it already covers
+	ctx := WithWorkDir(context.Background(), dir)
+	result, err := NewReadManyTool().Execute(ctx, map[string]any{
+		"file_paths": []any{"**/*.go", filepath.Join(dir, "main.go")},
+	})
+
+	// then - each match once, the markdown file not at all
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	a.Equal(1, strings.Count(result.Content, "main.go <=="))
+	a.Contains(result.Content, filepath.Join(dir, "pkg", "x.go")+" <==")
+	a.NotContains(result.Content, "notes")
+}
+
+func TestReadManyTool_Execute_SizeCap(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - two files that together exceed a small cap
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.txt"), filepath.Join(dir, "second.txt")
+	r.NoError(os.WriteFile(first, []byte(strings.Repeat("x", 60)+"\n"), 0644))
+	r.NoError(os.WriteFile(second, []byte(strings.Repeat("y", 60)+"\n"), 0644))
+	tool := NewReadManyTool()
+	tool.maxBytes = 120
+
+	// when
+	result, err := tool.Execute(context.Background(), map[string]any{"file_paths": []any{first, second}})
+
+	// then - the second file is named, not read
+	r.NoError(err)
+	a.Contains(result.Content, "==> "+first+" <==")
+	a.NotContains(result.Content, "yyy")
+	a.Contains(result.Content, "not read: "+second)
+}
+
+func TestReadManyTool_Execute_MissingFile(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - one existing file and one missing
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "real.txt")
+	r.NoError(os.WriteFile(existing, []byte("hello\n"), 0644))
+	missing := filepath.Join(dir, "missing.txt")
+
+	// when
+	result, err := NewReadManyTool().Execute(context.Background(), map[string]any{"file_paths": []any{missing, existing}})
+
+	// then - the error is reported inline and the rest still read
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Contains(result.Content, "==> "+missing+" <==\nerror: ")
+	a.Contains(result.Content, "1\thello")
+}
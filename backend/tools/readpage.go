@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	defaultPageSize = 500
+	maxPageSize     = 5000
+)
+
+// FileLine is one numbered line of a file
+type FileLine struct {
+	Number int    `json:"number"` // 1-indexed
+	Text   string `json:"text"`
+}
+
+// FilePage is a window of a file's lines
+type FilePage struct {
+	Page     int        `json:"page"`
+	PageSize int        `json:"pageSize"`
+	Lines    []FileLine `json:"lines"`
+	HasMore  bool       `json:"hasMore"`
+}
+
+// ReadPage returns the 1-indexed page of path's lines, streaming the file so
+// only the requested lines are held in memory. pageSize <= 0 uses 500 and is
+// capped at 5000. A page past the end returns no lines.
+func ReadPage(ctx context.Context, path string, page, pageSize int) (FilePage, error) {
+	if page < 1 {
+		return FilePage{}, fmt.Errorf("page must be at least 1, got %d", page)
+	}
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return FilePage{}, err
+	}
+	defer f.Close()
+
+	result := FilePage{Page: page, PageSize: pageSize, Lines: []FileLine{}}
+	reader := bufio.NewReader(f)
+	start := (page - 1) * pageSize
+
+	// skip earlier lines without keeping them
+	for n := 0; n < start; n++ {
+		if n%1000 == 0 && ctx.Err() != nil {
+			return FilePage{}, ctx.Err()
+		}
+		if err := skipLine(reader); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return FilePage{}, err
+		}
+	}
+
+	for len(result.Lines) < pageSize {
+		line, err := reader.ReadString('\n')
+		if line == "" && err == io.EOF {
+			return result, nil
+		}
+		if err != nil && err != io.EOF {
+			return FilePage{}, err
+		}
+		result.Lines = append(result.Lines, FileLine{
+			Number: start + len(result.Lines) + 1,
+			Text:   strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"),
+		})
+		if err == io.EOF {
+			return result, nil
+		}
+	}
+
+	_, err = reader.Peek(1)
+	result.HasMore = err == nil
+	return result, nil
+}
+
+// skipLine consumes through the next newline; io.EOF means no line remained
+func skipLine(reader *bufio.Reader) error {
+	read := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err == io.EOF && read {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLines writes n lines "line 1".."line n" and returns the path
+func writeLines(t *testing.T, n int, ending string) string {
+	t.Helper()
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "line %d%s", i, ending)
+	}
+	path := filepath.Join(t.TempDir(), "big.txt")
+	require.NoError(t, os.WriteFile(path, []byte(sb.String()), 0644))
+	return path
+}
+
+func TestReadPage_FirstPage(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - 25 lines
+	path := writeLines(t, 25, "\n")
+
+	// when
+	page, err := ReadPage(context.Background(), path, 1, 10)
+
+	// then - lines 1-10 with more remaining
+	r.NoError(err)
+	r.Len(page.Lines, 10)
+	a.Equal(FileLine{Number: 1, Text: "line 1"}, page.Lines[0])
+	a.Equal(FileLine{Number: 10, Text: "line 10"}, page.Lines[9])
+	a.True(page.HasMore)
+}
+
+func TestReadPage_MiddleAndLastPage(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - 25 CRLF lines
+	path := writeLines(t, 25, "\r\n")
+
+	// when
+	middle, err := ReadPage(context.Background(), path, 2, 10)
+	r.NoError(err)
+	last, err := ReadPage(context.Background(), path, 3, 10)
+	r.NoError(err)
+
+	// then - middle page continues numbering, last page is partial
+	r.Len(middle.Lines, 10)
+	a.Equal(FileLine{Number: 11, Text: "line 11"}, middle.Lines[0])
+	a.True(middle.HasMore)
+	r.Len(last.Lines, 5)
+	a.Equal(FileLine{Number: 25, Text: "line 25"}, last.Lines[4])
+	a.False(last.HasMore)
+}
+
+func TestReadPage_ExactMultipleHasNoMore(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - 20 lines, last one without a trailing newline
+	path := writeLines(t, 20, "\n")
+	data, _ := os.ReadFile(path)
+	r.NoError(os.WriteFile(path, []byte(strings.TrimSuffix(string(data), "\n")), 0644))
+
+	// when
+	page, err := ReadPage(context.Background(), path, 2, 10)
+
+	// then
+	r.NoError(err)
+	r.Len(page.Lines, 10)
+	a.Equal("line 20", page.Lines[9].Text)
+	a.False(page.HasMore)
+}
+
+func TestReadPage_PastEnd(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - 5 lines
+	path := writeLines(t, 5, "\n")
+
+	// when
+	page, err := ReadPage(context.Background(), path, 4, 10)
+
+	// then - empty page, not an error
+	r.NoError(err)
+	a.Empty(page.Lines)
+	a.NotNil(page.Lines)
+	a.False(page.HasMore)
+}
+
+func TestReadPage_InvalidInput(t *testing.T) {
+	a := assert.New(t)
+
+	path := writeLines(t, 5, "\n")
+
+	_, err := ReadPage(context.Background(), path, 0, 10)
+	a.Error(err)
+
+	_, err = ReadPage(context.Background(), filepath.Join(t.TempDir(), "missing.txt"), 1, 10)
+	a.Error(err)
+
+	page, err := ReadPage(context.Background(), path, 1, 0)
+	a.NoError(err)
+	a.Equal(defaultPageSize, page.PageSize)
+}
+
+func TestReadPage_Cancelled(t *testing.T) {
+	a := assert.New(t)
+
+	// given - a cancelled context and a page that needs skipping
+	path := writeLines(t, 50, "\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, err := ReadPage(ctx, path, 3, 10)
+
+	// then
+	a.ErrorIs(err, context.Canceled)
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// toolStatSamples bounds the durations kept per tool for percentiles
+const toolStatSamples = 1024
+
+// ToolStat summarizes executions of one tool
+type ToolStat struct {
+	Calls   int     `json:"calls"`
+	Errors  int     `json:"errors"` // Go errors and IsError results
+	TotalMs float64 `json:"totalMs"`
+	P50Ms   float64 `json:"p50Ms"` // over the most recent executions
+	P95Ms   float64 `json:"p95Ms"`
+	MaxMs   float64 `json:"maxMs"`
+}
+
+// toolStats accumulates counters and recent durations for one tool
+type toolStats struct {
+	calls   int
+	errors  int
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration // ring of recent durations
+	next    int
+}
+
+func (s *toolStats) record(d time.Duration, failed bool) {
+	s.calls++
+	if failed {
+		s.errors++
+	}
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+	if len(s.samples) < toolStatSamples {
+		s.samples = append(s.samples, d)
+		return
+	}
+	s.samples[s.next] = d
+	s.next = (s.next + 1) % toolStatSamples
+}
+
+func (s *toolStats) snapshot() ToolStat {
+	sorted := append([]time.Duration(nil), s.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return ToolStat{
+		Calls:   s.calls,
+		Errors:  s.errors,
+		TotalMs: millis(s.total),
+		P50Ms:   millis(percentile(sorted, 50)),
+		P95Ms:   millis(percentile(sorted, 95)),
+		MaxMs:   millis(s.max),
+	}
+}
+
+// percentile returns the nearest-rank pth percentile of sorted
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// statsRecorder tracks toolStats by tool name
+type statsRecorder struct {
+	mu    sync.Mutex
+	tools map[string]*toolStats
+}
+
+func (r *statsRecorder) record(name string, d time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tools == nil {
+		r.tools = make(map[string]*toolStats)
+	}
+	s, ok := r.tools[name]
+	if !ok {
+		s = &toolStats{}
+		r.tools[name] = s
+	}
+	s.record(d, failed)
+}
+
+func (r *statsRecorder) snapshot() map[string]ToolStat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make(map[string]ToolStat, len(r.tools))
+	for name, s := range r.tools {
+		result[name] = s.snapshot()
+	}
+	return result
+}
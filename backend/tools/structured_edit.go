@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ccui/backend"
+	"gopkg.in/yaml.v3"
+)
+
+// StructuredEditTool sets a value by key path in a JSON or YAML file.
+// Documents are parsed into yaml.Node trees so key order survives the edit.
+type StructuredEditTool struct{}
+
+// NewStructuredEditTool creates a new StructuredEdit tool
+func NewStructuredEditTool() *StructuredEditTool {
+	return &StructuredEditTool{}
+}
+
+// Name returns "StructuredEdit"
+func (s *StructuredEditTool) Name() string {
+	return "StructuredEdit"
+}
+
+// Execute sets key_path to value in file_path and writes the document back
+func (s *StructuredEditTool) Execute(ctx context.Context, input map[string]any) (ToolResult, error) {
+	// extract file_path (required)
+	filePath, ok := input["file_path"].(string)
+	if !ok || filePath == "" {
+		return ToolResult{Content: "file_path is required", IsError: true}, nil
+	}
+	filePath = resolvePath(ctx, filePath)
+	if err := confinePath(ctx, filePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
+
+	// extract key_path (required)
+	keyPath, ok := input["key_path"].(string)
+	if !ok || keyPath == "" {
+		return ToolResult{Content: "key_path is required", IsError: true}, nil
+	}
+
+	// extract value (required, may be null)
+	rawValue, ok := input["value"]
+	if !ok {
+		return ToolResult{Content: "value is required", IsError: true}, nil
+	}
+
+	isJSON := false
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		isJSON = true
+	case ".yaml", ".yml":
+	default:
+		return ToolResult{Content: fmt.Sprintf("unsupported file type: %s (expected .json, .yaml or .yml)", filePath), IsError: true}, nil
+	}
+
+	// read and parse file
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ToolResult{Content: fmt.Sprintf("failed to read file: %s", err), IsError: true}, nil
+	}
+	oldContent := string(data)
+
+	// only one document is written back, so a multi-document stream is refused
+	// rather than truncated
+	var doc yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&doc); err != nil && err != io.EOF {
+		return ToolResult{Content: fmt.Sprintf("failed to parse %s: %s", filePath, err), IsError: true}, nil
+	}
+	var next yaml.Node
+	if err := dec.Decode(&next); err != io.EOF {
+		if err != nil {
+			return ToolResult{Content: fmt.Sprintf("failed to parse %s: %s", filePath, err), IsError: true}, nil
+		}
+		return ToolResult{Content: fmt.Sprintf("%s holds multiple YAML documents; StructuredEdit edits single-document files only", filePath), IsError: true}, nil
+	}
+	if doc.Kind == 0 {
+		// empty file starts as an empty object
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	value, err := valueNode(rawValue)
+	if err != nil {
+		return ToolResult{Content: fmt.Sprintf("invalid value: %s", err), IsError: true}, nil
+	}
+	if err := setPath(doc.Content[0], strings.Split(keyPath, "."), value); err != nil {
+		return ToolResult{Content: fmt.Sprintf("failed to set %s: %s", keyPath, err), IsError: true}, nil
+	}
+
+	// encode with the file's own conventions
+	var newContent string
+	if isJSON {
+		var buf bytes.Buffer
+		writeJSONNode(&buf, doc.Content[0], detectIndent(oldContent), 0)
+		buf.WriteByte('\n')
+		newContent = buf.String()
+	} else {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return ToolResult{Content: fmt.Sprintf("failed to encode yaml: %s", err), IsError: true}, nil
+		}
+		enc.Close()
+		newContent = buf.String()
+	}
+
+	if newContent == oldContent {
+		return ToolResult{Content: fmt.Sprintf("%s already has this value; no change needed", keyPath)}, nil
+	}
+
+	// write file
+	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
+		return ToolResult{Content: fmt.Sprintf("failed to write file: %s", err), IsError: true}, nil
+	}
+
+	return ToolResult{
+		Content:    fmt.Sprintf("set %s in %s", keyPath, filePath),
+		FilePath:   filePath,
+		OldContent: oldContent,
+		NewContent: newContent,
+		Hunks:      backend.BuildHunksWithContext(oldContent, newContent, DiffContext(ctx)),
+	}, nil
+}
+
+// valueNode converts a tool input value to a node. Strings holding valid JSON
+// are decoded, so "8080" sets a number and "\"8080\"" sets a string.
+func valueNode(v any) (*yaml.Node, error) {
+	if str, ok := v.(string); ok {
+		if !json.Valid([]byte(str)) {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: str}, nil
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(str), &doc); err != nil {
+			return nil, err
+		}
+		clearStyle(doc.Content[0])
+		return doc.Content[0], nil
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// clearStyle drops JSON quoting and flow style so YAML output uses block style
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// setPath walks dot-separated keys, creating missing objects, and sets the
+// final key. Numeric segments index into arrays.
+func setPath(node *yaml.Node, keys []string, value *yaml.Node) error {
+	key := keys[0]
+	last := len(keys) == 1
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != key {
+				continue
+			}
+			if last {
+				node.Content[i+1] = value
+				return nil
+			}
+			return setPath(node.Content[i+1], keys[1:], value)
+		}
+		child := value
+		if !last {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		if last {
+			return nil
+		}
+		return setPath(child, keys[1:], value)
+
+	case yaml.SequenceNode:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(node.Content) {
+			return fmt.Errorf("index %q out of range for array of length %d", key, len(node.Content))
+		}
+		if last {
+			node.Content[idx] = value
+			return nil
+		}
+		return setPath(node.Content[idx], keys[1:], value)
+
+	default:
+		return fmt.Errorf("%q is not inside an object or array", key)
+	}
+}
+
+// detectIndent returns the indentation of the first indented line, or two spaces
+func detectIndent(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// writeJSONNode writes node as indented JSON, keeping mapping order
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node, indent string, depth int) {
+	newline := func(d int) {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(indent, d))
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			writeJSONString(buf, node.Content[i].Value)
+			buf.WriteString(": ")
+			writeJSONNode(buf, node.Content[i+1], indent, depth+1)
+		}
+		newline(depth)
+		buf.WriteByte('}')
+
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			writeJSONNode(buf, item, indent, depth+1)
+		}
+		newline(depth)
+		buf.WriteByte(']')
+
+	case yaml.AliasNode:
+		writeJSONNode(buf, node.Alias, indent, depth)
+
+	default:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			writeJSONString(buf, node.Value)
+		}
+	}
+}
+
+// writeJSONString writes s as a JSON string without json.Marshal's HTML
+// escaping, so untouched values like "a && b" round-trip unchanged
+func writeJSONString(buf *bytes.Buffer, s string) {
+	var str bytes.Buffer
+	enc := json.NewEncoder(&str)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Write(bytes.TrimSuffix(str.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredEditTool_Name(t *testing.T) {
+	a := assert.New(t)
+	tool := NewStructuredEditTool()
+	a.Equal("StructuredEdit", tool.Name())
+}
+
+func TestStructuredEditTool_Execute_NestedJSONKey(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a JSON config with nested objects
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	original := "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 80,\n        \"host\": \"localhost\"\n    },\n    \"tags\": [\"a\", \"b\"]\n}\n"
+	r.NoError(os.WriteFile(path, []byte(original), 0644))
+
+	tool := NewStructuredEditTool()
+
+	// when - set a nested key
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"key_path":  "server.port",
+		"value":     "8080",
+	})
+
+	// then - only that value changes, with key order and indent preserved
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	expected := "{\n    \"name\": \"app\",\n    \"server\": {\n        \"port\": 8080,\n        \"host\": \"localhost\"\n    },\n    \"tags\": [\n        \"a\",\n        \"b\"\n    ]\n}\n"
+	a.Equal(expected, string(data))
+	a.Equal(path, result.FilePath)
+	a.Equal(original, result.OldContent)
+	a.Equal(expected, result.NewContent)
+	r.Len(result.Hunks, 1)
+	a.Contains(result.Hunks[0].Lines, "-        \"port\": 80,")
+	a.Contains(result.Hunks[0].Lines, "+        \"port\": 8080,")
+}
+
+func TestStructuredEditTool_Execute_KeepsHTMLCharacters(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a package.json whose untouched values hold &, < and >
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	original := "{\n  \"version\": \"1.0.0\",\n  \"scripts\": {\n    \"build\": \"tsc && vite build\",\n    \"<check>\": \"a > b\"\n  }\n}\n"
+	r.NoError(os.WriteFile(path, []byte(original), 0644))
+
+	// when
+	result, err := NewStructuredEditTool().Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"key_path":  "version",
+		"value":     "\"1.1.0\"",
+	})
+
+	// then - only the edited line differs
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal(strings.Replace(original, "1.0.0", "1.1.0", 1), string(data))
+}
+
+func TestStructuredEditTool_Execute_CreatesMissingKeys(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a JSON document without the target object
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	r.NoError(os.WriteFile(path, []byte("{\"a\": 1}\n"), 0644))
+
+	tool := NewStructuredEditTool()
+
+	// when - set a path through missing objects with a plain string value
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"key_path":  "b.c",
+		"value":     "hello",
+	})
+
+	// then - intermediate objects are created
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("{\n  \"a\": 1,\n  \"b\": {\n    \"c\": \"hello\"\n  }\n}\n", string(data))
+}
+
+func TestStructuredEditTool_Execute_YAML(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a YAML file with a list
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	r.NoError(os.WriteFile(path, []byte("name: app\nitems:\n  - one\n  - two\n"), 0644))
+
+	tool := NewStructuredEditTool()
+
+	// when - set an array element
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"key_path":  "items.1",
+		"value":     "\"three\"",
+	})
+
+	// then - element replaced and layout kept
+	r.NoError(err)
+	a.False(result.IsError, result.Content)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("name: app\nitems:\n  - one\n  - three\n", string(data))
+}
+
+func TestStructuredEditTool_Execute_RejectsMultiDocumentYAML(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a YAML stream with two documents
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifests.yaml")
+	original := "a: 1\n---\nb: 2\n"
+	r.NoError(os.WriteFile(path, []byte(original), 0644))
+
+	// when
+	result, err := NewStructuredEditTool().Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"key_path":  "a",
+		"value":     "3",
+	})
+
+	// then - refused, and no document is lost
+	r.NoError(err)
+	a.True(result.IsError)
+	a.Contains(result.Content, "multiple YAML documents")
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal(original, string(data))
+}
+
+func TestStructuredEditTool_Execute_Errors(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(jsonPath, []byte("{\"a\": 1, \"list\": [1]}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	txtPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(txtPath, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		wantErr string
+	}{
+		{"missing file_path", map[string]any{"key_path": "a", "value": "1"}, "file_path is required"},
+		{"missing key_path", map[string]any{"file_path": jsonPath, "value": "1"}, "key_path is required"},
+		{"missing value", map[string]any{"file_path": jsonPath, "key_path": "a"}, "value is required"},
+		{"unsupported type", map[string]any{"file_path": txtPath, "key_path": "a", "value": "1"}, "unsupported file type"},
+		{"through scalar", map[string]any{"file_path": jsonPath, "key_path": "a.b", "value": "1"}, "not inside an object or array"},
+		{"index out of range", map[string]any{"file_path": jsonPath, "key_path": "list.3", "value": "1"}, "out of range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := require.New(t)
+
+			result, err := NewStructuredEditTool().Execute(context.Background(), tt.input)
+
+			r.NoError(err)
+			a.True(result.IsError)
+			a.Contains(result.Content, tt.wantErr)
+		})
+	}
+}
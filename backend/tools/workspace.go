@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+
+	"ccui/backend"
+)
+
+type workspaceRootKey struct{}
+
+// WithWorkspaceRoot returns a context whose file tools reject paths outside
+// root. Sessions use it to confine a shared registry to their directory.
+func WithWorkspaceRoot(ctx context.Context, root string) context.Context {
+	return context.WithValue(ctx, workspaceRootKey{}, root)
+}
+
+// WorkspaceRoot returns the root set by WithWorkspaceRoot, or ""
+func WorkspaceRoot(ctx context.Context) string {
+	root, _ := ctx.Value(workspaceRootKey{}).(string)
+	return root
+}
+
+type workDirKey struct{}
+
+// WithWorkDir returns a context whose commands run in dir
+func WithWorkDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workDirKey{}, dir)
+}
+
+// WorkDir returns the directory set by WithWorkDir, falling back to the
+// workspace root, or "" for the process's current directory
+func WorkDir(ctx context.Context) string {
+	if dir, _ := ctx.Value(workDirKey{}).(string); dir != "" {
+		return dir
+	}
+	return WorkspaceRoot(ctx)
+}
+
+type diffContextKey struct{}
+
+// WithDiffContext returns a context whose edit tools keep the given number of
+// unchanged lines around their hunks; 0 leaves the default
+func WithDiffContext(ctx context.Context, lines int) context.Context {
+	return context.WithValue(ctx, diffContextKey{}, lines)
+}
+
+// DiffContext returns the lines set by WithDiffContext, falling back to
+// backend.DefaultDiffContextLines
+func DiffContext(ctx context.Context) int {
+	if lines, _ := ctx.Value(diffContextKey{}).(int); lines != 0 {
+		return lines
+	}
+	return backend.DefaultDiffContextLines
+}
+
+// resolvePath joins a relative path onto WorkDir(ctx), so file tools see the
+// same tree as the session's commands rather than the process's directory
+func resolvePath(ctx context.Context, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if dir := WorkDir(ctx); dir != "" {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
+
+// confinePath returns an error when ctx has a workspace root and path
+// resolves outside it
+func confinePath(ctx context.Context, path string) error {
+	root := WorkspaceRoot(ctx)
+	if root == "" {
+		return nil
+	}
+	return backend.ConfinePath(root, path)
+}
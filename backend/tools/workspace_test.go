@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTools_WorkspaceRoot(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a workspace with a file and a secret next to it
+	parent := t.TempDir()
+	root := filepath.Join(parent, "workspace")
+	r.NoError(os.MkdirAll(root, 0755))
+	inside := filepath.Join(root, "notes.txt")
+	r.NoError(os.WriteFile(inside, []byte("hello\n"), 0644))
+	secret := filepath.Join(parent, "secret.txt")
+	r.NoError(os.WriteFile(secret, []byte("token\n"), 0644))
+	escape := filepath.Join(root, "..", "secret.txt")
+
+	ctx := WithWorkspaceRoot(context.Background(), root)
+
+	// when - reading inside the root
+	result, err := NewReadTool().Execute(ctx, map[string]any{"file_path": inside})
+
+	// then - allowed
+	r.NoError(err)
+	a.False(result.IsError)
+	a.Contains(result.Content, "hello")
+
+	// when - each file tool targets a ../ escape
+	inputs := map[Tool]map[string]any{
+		NewReadTool():       {"file_path": escape},
+		NewWriteTool():      {"file_path": escape, "content": "pwned"},
+		NewEditTool():       {"file_path": escape, "old_string": "token", "new_string": "pwned"},
+		NewGrepTool():       {"pattern": "token", "path": parent},
+		NewApplyPatchTool(): {"patch": "--- " + escape + "\n+++ /dev/null\n@@ -1 +0,0 @@\n-token\n"},
+	}
+	for tool, input := range inputs {
+		result, err := tool.Execute(ctx, input)
+
+		// then - rejected with a clear error and the secret untouched
+		r.NoError(err)
+		a.True(result.IsError, tool.Name())
+		a.Contains(result.Content, "outside the workspace root", tool.Name())
+	}
+	data, err := os.ReadFile(secret)
+	r.NoError(err)
+	a.Equal("token\n", string(data))
+
+	// when - no root is set
+	result, err = NewReadTool().Execute(context.Background(), map[string]any{"file_path": escape})
+
+	// then - unconfined
+	r.NoError(err)
+	a.False(result.IsError)
+}
+
+func TestFileTools_RelativePathsUseWorkDir(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a session directory that is not the process's cwd
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello\n"), 0644))
+	ctx := WithWorkspaceRoot(context.Background(), dir)
+
+	// when - file tools take relative paths or default to the session's directory
+	steps := []struct {
+		tool  Tool
+		input map[string]any
+		want  string
+	}{
+		{NewReadTool(), map[string]any{"file_path": "notes.txt"}, "hello"},
+		{NewWriteTool(), map[string]any{"file_path": "new.txt", "content": "x"}, filepath.Join(dir, "new.txt")},
+		{NewEditTool(), map[string]any{"file_path": "notes.txt", "old_string": "hello", "new_string": "hi"}, filepath.Join(dir, "notes.txt")},
+		{NewApplyPatchTool(), map[string]any{"patch": "--- /dev/null\n+++ patched.txt\n@@ -0,0 +1 @@\n+y\n"}, "patched.txt"},
+		{NewGlobTool(), map[string]any{"pattern": "*.txt"}, filepath.Join(dir, "patched.txt")},
+		{NewGrepTool(), map[string]any{"pattern": "x"}, "new.txt"},
+	}
+	for _, step := range steps {
+		result, err := step.tool.Execute(ctx, step.input)
+
+		// then - each resolved inside the session's directory
+		r.NoError(err, step.tool.Name())
+		a.False(result.IsError, "%s: %s", step.tool.Name(), result.Content)
+		a.Contains(result.Content, step.want, step.tool.Name())
+	}
+	for file, want := range map[string]string{"notes.txt": "hi\n", "new.txt": "x", "patched.txt": "y\n"} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		r.NoError(err, file)
+		a.Equal(want, string(data), file)
+	}
+}
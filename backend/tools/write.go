@@ -27,6 +27,10 @@ func (w *WriteTool) Execute(ctx context.Context, input map[string]any) (ToolResu
 	if !ok || filePath == "" {
 		return ToolResult{Content: "file_path is required", IsError: true}, nil
 	}
+	filePath = resolvePath(ctx, filePath)
+	if err := confinePath(ctx, filePath); err != nil {
+		return ToolResult{Content: err.Error(), IsError: true}, nil
+	}
 
 	// extract content (required)
 	content, ok := input["content"].(string)
@@ -34,6 +38,14 @@ func (w *WriteTool) Execute(ctx context.Context, input map[string]any) (ToolResu
 		return ToolResult{Content: "content is required", IsError: true}, nil
 	}
 
+	// keep an existing file's line ending, otherwise the content's own
+	ending := detectLineEnding(content)
+	if existing, err := os.ReadFile(filePath); err == nil && len(existing) > 0 {
+		ending = detectLineEnding(string(existing))
+	}
+	content = normalizeLineEndings(content)
+	data := []byte(restoreLineEndings(content, ending))
+
 	// create parent directories
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -41,12 +53,12 @@ func (w *WriteTool) Execute(ctx context.Context, input map[string]any) (ToolResu
 	}
 
 	// write file
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return ToolResult{Content: fmt.Sprintf("failed to write file: %s", err), IsError: true}, nil
 	}
 
 	return ToolResult{
-		Content:    fmt.Sprintf("wrote %d bytes to %s", len(content), filePath),
+		Content:    fmt.Sprintf("wrote %d bytes to %s", len(data), filePath),
 		FilePath:   filePath,
 		NewContent: content,
 	}, nil
@@ -177,3 +177,50 @@ func TestWriteTool_Execute_InvalidPath(t *testing.T) {
 	a.True(result.IsError)
 	a.Contains(result.Content, "failed")
 }
+
+func TestWriteTool_Execute_PreservesExistingCRLF(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - existing CRLF file
+	dir := t.TempDir()
+	path := filepath.Join(dir, "crlf.txt")
+	r.NoError(os.WriteFile(path, []byte("old\r\ncontent\r\n"), 0644))
+
+	tool := NewWriteTool()
+
+	// when - overwritten with LF content
+	result, err := tool.Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"content":   "new\ncontent\n",
+	})
+
+	// then - file keeps CRLF
+	r.NoError(err)
+	a.False(result.IsError)
+
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("new\r\ncontent\r\n", string(data))
+	a.Equal("new\ncontent\n", result.NewContent)
+}
+
+func TestWriteTool_Execute_NewFileKeepsContentEnding(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given
+	path := filepath.Join(t.TempDir(), "new.txt")
+
+	// when - new file written with CRLF content
+	_, err := NewWriteTool().Execute(context.Background(), map[string]any{
+		"file_path": path,
+		"content":   "a\r\nb\r\n",
+	})
+
+	// then
+	r.NoError(err)
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	a.Equal("a\r\nb\r\n", string(data))
+}
@@ -1,6 +1,11 @@
 package backend
 
-import "sync"
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
 
 // PatchHunk represents a single hunk in a unified diff
 type PatchHunk struct {
@@ -19,10 +24,17 @@ type DiffBlock struct {
 	NewText string `json:"newText,omitempty"`
 }
 
+// MIME types for tool output
+const (
+	MimeTextPlain = "text/plain"
+	MimeJSON      = "application/json"
+)
+
 // OutputBlock represents tool output content
 type OutputBlock struct {
 	Type       string       `json:"type"`
 	Content    *TextContent `json:"content,omitempty"`
+	MimeType   string       `json:"mimeType,omitempty"` // of Content; empty means text/plain
 	Path       string       `json:"path,omitempty"`
 	OldContent string       `json:"oldContent,omitempty"`
 	NewContent string       `json:"newContent,omitempty"`
@@ -41,6 +53,42 @@ type PermOption struct {
 	Kind     string `json:"kind"`
 }
 
+// AllowOptionID picks the one-time allow option, falling back to any allow
+// option and then to "allow_once"
+func AllowOptionID(options []PermOption) string {
+	fallback := ""
+	for _, o := range options {
+		switch {
+		case o.Kind == "allow_once":
+			return o.OptionID
+		case fallback == "" && strings.HasPrefix(o.Kind, "allow"):
+			fallback = o.OptionID
+		}
+	}
+	if fallback == "" {
+		return "allow_once"
+	}
+	return fallback
+}
+
+// RejectOptionID picks the one-time reject option, falling back to any
+// reject/deny option and then to "reject_once"
+func RejectOptionID(options []PermOption) string {
+	fallback := ""
+	for _, o := range options {
+		switch {
+		case o.Kind == "reject_once":
+			return o.OptionID
+		case fallback == "" && (strings.HasPrefix(o.Kind, "reject") || o.Kind == "deny"):
+			fallback = o.OptionID
+		}
+	}
+	if fallback == "" {
+		return "reject_once"
+	}
+	return fallback
+}
+
 // SessionMode represents an agent session mode
 type SessionMode struct {
 	ID          string `json:"id"`
@@ -70,32 +118,195 @@ type ToolState struct {
 	PermissionOptions []PermOption   `json:"permissionOptions,omitempty"`
 }
 
+// Clone returns a deep copy of s that shares no maps or slices with it, so
+// it can be read while s keeps changing
+func (s *ToolState) Clone() *ToolState {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Input = cloneMap(s.Input)
+	c.Diff = cloneMap(s.Diff)
+	c.Diffs = slices.Clone(s.Diffs)
+	c.PermissionOptions = slices.Clone(s.PermissionOptions)
+	if s.Output != nil {
+		c.Output = make([]OutputBlock, len(s.Output))
+		for i, block := range s.Output {
+			if block.Content != nil {
+				content := *block.Content
+				block.Content = &content
+			}
+			c.Output[i] = block
+		}
+	}
+	return &c
+}
+
+// cloneMap deep copies the nested maps and slices of decoded JSON
+func cloneMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = cloneValue(v)
+	}
+	return out
+}
+
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return cloneMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = cloneValue(e)
+		}
+		return out
+	case []PatchHunk:
+		out := make([]PatchHunk, len(v))
+		for i, h := range v {
+			h.Lines = slices.Clone(h.Lines)
+			out[i] = h
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// ToolOutputChunk is incremental output from a running tool
+type ToolOutputChunk struct {
+	ToolCallID string `json:"toolCallId"`
+	Chunk      string `json:"chunk"`
+}
+
+// ToolProgress reports how far a running search has got
+type ToolProgress struct {
+	ToolCallID   string `json:"toolCallId"`
+	FilesScanned int    `json:"filesScanned"`
+	Matches      int    `json:"matches"`
+}
+
+// AgentTerminal announces a terminal the agent started for a command
+type AgentTerminal struct {
+	TerminalID string   `json:"terminalId"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args,omitempty"`
+}
+
+// HistoryCompaction reports older turns replaced by a summary
+type HistoryCompaction struct {
+	MessagesRemoved int `json:"messagesRemoved"`
+	TokensBefore    int `json:"tokensBefore"` // input tokens of the summarized turns
+	TokensAfter     int `json:"tokensAfter"`  // tokens of the summary
+	TokensSaved     int `json:"tokensSaved"`
+}
+
+// DefaultMaxTaskDepth is how deep Task tools nest before their children are
+// flattened onto the deepest allowed Task
+const DefaultMaxTaskDepth = 8
+
+// TokenUsage totals the tokens of one or more API requests
+type TokenUsage struct {
+	InputTokens              int      `json:"inputTokens"`
+	OutputTokens             int      `json:"outputTokens"`
+	CacheCreationInputTokens int      `json:"cacheCreationInputTokens,omitempty"`
+	CacheReadInputTokens     int      `json:"cacheReadInputTokens,omitempty"`
+	CostUSD                  *float64 `json:"costUsd,omitempty"` // estimate; nil when a model's price is unknown
+}
+
+// UsageReport is sent when a prompt finishes with its tokens and those of
+// the whole session
+type UsageReport struct {
+	Model   string     `json:"model"` // model of the turn's last request
+	Turn    TokenUsage `json:"turn"`
+	Session TokenUsage `json:"session"`
+}
+
 // ToolCallManager tracks all active tool calls
 type ToolCallManager struct {
 	tools       map[string]*ToolState
+	order       []string // tool IDs in the order first seen
 	parentStack []string // stack of active Task tool IDs
+	maxDepth    int      // cap on len(parentStack)
 	mu          sync.RWMutex
 }
 
 // NewToolCallManager creates a new ToolCallManager
 func NewToolCallManager() *ToolCallManager {
-	return &ToolCallManager{tools: make(map[string]*ToolState)}
+	return &ToolCallManager{tools: make(map[string]*ToolState), maxDepth: DefaultMaxTaskDepth}
 }
 
-// Get returns the tool state for the given ID
+// SetMaxDepth caps how deep Tasks nest; n <= 0 restores DefaultMaxTaskDepth
+func (m *ToolCallManager) SetMaxDepth(n int) {
+	if n <= 0 {
+		n = DefaultMaxTaskDepth
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxDepth = n
+}
+
+// Get returns a copy of the tool state for the given ID, or nil
 func (m *ToolCallManager) Get(id string) *ToolState {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.tools[id]
+	return m.tools[id].Clone()
 }
 
 // Set stores a tool state
 func (m *ToolCallManager) Set(state *ToolState) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if _, ok := m.tools[state.ID]; !ok {
+		m.order = append(m.order, state.ID)
+	}
 	m.tools[state.ID] = state
 }
 
+// List returns copies of all tool states as a depth-first walk of the
+// tool tree: each parent is followed by its children, siblings in the
+// order they were first set. Tools whose parent is unknown are roots.
+func (m *ToolCallManager) List() []*ToolState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	children := make(map[string][]string)
+	var roots []string
+	for _, id := range m.order {
+		parent := m.tools[id].ParentID
+		if _, ok := m.tools[parent]; ok && parent != id {
+			children[parent] = append(children[parent], id)
+		} else {
+			roots = append(roots, id)
+		}
+	}
+
+	result := make([]*ToolState, 0, len(m.order))
+	visited := make(map[string]bool, len(m.order))
+	var walk func(id string)
+	walk = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		result = append(result, m.tools[id].Clone())
+		for _, child := range children[id] {
+			walk(child)
+		}
+	}
+	for _, id := range roots {
+		walk(id)
+	}
+	// parent cycles have no root; keep them in first-seen order
+	for _, id := range m.order {
+		walk(id)
+	}
+	return result
+}
+
 // Update applies a function to update a tool state
 func (m *ToolCallManager) Update(id string, fn func(*ToolState)) *ToolState {
 	m.mu.Lock()
@@ -107,10 +318,16 @@ func (m *ToolCallManager) Update(id string, fn func(*ToolState)) *ToolState {
 	return nil
 }
 
-// PushParent adds a parent tool ID to the stack
+// PushParent adds a parent tool ID to the stack. Past the max depth the
+// Task is not pushed, so its children join the deepest allowed parent.
 func (m *ToolCallManager) PushParent(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if len(m.parentStack) >= m.maxDepth {
+		slog.Warn("task nesting too deep; flattening its children",
+			"tool", id, "parent", m.parentStack[len(m.parentStack)-1], "maxDepth", m.maxDepth)
+		return
+	}
 	m.parentStack = append(m.parentStack, id)
 }
 
@@ -137,18 +354,59 @@ func (m *ToolCallManager) CurrentParent() string {
 	return ""
 }
 
+// NewTurn readies the manager for the next prompt: tools that finished in
+// earlier turns are dropped and the parent stack is emptied, so a Task that
+// never reported a terminal status cannot adopt the new turn's tools. Tools
+// still in flight are kept for their late updates.
+func (m *ToolCallManager) NewTurn() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parentStack = nil
+	order := m.order[:0]
+	for _, id := range m.order {
+		if IsTerminalStatus(m.tools[id].Status) {
+			delete(m.tools, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	m.order = order
+}
+
+// EndTurn empties the parent stack once a prompt has finished; a Task whose
+// agent crashed or was cancelled before its terminal update would otherwise
+// adopt every later tool
+func (m *ToolCallManager) EndTurn() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parentStack = nil
+}
+
+// IsTerminalStatus reports whether a tool status is final
+func IsTerminalStatus(status string) bool {
+	return status == "completed" || status == "error" || status == "failed"
+}
+
 // FileChange tracks a file's changes during the session
 type FileChange struct {
 	FilePath        string      `json:"filePath"`
 	OriginalContent string      `json:"originalContent"`
 	CurrentContent  string      `json:"currentContent"`
 	Hunks           []PatchHunk `json:"hunks"`
+	Stats           *DiffStats  `json:"stats,omitempty"` // set by FileChangeStore.Diff
+}
+
+// DiffStats counts changed lines between original and current content
+type DiffStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
 }
 
 // FileChangeStore accumulates file changes, coalesces to latest state
 type FileChangeStore struct {
-	changes map[string]*FileChange
-	mu      sync.RWMutex
+	changes  map[string]*FileChange
+	mu       sync.RWMutex
+	onRecord func(filePath string) // called after each RecordChange, outside mu
 }
 
 // NewFileChangeStore creates a new FileChangeStore
@@ -159,8 +417,6 @@ func NewFileChangeStore() *FileChangeStore {
 // RecordChange records a file change, coalescing with existing changes
 func (s *FileChangeStore) RecordChange(filePath, originalContent, currentContent string, hunks []PatchHunk) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if existing, ok := s.changes[filePath]; ok {
 		// Coalesce: keep original, update current
 		existing.CurrentContent = currentContent
@@ -173,6 +429,30 @@ func (s *FileChangeStore) RecordChange(filePath, originalContent, currentContent
 			Hunks:           hunks,
 		}
 	}
+	onRecord := s.onRecord
+	s.mu.Unlock()
+
+	if onRecord != nil {
+		onRecord(filePath)
+	}
+}
+
+// updateCurrent replaces a tracked file's current content if it differs,
+// with hunks for just this change; it reports whether anything changed
+func (s *FileChangeStore) updateCurrent(filePath, currentContent string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.changes[filePath]
+	if !ok || c.CurrentContent == currentContent {
+		return false
+	}
+	// replaced rather than mutated, as Get hands out the stored pointer and
+	// this runs on the watcher's goroutine
+	updated := *c
+	updated.Hunks = BuildHunks(c.CurrentContent, currentContent)
+	updated.CurrentContent = currentContent
+	s.changes[filePath] = &updated
+	return true
 }
 
 // Get returns the file change for the given path
@@ -182,6 +462,35 @@ func (s *FileChangeStore) Get(filePath string) *FileChange {
 	return s.changes[filePath]
 }
 
+// Diff returns a copy of the change for filePath with hunks and stats
+// spanning original to current content, or nil if the file is not tracked
+func (s *FileChangeStore) Diff(filePath string) *FileChange {
+	s.mu.RLock()
+	c, ok := s.changes[filePath]
+	if !ok {
+		s.mu.RUnlock()
+		return nil
+	}
+	diff := *c
+	s.mu.RUnlock()
+
+	// stored hunks cover only the latest edit once changes coalesce
+	diff.Hunks = BuildHunks(diff.OriginalContent, diff.CurrentContent)
+	stats := DiffStats{}
+	for _, h := range diff.Hunks {
+		for _, line := range h.Lines {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				stats.Additions++
+			case strings.HasPrefix(line, "-"):
+				stats.Deletions++
+			}
+		}
+	}
+	diff.Stats = &stats
+	return &diff
+}
+
 // GetAll returns all file changes
 func (s *FileChangeStore) GetAll() []FileChange {
 	s.mu.RLock()
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToolCallManager_List(t *testing.T) {
+	m := NewToolCallManager()
+	// set assigns ParentID from the parent stack like the sessions do
+	set := func(id string) {
+		m.Set(&ToolState{ID: id, Status: "running", ParentID: m.CurrentParent()})
+	}
+
+	// given: a Task with a nested Task, then a top-level tool
+	set("task1")
+	m.PushParent("task1")
+	set("read1")
+	set("task2")
+	m.PushParent("task2")
+	set("grep1")
+	m.PopParent("task2")
+	set("bash1")
+	m.PopParent("task1")
+	set("write1")
+
+	// given: a child of task2 reported after the top-level tool
+	m.Set(&ToolState{ID: "grep2", ParentID: "task2"})
+
+	// when
+	list := m.List()
+
+	// then: depth-first, children right after their parent
+	ids := make([]string, len(list))
+	parents := make(map[string]string, len(list))
+	for i, s := range list {
+		ids[i] = s.ID
+		parents[s.ID] = s.ParentID
+	}
+	if got, want := strings.Join(ids, ","), "task1,read1,task2,grep1,grep2,bash1,write1"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	wantParents := map[string]string{
+		"task1": "", "read1": "task1", "task2": "task1", "grep1": "task2",
+		"grep2": "task2", "bash1": "task1", "write1": "",
+	}
+	for id, want := range wantParents {
+		if parents[id] != want {
+			t.Errorf("%s: expected parent %q, got %q", id, want, parents[id])
+		}
+	}
+
+	// then: entries are copies
+	list[0].Status = "mutated"
+	if m.Get("task1").Status != "running" {
+		t.Errorf("List returned a live state")
+	}
+}
+
+func TestToolCallManager_NewTurn(t *testing.T) {
+	// given: a finished tool and a Task that never reported a terminal status
+	m := NewToolCallManager()
+	m.Set(&ToolState{ID: "read1", Status: "completed"})
+	m.Set(&ToolState{ID: "task1", Status: "in_progress"})
+	m.PushParent("task1")
+
+	// when
+	m.NewTurn()
+
+	// then: the stack is empty, finished tools are gone, live ones kept
+	if got := m.CurrentParent(); got != "" {
+		t.Errorf("expected no parent, got %q", got)
+	}
+	if m.Get("read1") != nil {
+		t.Error("expected the completed tool to be dropped")
+	}
+	if m.Get("task1") == nil {
+		t.Error("expected the running Task to be kept")
+	}
+	if got := len(m.List()); got != 1 {
+		t.Errorf("expected 1 tool listed, got %d", got)
+	}
+}
+
+func TestToolCallManager_MaxDepth(t *testing.T) {
+	// given: a manager capped at two levels of Task
+	m := NewToolCallManager()
+	m.SetMaxDepth(2)
+
+	// when: three Tasks nest
+	for _, id := range []string{"task1", "task2", "task3"} {
+		m.PushParent(id)
+	}
+
+	// then: the third is flattened, its children join task2
+	if got := m.CurrentParent(); got != "task2" {
+		t.Errorf("expected task2 as parent, got %q", got)
+	}
+	m.PopParent("task3")
+	if got := m.CurrentParent(); got != "task2" {
+		t.Errorf("popping the flattened Task changed the parent to %q", got)
+	}
+	m.PopParent("task2")
+	if got := m.CurrentParent(); got != "task1" {
+		t.Errorf("expected task1 as parent, got %q", got)
+	}
+}
+
+func TestToolState_Clone(t *testing.T) {
+	// given: a state with nested input, output and diff
+	orig := &ToolState{
+		ID:     "t1",
+		Input:  map[string]any{"edits": []any{map[string]any{"old": "a"}}},
+		Output: []OutputBlock{{Type: "content", Content: &TextContent{Type: "text", Text: "ok"}}},
+		Diff:   map[string]any{"structuredPatch": []PatchHunk{{Lines: []string{"-a", "+b"}}}},
+		Diffs:  []DiffBlock{{Type: "diff", Path: "/tmp/a"}},
+	}
+
+	// when: the clone is mutated at every level
+	c := orig.Clone()
+	c.Input["edits"].([]any)[0].(map[string]any)["old"] = "changed"
+	c.Output[0].Content.Text = "changed"
+	c.Diff["structuredPatch"].([]PatchHunk)[0].Lines[0] = "changed"
+	c.Diffs[0].Path = "changed"
+
+	// then: the original is untouched
+	if orig.Input["edits"].([]any)[0].(map[string]any)["old"] != "a" {
+		t.Errorf("input shared with clone")
+	}
+	if orig.Output[0].Content.Text != "ok" {
+		t.Errorf("output shared with clone")
+	}
+	if orig.Diff["structuredPatch"].([]PatchHunk)[0].Lines[0] != "-a" {
+		t.Errorf("diff shared with clone")
+	}
+	if orig.Diffs[0].Path != "/tmp/a" {
+		t.Errorf("diffs shared with clone")
+	}
+}
@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfinePath returns an error when path resolves outside root, following
+// symlinks in the existing part of both
+func ConfinePath(root, path string) error {
+	resolvedRoot, err := resolvePath(root)
+	if err != nil {
+		return fmt.Errorf("resolve workspace root: %w", err)
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", path, err)
+	}
+
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside the workspace root %s", path, root)
+	}
+	return nil
+}
+
+// resolvePath makes path absolute and resolves symlinks in its longest
+// existing prefix, so paths to files not yet created still resolve
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	for current := abs; ; {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return abs, nil
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
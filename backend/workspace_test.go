@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfinePath(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "project")
+	os.MkdirAll(filepath.Join(root, "src"), 0755)
+	os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main"), 0644)
+	os.MkdirAll(filepath.Join(parent, "project-other"), 0755)
+	os.Symlink(parent, filepath.Join(root, "up"))
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"existing file in root", filepath.Join(root, "src", "main.go"), false},
+		{"new file in root", filepath.Join(root, "src", "new", "file.go"), false},
+		{"root itself", root, false},
+		{"dot-dot escape", filepath.Join(root, "src", "..", "..", "secret.txt"), true},
+		{"absolute outside", "/etc/passwd", true},
+		{"sibling sharing prefix", filepath.Join(parent, "project-other", "x"), true},
+		{"symlink escape", filepath.Join(root, "up", "secret.txt"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ConfinePath(root, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfinePath(%s) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "outside the workspace root") {
+				t.Errorf("expected clear error, got %v", err)
+			}
+		})
+	}
+}
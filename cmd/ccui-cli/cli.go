@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ccui/backend"
+	"ccui/backend/acp"
+	"ccui/permission"
+)
+
+// cli connects one ACP session to a line-oriented terminal. Prompts and
+// permission answers share the input: while a turn runs the prompt loop is
+// blocked in SendPrompt, so only the permission prompter reads.
+type cli struct {
+	in  *bufio.Scanner
+	out io.Writer
+	mu  sync.Mutex // serializes writes from the event and permission goroutines
+
+	layer    *permission.Layer
+	events   chan backend.Event
+	turnDone chan struct{} // signalled once a turn's events are printed
+}
+
+// newCLI creates a cli reading from in and writing to out
+func newCLI(in io.Reader, out io.Writer) *cli {
+	c := &cli{
+		in:       bufio.NewScanner(in),
+		out:      out,
+		events:   make(chan backend.Event, 100),
+		turnDone: make(chan struct{}, 1),
+	}
+	c.layer = permission.NewLayerWithDenylist(permission.DefaultRules(), permission.DefaultDenylist(), c)
+	return c
+}
+
+// connect creates a client on transport whose permission requests are asked
+// on the terminal, except for allowed tools or, with yes, everything
+func (c *cli) connect(transport acp.Transport, allowed []string, yes bool) *acp.Client {
+	c.layer.SetAutoApprove(allowed...)
+	return acp.NewClient(acp.ClientConfig{
+		Transport:      transport,
+		EventChan:      c.events,
+		AutoPermission: yes,
+	}, acp.WithPermissionLayer(c.layer))
+}
+
+// run starts a session in cwd and sends each input line as a prompt until
+// the input ends
+func (c *cli) run(client *acp.Client, cwd string) error {
+	go c.printEvents()
+	if err := client.Initialize(); err != nil {
+		return fmt.Errorf("initialize: %w", err)
+	}
+	if err := client.NewSession(cwd, []any{}); err != nil {
+		if errors.Is(err, acp.ErrAuthRequired) {
+			return fmt.Errorf("%w; log in with the agent first", err)
+		}
+		return fmt.Errorf("new session: %w", err)
+	}
+
+	for {
+		c.printf("> ")
+		if !c.in.Scan() {
+			c.printf("\n")
+			return c.in.Err()
+		}
+		text := strings.TrimSpace(c.in.Text())
+		if text == "" {
+			continue
+		}
+		if err := client.SendPrompt(text, nil); err != nil {
+			c.printf("error: %v\n", err)
+			continue
+		}
+		<-c.turnDone
+	}
+}
+
+// printEvents streams the session's events to the terminal
+func (c *cli) printEvents() {
+	statuses := make(map[string]string) // last printed status per tool call
+	for ev := range c.events {
+		switch ev.Type {
+		case backend.EventMessageChunk:
+			if text, ok := ev.Data.(string); ok {
+				c.printf("%s", text)
+			}
+		case backend.EventToolState:
+			state, ok := ev.Data.(*backend.ToolState)
+			if !ok || statuses[state.ID] == state.Status {
+				continue
+			}
+			statuses[state.ID] = state.Status
+			c.printf("\n[%s: %s]\n", state.Title, state.Status)
+		case backend.EventAuthRequired:
+			if auth, ok := ev.Data.(acp.AuthRequired); ok {
+				c.printf("authentication required: %s\n", auth.Message)
+			}
+		case backend.EventPromptComplete:
+			c.printf("\n")
+			c.turnDone <- struct{}{}
+		}
+	}
+}
+
+// Emit implements permission.EventEmitter by asking on the terminal and
+// answering the layer before its Request call blocks
+func (c *cli) Emit(eventName string, data any) {
+	req, ok := data.(permission.PermissionRequest)
+	if eventName != "permission_request" || !ok {
+		return
+	}
+	c.layer.Respond(req.SessionID, req.ToolCallID, c.askPermission(req))
+}
+
+// askPermission prompts until a listed option is picked by number or ID;
+// closed input rejects
+func (c *cli) askPermission(req permission.PermissionRequest) string {
+	c.printf("\nAllow %s?\n", req.ToolName)
+	for i, opt := range req.Options {
+		c.printf("  %d) %s\n", i+1, opt.Name)
+	}
+	for {
+		c.printf("choice: ")
+		if !c.in.Scan() {
+			return backend.RejectOptionID(req.Options)
+		}
+		answer := strings.TrimSpace(c.in.Text())
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(req.Options) {
+			return req.Options[n-1].OptionID
+		}
+		for _, opt := range req.Options {
+			if answer == opt.OptionID {
+				return opt.OptionID
+			}
+		}
+	}
+}
+
+// printf writes to the terminal
+func (c *cli) printf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, format, args...)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"ccui/backend"
+	"ccui/backend/acp"
+)
+
+// MockTransport plays a scripted agent: Send answers from responses after
+// running the method's script, which may call back into the client
+type MockTransport struct {
+	mu        sync.Mutex
+	handler   func(method string, params json.RawMessage, id *int)
+	sent      []string
+	responses map[string]any
+	scripts   map[string]func()
+	replies   []json.RawMessage
+}
+
+func NewMockTransport() *MockTransport {
+	return &MockTransport{responses: make(map[string]any), scripts: make(map[string]func())}
+}
+
+func (m *MockTransport) Send(method string, params any) (json.RawMessage, error) {
+	m.mu.Lock()
+	m.sent = append(m.sent, method)
+	script := m.scripts[method]
+	resp, _ := json.Marshal(m.responses[method])
+	m.mu.Unlock()
+	if script != nil {
+		script()
+	}
+	return resp, nil
+}
+
+func (m *MockTransport) Notify(method string, params any) {
+	m.mu.Lock()
+	m.sent = append(m.sent, method)
+	m.mu.Unlock()
+}
+
+func (m *MockTransport) Respond(id *int, result json.RawMessage) {
+	m.mu.Lock()
+	m.replies = append(m.replies, result)
+	m.mu.Unlock()
+}
+
+func (m *MockTransport) RespondError(id *int, err *acp.RPCError) {}
+func (m *MockTransport) Close() error                            { return nil }
+func (m *MockTransport) OnMethod(handler func(method string, params json.RawMessage, id *int)) {
+	m.handler = handler
+}
+
+// SimulateMethod delivers an agent request or notification to the client
+func (m *MockTransport) SimulateMethod(method string, params any, id *int) {
+	data, _ := json.Marshal(params)
+	m.handler(method, data, id)
+}
+
+// agentReply streams text and asks permission for a Write during a turn
+func agentReply(transport *MockTransport, text string) func() {
+	return func() {
+		transport.SimulateMethod("session/update", acp.SessionUpdate{
+			SessionID: "sess-1",
+			Update: acp.UpdateContent{
+				SessionUpdate: "agent_message_chunk",
+				Content:       json.RawMessage(`{"type":"text","text":"` + text + `"}`),
+			},
+		}, nil)
+		id := 7
+		transport.SimulateMethod("session/request_permission", acp.PermissionRequest{
+			SessionID: "sess-1",
+			ToolCall:  acp.ToolCallInfo{ToolCallID: "tool-1", Title: "Write", Kind: "edit"},
+			Options: []backend.PermOption{
+				{OptionID: "allow_once", Name: "Allow once", Kind: "allow_once"},
+				{OptionID: "reject_once", Name: "Reject", Kind: "reject_once"},
+			},
+		}, &id)
+	}
+}
+
+func TestCLI_PromptStreamsAndAsksPermission(t *testing.T) {
+	// given: an agent that replies and asks to write a file
+	transport := NewMockTransport()
+	transport.responses["initialize"] = map[string]any{"protocolVersion": acp.ProtocolVersion}
+	transport.responses["session/new"] = map[string]any{"sessionId": "sess-1"}
+	transport.responses["session/prompt"] = map[string]any{"stopReason": "end_turn"}
+	transport.scripts["session/prompt"] = agentReply(transport, "Hello from the agent")
+
+	// and: the user sends a prompt, first mistypes, then rejects the write
+	var out bytes.Buffer
+	c := newCLI(strings.NewReader("write a file\n9\n2\n"), &out)
+	client := c.connect(transport, nil, false)
+
+	// when
+	if err := c.run(client, "/work"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// then: the reply streamed and the permission prompt was shown
+	got := out.String()
+	if !strings.Contains(got, "Hello from the agent") {
+		t.Errorf("expected streamed reply, got %q", got)
+	}
+	if !strings.Contains(got, "Allow Write?") || strings.Count(got, "choice: ") != 2 {
+		t.Errorf("expected a repeated permission prompt, got %q", got)
+	}
+
+	// and: the choice reached the agent
+	if len(transport.replies) != 1 {
+		t.Fatalf("expected 1 permission reply, got %d", len(transport.replies))
+	}
+	var reply acp.PermissionResponse
+	json.Unmarshal(transport.replies[0], &reply)
+	if reply.Outcome.OptionID != "reject_once" {
+		t.Errorf("expected reject_once, got %+v", reply.Outcome)
+	}
+	if want := []string{"initialize", "session/new", "session/prompt"}; strings.Join(transport.sent, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v sent, got %v", want, transport.sent)
+	}
+}
+
+func TestCLI_AllowedToolSkipsPrompt(t *testing.T) {
+	// given: Write is allowed up front
+	transport := NewMockTransport()
+	transport.responses["initialize"] = map[string]any{"protocolVersion": acp.ProtocolVersion}
+	transport.responses["session/new"] = map[string]any{"sessionId": "sess-1"}
+	transport.scripts["session/prompt"] = agentReply(transport, "done")
+	var out bytes.Buffer
+	c := newCLI(strings.NewReader("go\n"), &out)
+	client := c.connect(transport, []string{"Write"}, false)
+
+	// when
+	if err := c.run(client, "/work"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// then: allowed without asking
+	if strings.Contains(out.String(), "Allow Write?") {
+		t.Errorf("expected no permission prompt, got %q", out.String())
+	}
+	var reply acp.PermissionResponse
+	if len(transport.replies) == 1 {
+		json.Unmarshal(transport.replies[0], &reply)
+	}
+	if reply.Outcome.OptionID != "allow_once" {
+		t.Errorf("expected allow_once, got %+v", reply.Outcome)
+	}
+}
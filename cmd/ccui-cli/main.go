@@ -0,0 +1,74 @@
+// Command ccui-cli runs an ACP agent in the terminal: prompts are read from
+// stdin, replies stream to stdout and permission requests are answered inline
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"ccui/backend/acp"
+)
+
+func main() {
+	agent := flag.String("agent", "claude-code-acp", "ACP agent binary; arguments follow the flags")
+	cwd := flag.String("cwd", "", "session working directory, default the current one")
+	allow := flag.String("allow", "", "comma-separated tool names allowed without asking")
+	yes := flag.Bool("yes", false, "allow every permission request without asking")
+	flag.Parse()
+
+	if err := run(*agent, flag.Args(), *cwd, *allow, *yes); err != nil {
+		fmt.Fprintln(os.Stderr, "ccui-cli:", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the agent and drives a session on it until stdin closes
+func run(agent string, args []string, cwd, allow string, yes bool) error {
+	if cwd == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		cwd = wd
+	}
+
+	cmd := exec.Command(agent, args...)
+	cmd.Dir = cwd
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", agent, err)
+	}
+	defer cmd.Wait()
+
+	c := newCLI(os.Stdin, os.Stdout)
+	var allowed []string
+	if allow != "" {
+		allowed = strings.Split(allow, ",")
+	}
+	client := c.connect(acp.NewStdioTransport(stdin, stdout), allowed, yes)
+	defer client.Close()
+
+	// Ctrl-C cancels the running turn rather than killing the agent
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+			client.Cancel()
+		}
+	}()
+
+	return c.run(client, cwd)
+}
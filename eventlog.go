@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event log entry types; tool states and file changes are kept apart from
+// the rest of a session's updates so a replay can filter on them
+const (
+	eventLogSessionUpdate = "session_update"
+	eventLogToolState     = "tool_state"
+	eventLogFileChanges   = "file_changes"
+)
+
+// eventLogEntry is one line of an events-*.jsonl log
+type eventLogEntry struct {
+	Time  time.Time       `json:"time"`
+	Type  string          `json:"type"`
+	Event string          `json:"event"` // frontend event name, including the session prefix
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// eventRecorder appends every bridged frontend event to a JSONL log, so a
+// session can be replayed later with ReplayLog
+type eventRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newEventRecorder creates <dir>/events-<unix nanos>.jsonl
+func newEventRecorder(dir string) (*eventRecorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create event log dir: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("events-%d.jsonl", time.Now().UnixNano()))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open event log: %w", err)
+	}
+	return &eventRecorder{file: f}, nil
+}
+
+// record appends one event; a nil or closed recorder drops it
+func (r *eventRecorder) record(event string, data any) {
+	if r == nil {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	line, err := json.Marshal(eventLogEntry{Time: time.Now(), Type: eventLogType(event), Event: event, Data: raw})
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Write(append(line, '\n'))
+	}
+}
+
+// Close stops recording
+func (r *eventRecorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// eventLogType classifies a frontend event name for the log
+func eventLogType(event string) string {
+	switch {
+	case strings.HasSuffix(event, ":tool_state"):
+		return eventLogToolState
+	case strings.HasSuffix(event, ":file_changes_updated"):
+		return eventLogFileChanges
+	default:
+		return eventLogSessionUpdate
+	}
+}
+
+// ReplayLog re-emits the session_update, tool_state and file_changes entries
+// of an events-*.jsonl log in order, so a past session can be inspected in a
+// fresh UI without its agent. Entries of other types are skipped.
+func ReplayLog(path string, emit EventEmitter) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var entry eventLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		switch entry.Type {
+		case eventLogSessionUpdate, eventLogToolState, eventLogFileChanges:
+			emit.Emit(entry.Event, entry.Data)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ccui/backend"
+)
+
+func TestReplayLog_SyntheticLog(t *testing.T) {
+	// given: a log with one entry of each type plus one of an unknown type
+	path := filepath.Join(t.TempDir(), "events-1.jsonl")
+	log := strings.Join([]string{
+		`{"time":"2026-01-01T00:00:00Z","type":"session_update","event":"session:s1:chat_chunk","data":"hello"}`,
+		`{"time":"2026-01-01T00:00:01Z","type":"tool_state","event":"session:s1:tool_state","data":{"id":"t1","status":"running"}}`,
+		`{"time":"2026-01-01T00:00:02Z","type":"debug","event":"session:s1:ignored","data":null}`,
+		``,
+		`{"time":"2026-01-01T00:00:03Z","type":"file_changes","event":"session:s1:file_changes_updated","data":[]}`,
+	}, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	emitter := &recordingEmitter{}
+
+	// when
+	if err := ReplayLog(path, emitter); err != nil {
+		t.Fatalf("ReplayLog: %v", err)
+	}
+
+	// then: known entries are re-emitted in order with their raw data
+	wantNames := []string{"session:s1:chat_chunk", "session:s1:tool_state", "session:s1:file_changes_updated"}
+	if strings.Join(emitter.names, ",") != strings.Join(wantNames, ",") {
+		t.Fatalf("expected %v, got %v", wantNames, emitter.names)
+	}
+	wantData := []string{`"hello"`, `{"id":"t1","status":"running"}`, `[]`}
+	for i, want := range wantData {
+		if got := string(emitter.data[i].(json.RawMessage)); got != want {
+			t.Errorf("entry %d: expected data %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestReplayLog_MalformedLine(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "events-1.jsonl")
+	log := `{"type":"session_update","event":"session:s1:chat_chunk","data":"ok"}` + "\n{not json\n"
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	err := ReplayLog(path, &recordingEmitter{})
+
+	// then: the error names the offending line
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("expected error for line 2, got %v", err)
+	}
+}
+
+func TestApp_BridgeEvents_RecordsEventLog(t *testing.T) {
+	// given: an app recording into a temp dir
+	dir := t.TempDir()
+	rec, err := newEventRecorder(dir)
+	if err != nil {
+		t.Fatalf("newEventRecorder: %v", err)
+	}
+	app := NewApp()
+	app.emitter = &recordingEmitter{}
+	app.events = rec
+	events := make(chan backend.Event, 3)
+	events <- backend.Event{Type: backend.EventMessageChunk, Data: "said"}
+	events <- backend.Event{Type: backend.EventToolState, Data: map[string]string{"id": "t1"}}
+	events <- backend.Event{Type: backend.EventFileChanges, Data: []string{"a.go"}}
+	close(events)
+
+	// when: the bridge runs and the log is replayed
+	app.bridgeEvents("session:s1:", events, "chat_chunk", "chat_thought")
+	rec.Close()
+	logs, _ := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	if len(logs) != 1 {
+		t.Fatalf("expected one event log, got %v", logs)
+	}
+	replayed := &recordingEmitter{}
+	if err := ReplayLog(logs[0], replayed); err != nil {
+		t.Fatalf("ReplayLog: %v", err)
+	}
+
+	// then
+	want := []string{"session:s1:chat_chunk", "session:s1:tool_state", "session:s1:file_changes_updated"}
+	if strings.Join(replayed.names, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, replayed.names)
+	}
+}
@@ -47,6 +47,8 @@ export interface ReviewComment {
   lineNumber?: number;
   hunkIndex?: number;
   text: string;
+  severity?: 'info' | 'warn' | 'blocker';
+  category?: 'bug' | 'style' | 'perf' | 'security';
 }
 
 export interface SessionMode {
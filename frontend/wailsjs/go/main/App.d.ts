@@ -2,23 +2,44 @@
 // This file is automatically generated. DO NOT EDIT
 import {backend} from '../models';
 import {main} from '../models';
+import {tools} from '../models';
+
+export function Authenticate(arg1:string,arg2:string):Promise<void>;
+
+export function CancelReview(arg1:string):Promise<void>;
 
 export function CloseSession(arg1:string):Promise<void>;
 
-export function CreateSession(arg1:string):Promise<string>;
+export function CompactHistory():Promise<void>;
+
+export function CreateSession(arg1:string,arg2:string):Promise<string>;
 
 export function GetActiveSession():Promise<string>;
 
 export function GetCurrentMode():Promise<string>;
 
+export function GetFileDiff(arg1:string):Promise<backend.FileChange>;
+
+export function GetModel():Promise<string>;
+
 export function GetModes():Promise<Array<backend.SessionMode>>;
 
 export function GetSessions():Promise<Array<main.SessionInfo>>;
 
+export function GetToolStates(arg1:string):Promise<Array<backend.ToolState>>;
+
+export function GetToolStats():Promise<Record<string, tools.ToolStat>>;
+
+export function ReadFilePage(arg1:string,arg2:number,arg3:number):Promise<tools.FilePage>;
+
 export function SetMode(arg1:string):Promise<void>;
 
+export function SetModel(arg1:string):Promise<void>;
+
+export function SnapshotSession(arg1:string):Promise<void>;
+
 export function StartTerminalListeners():Promise<void>;
 
-export function SubmitReview(arg1:Array<main.ReviewComment>):Promise<void>;
+export function SubmitReview(arg1:string,arg2:Array<main.ReviewComment>,arg3:main.ReviewOptions):Promise<void>;
 
 export function SwitchSession(arg1:string):Promise<void>;
@@ -2,12 +2,24 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function Authenticate(arg1, arg2) {
+  return window['go']['main']['App']['Authenticate'](arg1, arg2);
+}
+
+export function CancelReview(arg1) {
+  return window['go']['main']['App']['CancelReview'](arg1);
+}
+
 export function CloseSession(arg1) {
   return window['go']['main']['App']['CloseSession'](arg1);
 }
 
-export function CreateSession(arg1) {
-  return window['go']['main']['App']['CreateSession'](arg1);
+export function CompactHistory() {
+  return window['go']['main']['App']['CompactHistory']();
+}
+
+export function CreateSession(arg1, arg2) {
+  return window['go']['main']['App']['CreateSession'](arg1, arg2);
 }
 
 export function GetActiveSession() {
@@ -18,6 +30,14 @@ export function GetCurrentMode() {
   return window['go']['main']['App']['GetCurrentMode']();
 }
 
+export function GetFileDiff(arg1) {
+  return window['go']['main']['App']['GetFileDiff'](arg1);
+}
+
+export function GetModel() {
+  return window['go']['main']['App']['GetModel']();
+}
+
 export function GetModes() {
   return window['go']['main']['App']['GetModes']();
 }
@@ -26,16 +46,36 @@ export function GetSessions() {
   return window['go']['main']['App']['GetSessions']();
 }
 
+export function GetToolStates(arg1) {
+  return window['go']['main']['App']['GetToolStates'](arg1);
+}
+
+export function GetToolStats() {
+  return window['go']['main']['App']['GetToolStats']();
+}
+
+export function ReadFilePage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ReadFilePage'](arg1, arg2, arg3);
+}
+
 export function SetMode(arg1) {
   return window['go']['main']['App']['SetMode'](arg1);
 }
 
+export function SetModel(arg1) {
+  return window['go']['main']['App']['SetModel'](arg1);
+}
+
+export function SnapshotSession(arg1) {
+  return window['go']['main']['App']['SnapshotSession'](arg1);
+}
+
 export function StartTerminalListeners() {
   return window['go']['main']['App']['StartTerminalListeners']();
 }
 
-export function SubmitReview(arg1) {
-  return window['go']['main']['App']['SubmitReview'](arg1);
+export function SubmitReview(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SubmitReview'](arg1, arg2, arg3);
 }
 
 export function SwitchSession(arg1) {
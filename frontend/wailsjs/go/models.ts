@@ -1,5 +1,77 @@
 export namespace backend {
 	
+	export class DiffStats {
+	    additions: number;
+	    deletions: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.additions = source["additions"];
+	        this.deletions = source["deletions"];
+	    }
+	}
+	export class PatchHunk {
+	    oldStart: number;
+	    oldLines: number;
+	    newStart: number;
+	    newLines: number;
+	    lines: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PatchHunk(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.oldStart = source["oldStart"];
+	        this.oldLines = source["oldLines"];
+	        this.newStart = source["newStart"];
+	        this.newLines = source["newLines"];
+	        this.lines = source["lines"];
+	    }
+	}
+	export class FileChange {
+	    filePath: string;
+	    originalContent: string;
+	    currentContent: string;
+	    hunks: PatchHunk[];
+	    stats?: DiffStats;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filePath = source["filePath"];
+	        this.originalContent = source["originalContent"];
+	        this.currentContent = source["currentContent"];
+	        this.hunks = this.convertValues(source["hunks"], PatchHunk);
+	        this.stats = this.convertValues(source["stats"], DiffStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class SessionMode {
 	    id: string;
 	    name: string;
@@ -16,6 +88,144 @@ export namespace backend {
 	        this.description = source["description"];
 	    }
 	}
+	export class DiffBlock {
+	    type: string;
+	    path?: string;
+	    oldText?: string;
+	    newText?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiffBlock(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.path = source["path"];
+	        this.oldText = source["oldText"];
+	        this.newText = source["newText"];
+	    }
+	}
+	export class TextContent {
+	    type: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TextContent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.text = source["text"];
+	    }
+	}
+	export class OutputBlock {
+	    type: string;
+	    content?: TextContent;
+	    mimeType?: string;
+	    path?: string;
+	    oldContent?: string;
+	    newContent?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutputBlock(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.content = this.convertValues(source["content"], TextContent);
+	        this.mimeType = source["mimeType"];
+	        this.path = source["path"];
+	        this.oldContent = source["oldContent"];
+	        this.newContent = source["newContent"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PermOption {
+	    optionId: string;
+	    name: string;
+	    kind: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PermOption(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.optionId = source["optionId"];
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	    }
+	}
+	export class ToolState {
+	    id: string;
+	    status: string;
+	    title: string;
+	    kind: string;
+	    toolName?: string;
+	    parentId?: string;
+	    input?: Record<string, any>;
+	    output?: OutputBlock[];
+	    diff?: Record<string, any>;
+	    diffs?: DiffBlock[];
+	    permissionOptions?: PermOption[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.status = source["status"];
+	        this.title = source["title"];
+	        this.kind = source["kind"];
+	        this.toolName = source["toolName"];
+	        this.parentId = source["parentId"];
+	        this.input = source["input"];
+	        this.output = this.convertValues(source["output"], OutputBlock);
+	        this.diff = source["diff"];
+	        this.diffs = this.convertValues(source["diffs"], DiffBlock);
+	        this.permissionOptions = this.convertValues(source["permissionOptions"], PermOption);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
@@ -28,6 +238,8 @@ export namespace main {
 	    Text: string;
 	    LineNumber: number;
 	    HunkIndex: number;
+	    Severity: string;
+	    Category: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ReviewComment(source);
@@ -41,6 +253,24 @@ export namespace main {
 	        this.Text = source["Text"];
 	        this.LineNumber = source["LineNumber"];
 	        this.HunkIndex = source["HunkIndex"];
+	        this.Severity = source["Severity"];
+	        this.Category = source["Category"];
+	    }
+	}
+	export class ReviewOptions {
+	    agentCommand?: string;
+	    model?: string;
+	    readOnly?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReviewOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.agentCommand = source["agentCommand"];
+	        this.model = source["model"];
+	        this.readOnly = source["readOnly"];
 	    }
 	}
 	export class SessionInfo {
@@ -48,6 +278,7 @@ export namespace main {
 	    Name: string;
 	    CreatedAt: string;
 	    ModeID: string;
+	    Busy: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new SessionInfo(source);
@@ -59,6 +290,84 @@ export namespace main {
 	        this.Name = source["Name"];
 	        this.CreatedAt = source["CreatedAt"];
 	        this.ModeID = source["ModeID"];
+	        this.Busy = source["Busy"];
+	    }
+	}
+
+}
+
+export namespace tools {
+	
+	export class FileLine {
+	    number: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.number = source["number"];
+	        this.text = source["text"];
+	    }
+	}
+	export class FilePage {
+	    page: number;
+	    pageSize: number;
+	    lines: FileLine[];
+	    hasMore: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FilePage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = source["page"];
+	        this.pageSize = source["pageSize"];
+	        this.lines = this.convertValues(source["lines"], FileLine);
+	        this.hasMore = source["hasMore"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ToolStat {
+	    calls: number;
+	    errors: number;
+	    totalMs: number;
+	    p50Ms: number;
+	    p95Ms: number;
+	    maxMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.calls = source["calls"];
+	        this.errors = source["errors"];
+	        this.totalMs = source["totalMs"];
+	        this.p50Ms = source["p50Ms"];
+	        this.p95Ms = source["p95Ms"];
+	        this.maxMs = source["maxMs"];
 	    }
 	}
 
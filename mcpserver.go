@@ -9,7 +9,6 @@ import (
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // UserQuestionServer wraps an MCP server with AskUserQuestion tool
@@ -17,7 +16,7 @@ type UserQuestionServer struct {
 	mcpServer  *server.MCPServer
 	httpServer *http.Server
 	listener   net.Listener
-	ctx        context.Context
+	emitter    EventEmitter
 	responseCh chan UserAnswer
 }
 
@@ -40,9 +39,9 @@ type UserAnswer struct {
 }
 
 // NewUserQuestionServer creates a new MCP server for user questions
-func NewUserQuestionServer(ctx context.Context) *UserQuestionServer {
+func NewUserQuestionServer(emitter EventEmitter) *UserQuestionServer {
 	s := &UserQuestionServer{
-		ctx:        ctx,
+		emitter:    emitter,
 		responseCh: make(chan UserAnswer, 1),
 	}
 
@@ -122,7 +121,7 @@ func (s *UserQuestionServer) handleAskUserQuestion(ctx context.Context, req mcp.
 		Question:  question,
 		Options:   options,
 	}
-	runtime.EventsEmit(s.ctx, "user_question", uq)
+	s.emitter.Emit("user_question", uq)
 
 	// Block waiting for response
 	answer := <-s.responseCh
@@ -0,0 +1,68 @@
+package permission
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// defaultDeniedCommands are Bash patterns that are never allowed
+var defaultDeniedCommands = []string{
+	`\brm\s+(-\S+\s+)*(/|/\*|~|~/)(\s|;|&|\||$)`, // rm -rf /, rm -rf ~
+	`\bgit\s+push\b.*(\s--force|\s-f)(\s|$)`,     // force push (not --force-with-lease)
+	`\bmkfs(\.\w+)?\b`,                           // format a filesystem
+	`\bdd\b.*\bof=/dev/`,                         // overwrite a device
+	`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`,   // fork bomb
+}
+
+// Denylist rejects tools and Bash commands before any Ask/Allow rule applies
+type Denylist struct {
+	tools    map[string]bool
+	commands []*regexp.Regexp
+}
+
+// NewDenylist creates a denylist from tool names and Bash command regexes
+func NewDenylist(tools, commandPatterns []string) (*Denylist, error) {
+	d := &Denylist{tools: make(map[string]bool)}
+	for _, t := range tools {
+		d.tools[t] = true
+	}
+	for _, p := range commandPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command pattern %q: %w", p, err)
+		}
+		d.commands = append(d.commands, re)
+	}
+	return d, nil
+}
+
+// DefaultDenylist returns a denylist of destructive Bash commands
+func DefaultDenylist() *Denylist {
+	d, _ := NewDenylist(nil, defaultDeniedCommands)
+	return d
+}
+
+// Denies reports whether the tool call is denylisted. Bash input may be the
+// tool's JSON input or the raw command.
+func (d *Denylist) Denies(tool, input string) bool {
+	if d.tools[tool] {
+		return true
+	}
+	if tool != "Bash" || len(d.commands) == 0 {
+		return false
+	}
+	command := input
+	var parsed struct {
+		Command string `json:"command"`
+	}
+	if json.Unmarshal([]byte(input), &parsed) == nil && parsed.Command != "" {
+		command = parsed.Command
+	}
+	for _, re := range d.commands {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenylist_OverridesBroadAllow(t *testing.T) {
+	a := assert.New(t)
+
+	// given - a rule set that allows all Bash, plus the default denylist
+	rules := &RuleSet{rules: map[string]Decision{"Bash": Allow}}
+	layer := NewLayerWithDenylist(rules, DefaultDenylist(), &mockEmitter{})
+
+	// when/then - denylisted commands are rejected, others still allowed
+	a.Equal(Deny, layer.Check("Bash", `{"command":"rm -rf /"}`))
+	a.Equal(Deny, layer.Check("Bash", `{"command":"git push --force origin main"}`))
+	a.Equal(Allow, layer.Check("Bash", `{"command":"git push origin main"}`))
+	a.Equal(Allow, layer.Check("Bash", `{"command":"rm -rf ./build"}`))
+}
+
+func TestDenylist_DefaultCommands(t *testing.T) {
+	d := DefaultDenylist()
+
+	tests := []struct {
+		command string
+		denied  bool
+	}{
+		{"rm -rf /", true},
+		{"rm -rf /*", true},
+		{"sudo rm -r -f / ", true},
+		{"rm -rf ~", true},
+		{"cd /tmp && rm -rf / && echo done", true},
+		{"rm -rf /tmp/build", false},
+		{"rm file.txt", false},
+		{"git push -f", true},
+		{"git push origin main --force", true},
+		{"git push --force-with-lease", false},
+		{"mkfs.ext4 /dev/sda1", true},
+		{"dd if=/dev/zero of=/dev/sda", true},
+		{"dd if=in.img of=out.img", false},
+		{":(){ :|:& };:", true},
+		{"ls -la", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			assert.Equal(t, tt.denied, d.Denies("Bash", tt.command))
+		})
+	}
+}
+
+func TestDenylist_ToolNames(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a denylist naming a tool
+	d, err := NewDenylist([]string{"WebFetch"}, nil)
+	r.NoError(err)
+	layer := NewLayerWithDenylist(DefaultRules(), d, &mockEmitter{})
+
+	// when/then - the tool is denied although the rules allow it
+	a.Equal(Deny, layer.Check("WebFetch", ""))
+	a.Equal(Allow, layer.Check("Read", ""))
+	a.Equal(Ask, layer.Check("Bash", `{"command":"rm -rf /"}`), "no command patterns configured")
+}
+
+func TestNewDenylist_InvalidPattern(t *testing.T) {
+	a := assert.New(t)
+
+	// when
+	_, err := NewDenylist(nil, []string{"("})
+
+	// then
+	a.Error(err)
+	a.Contains(err.Error(), "invalid command pattern")
+}
@@ -2,6 +2,7 @@ package permission
 
 import (
 	"ccui/backend"
+	"fmt"
 	"sync"
 )
 
@@ -12,18 +13,30 @@ type EventEmitter interface {
 
 // PermissionRequest is emitted when user permission is needed
 type PermissionRequest struct {
+	SessionID  string               `json:"sessionId"`
 	ToolCallID string               `json:"toolCallId"`
 	ToolName   string               `json:"toolName"`
 	Options    []backend.PermOption `json:"options"`
 }
 
-// Layer handles permission checks and user permission requests
+// Layer handles permission checks and user permission requests. It is safe
+// for concurrent use by several sessions; rules and denylist are read-only
+// after construction.
 type Layer struct {
-	rules   *RuleSet
-	emitter EventEmitter
+	rules       *RuleSet
+	denylist    *Denylist // optional, checked before rules
+	emitter     EventEmitter
+	autoApprove map[string]bool // tools allowed without asking; guarded by mu
 
-	mu       sync.Mutex
-	pending  map[string]chan string // toolCallID -> response channel
+	mu      sync.Mutex
+	pending map[pendingKey]chan string // response channel per session tool call
+}
+
+// pendingKey scopes a tool call ID to its session, since sessions sharing a
+// layer may reuse IDs
+type pendingKey struct {
+	sessionID  string
+	toolCallID string
 }
 
 // NewLayer creates a new permission layer
@@ -31,49 +44,98 @@ func NewLayer(rules *RuleSet, emitter EventEmitter) *Layer {
 	return &Layer{
 		rules:   rules,
 		emitter: emitter,
-		pending: make(map[string]chan string),
+		pending: make(map[pendingKey]chan string),
 	}
 }
 
+// NewLayerWithDenylist creates a permission layer whose denylist overrides all rules
+func NewLayerWithDenylist(rules *RuleSet, denylist *Denylist, emitter EventEmitter) *Layer {
+	l := NewLayer(rules, emitter)
+	l.denylist = denylist
+	return l
+}
+
+// SetAutoApprove replaces the tool names that are allowed without asking.
+// The denylist still applies to them.
+func (l *Layer) SetAutoApprove(toolNames ...string) {
+	set := make(map[string]bool, len(toolNames))
+	for _, name := range toolNames {
+		set[name] = true
+	}
+	l.mu.Lock()
+	l.autoApprove = set
+	l.mu.Unlock()
+}
+
+// AutoApproves reports whether toolName is allowed without asking
+func (l *Layer) AutoApproves(toolName string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.autoApprove[toolName]
+}
+
 // Check returns the permission decision for a tool
 func (l *Layer) Check(toolName, input string) Decision {
+	if l.Denied(toolName, input) {
+		return Deny
+	}
+	if l.AutoApproves(toolName) {
+		return Allow
+	}
 	return l.rules.Check(toolName, input)
 }
 
+// Denied reports whether the denylist rejects the tool call
+func (l *Layer) Denied(toolName, input string) bool {
+	return l.denylist != nil && l.denylist.Denies(toolName, input)
+}
+
 // Request blocks until user grants/denies permission
-// Returns the selected option ID
-func (l *Layer) Request(toolCallID, toolName string, options []backend.PermOption) (string, error) {
-	// Create response channel
+// Returns the selected option ID; denylisted calls get a reject option and
+// auto-approved tools an allow option without asking
+func (l *Layer) Request(sessionID, toolCallID, toolName, input string, options []backend.PermOption) (string, error) {
+	if l.Denied(toolName, input) {
+		return backend.RejectOptionID(options), nil
+	}
+	if l.AutoApproves(toolName) {
+		return backend.AllowOptionID(options), nil
+	}
+
+	// Create response channel; a duplicate ID would steal the other caller's response
+	key := pendingKey{sessionID: sessionID, toolCallID: toolCallID}
 	respCh := make(chan string, 1)
 	l.mu.Lock()
-	l.pending[toolCallID] = respCh
+	if _, ok := l.pending[key]; ok {
+		l.mu.Unlock()
+		return "", fmt.Errorf("permission request already pending for %s", toolCallID)
+	}
+	l.pending[key] = respCh
 	l.mu.Unlock()
 
 	// Emit permission request event
 	l.emitter.Emit("permission_request", PermissionRequest{
+		SessionID:  sessionID,
 		ToolCallID: toolCallID,
 		ToolName:   toolName,
 		Options:    options,
 	})
 
-	// Block waiting for response
-	optionID := <-respCh
-
-	// Cleanup
-	l.mu.Lock()
-	delete(l.pending, toolCallID)
-	l.mu.Unlock()
-
-	return optionID, nil
+	// Block waiting for response; Respond removes the pending entry
+	return <-respCh, nil
 }
 
-// Respond unblocks a pending permission request
-func (l *Layer) Respond(toolCallID, optionID string) {
+// Respond unblocks the session's pending permission request and reports
+// whether one was waiting. Responses for unknown or already answered requests
+// are dropped.
+func (l *Layer) Respond(sessionID, toolCallID, optionID string) bool {
+	key := pendingKey{sessionID: sessionID, toolCallID: toolCallID}
 	l.mu.Lock()
-	ch, ok := l.pending[toolCallID]
-	l.mu.Unlock()
-
-	if ok {
-		ch <- optionID
+	defer l.mu.Unlock()
+	ch, ok := l.pending[key]
+	if !ok {
+		return false
 	}
+	delete(l.pending, key)
+	ch <- optionID // buffered, and the entry is gone so this is the only send
+	return true
 }
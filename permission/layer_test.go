@@ -64,7 +64,7 @@ func TestPermissionLayer_RequestBlocks(t *testing.T) {
 	resultCh := make(chan string, 1)
 	errCh := make(chan error, 1)
 	go func() {
-		optionID, err := layer.Request("call-123", "Write", options)
+		optionID, err := layer.Request("s1", "call-123", "Write", "", options)
 		if err != nil {
 			errCh <- err
 		} else {
@@ -89,11 +89,12 @@ func TestPermissionLayer_RequestBlocks(t *testing.T) {
 	req, ok := events[0].data.(PermissionRequest)
 	r.True(ok)
 	a.Equal("call-123", req.ToolCallID)
+	a.Equal("s1", req.SessionID)
 	a.Equal("Write", req.ToolName)
 	a.Equal(options, req.Options)
 
 	// cleanup - respond to unblock
-	layer.Respond("call-123", "allow")
+	layer.Respond("s1", "call-123", "allow")
 	select {
 	case result := <-resultCh:
 		a.Equal("allow", result)
@@ -116,7 +117,7 @@ func TestPermissionLayer_RespondUnblocks(t *testing.T) {
 
 	resultCh := make(chan string, 1)
 	go func() {
-		optionID, _ := layer.Request("call-456", "Edit", options)
+		optionID, _ := layer.Request("s1", "call-456", "Edit", "", options)
 		resultCh <- optionID
 	}()
 
@@ -124,7 +125,7 @@ func TestPermissionLayer_RespondUnblocks(t *testing.T) {
 	time.Sleep(20 * time.Millisecond)
 
 	// when - Respond is called with the deny option
-	layer.Respond("call-456", "deny")
+	layer.Respond("s1", "call-456", "deny")
 
 	// then - Request should return the selected option
 	select {
@@ -134,3 +135,187 @@ func TestPermissionLayer_RespondUnblocks(t *testing.T) {
 		t.Fatal("Request should unblock after Respond")
 	}
 }
+
+func TestPermissionLayer_AutoApprove(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - Bash is trusted, the denylist still guards it
+	emitter := &mockEmitter{}
+	layer := NewLayerWithDenylist(DefaultRules(), DefaultDenylist(), emitter)
+	layer.SetAutoApprove("Bash")
+
+	// when/then - Check allows Bash but not Write, and the denylist wins
+	a.Equal(Allow, layer.Check("Bash", `{"command":"ls"}`))
+	a.Equal(Ask, layer.Check("Write", `{"file_path":"/tmp/a.txt"}`))
+	a.Equal(Deny, layer.Check("Bash", `{"command":"rm -rf /"}`))
+
+	// when - Request is made for the trusted tool
+	optionID, err := layer.Request("s1", "call-789", "Bash", `{"command":"ls"}`, []backend.PermOption{
+		{OptionID: "allow", Name: "Allow", Kind: "allow"},
+		{OptionID: "deny", Name: "Deny", Kind: "deny"},
+	})
+
+	// then - it returns an allow option without emitting a request
+	r.NoError(err)
+	a.Equal("allow", optionID)
+	a.Empty(emitter.getEvents())
+}
+
+func TestPermissionLayer_RequestDenylistBeatsAutoApprove(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - Bash is trusted but the command is denylisted
+	emitter := &mockEmitter{}
+	layer := NewLayerWithDenylist(DefaultRules(), DefaultDenylist(), emitter)
+	layer.SetAutoApprove("Bash")
+
+	// when - an agent requests permission for the destructive command
+	optionID, err := layer.Request("s1", "call-790", "Bash", `{"command":"rm -rf /"}`, []backend.PermOption{
+		{OptionID: "allow", Name: "Allow", Kind: "allow_once"},
+		{OptionID: "reject", Name: "Reject", Kind: "reject_once"},
+	})
+
+	// then - it is rejected without asking
+	r.NoError(err)
+	a.Equal("reject", optionID)
+	a.Empty(emitter.getEvents())
+}
+
+func TestPermissionLayer_DuplicatePendingID(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a pending request for call-1
+	layer := NewLayer(DefaultRules(), &mockEmitter{})
+	options := []backend.PermOption{{OptionID: "allow", Name: "Allow", Kind: "allow"}}
+	resultCh := make(chan string, 1)
+	go func() {
+		optionID, _ := layer.Request("s1", "call-1", "Bash", "", options)
+		resultCh <- optionID
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// when - another caller reuses the ID
+	_, err := layer.Request("s1", "call-1", "Write", "", options)
+
+	// then - it is rejected and the first request keeps its channel
+	r.Error(err)
+	layer.Respond("s1", "call-1", "allow")
+	select {
+	case result := <-resultCh:
+		a.Equal("allow", result)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("first Request should receive the response")
+	}
+
+	// when/then - a second response for the answered request does not block
+	done := make(chan struct{})
+	go func() {
+		layer.Respond("s1", "call-1", "deny")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("duplicate Respond should be dropped")
+	}
+}
+
+func TestPermissionLayer_SameIDAcrossSessions(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - two sessions waiting on the same tool call ID
+	emitter := &mockEmitter{}
+	layer := NewLayer(DefaultRules(), emitter)
+	options := []backend.PermOption{
+		{OptionID: "allow", Name: "Allow", Kind: "allow"},
+		{OptionID: "deny", Name: "Deny", Kind: "deny"},
+	}
+	results := map[string]chan string{"s1": make(chan string, 1), "s2": make(chan string, 1)}
+	for sessionID, ch := range results {
+		go func() {
+			optionID, err := layer.Request(sessionID, "toolu_bash", "Bash", "", options)
+			a.NoError(err)
+			ch <- optionID
+		}()
+	}
+	r.Eventually(func() bool { return len(emitter.getEvents()) == 2 }, time.Second, 5*time.Millisecond)
+
+	// when - each session gets a different answer
+	layer.Respond("s2", "toolu_bash", "deny")
+	layer.Respond("s1", "toolu_bash", "allow")
+
+	// then - neither response reached the other session
+	for sessionID, want := range map[string]string{"s1": "allow", "s2": "deny"} {
+		select {
+		case got := <-results[sessionID]:
+			a.Equal(want, got, sessionID)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("%s never received its response", sessionID)
+		}
+	}
+}
+
+func TestPermissionLayer_RespondUnknown(t *testing.T) {
+	a := assert.New(t)
+
+	// given - a layer with nothing pending
+	layer := NewLayer(DefaultRules(), &mockEmitter{})
+
+	// when/then - the response reports no waiter
+	a.False(layer.Respond("s1", "call-none", "allow"))
+}
+
+func TestPermissionLayer_RespondBeforeRequest(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - a response that arrives before its request
+	emitter := &mockEmitter{}
+	layer := NewLayer(DefaultRules(), emitter)
+	a.False(layer.Respond("s1", "call-early", "allow"))
+
+	// when - the request is made afterwards
+	resultCh := make(chan string, 1)
+	go func() {
+		optionID, _ := layer.Request("s1", "call-early", "Write", "", []backend.PermOption{{OptionID: "allow", Kind: "allow"}})
+		resultCh <- optionID
+	}()
+	r.Eventually(func() bool { return len(emitter.getEvents()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// then - the stale response was not kept for it
+	select {
+	case got := <-resultCh:
+		t.Fatalf("request answered by a stale response: %q", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+	a.True(layer.Respond("s1", "call-early", "deny"))
+	a.Equal("deny", <-resultCh)
+}
+
+func TestPermissionLayer_DoubleRespond(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	// given - one pending request
+	emitter := &mockEmitter{}
+	layer := NewLayer(DefaultRules(), emitter)
+	resultCh := make(chan string, 1)
+	go func() {
+		optionID, _ := layer.Request("s1", "call-twice", "Write", "", []backend.PermOption{{OptionID: "allow", Kind: "allow"}})
+		resultCh <- optionID
+	}()
+	r.Eventually(func() bool { return len(emitter.getEvents()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// when - the user clicks twice
+	first := layer.Respond("s1", "call-twice", "allow")
+	second := layer.Respond("s1", "call-twice", "deny")
+
+	// then - only the first reaches the waiter
+	a.True(first)
+	a.False(second)
+	a.Equal("allow", <-resultCh)
+}
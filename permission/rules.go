@@ -27,16 +27,27 @@ func DefaultRules() *RuleSet {
 	return &RuleSet{
 		rules: map[string]Decision{
 			// Safe tools - auto-allow
-			"Read":      Allow,
-			"Glob":      Allow,
-			"Grep":      Allow,
-			"WebSearch": Allow,
-			"WebFetch":  Allow,
+			"Read":        Allow,
+			"ReadMany":    Allow,
+			"Glob":        Allow,
+			"Grep":        Allow,
+			"Outline":     Allow,
+			"GitStatus":   Allow,
+			"GitDiff":     Allow,
+			"ProjectInfo": Allow,
+			"WebSearch":   Allow,
+			"WebFetch":    Allow,
+			// Background process listing and output are read-only
+			"ListBackground": Allow,
+			"ReadBackground": Allow,
 			// Write tools - ask
-			"Write":        Ask,
-			"Edit":         Ask,
-			"NotebookEdit": Ask,
-			"Bash":         Ask,
+			"Write":          Ask,
+			"Edit":           Ask,
+			"StructuredEdit": Ask,
+			"ApplyPatch":     Ask,
+			"NotebookEdit":   Ask,
+			"Bash":           Ask,
+			"KillBackground": Ask,
 		},
 	}
 }
@@ -13,7 +13,7 @@ func TestPermissionRules_ReadAllowed(t *testing.T) {
 	rules := DefaultRules()
 
 	// when/then - safe tools should be allowed without asking
-	safeTools := []string{"Read", "Glob", "Grep", "WebSearch", "WebFetch"}
+	safeTools := []string{"Read", "ReadMany", "Glob", "Grep", "Outline", "GitStatus", "GitDiff", "ProjectInfo", "WebSearch", "WebFetch"}
 	for _, tool := range safeTools {
 		decision := rules.Check(tool, "any input")
 		a.Equal(Allow, decision, "tool %s should be allowed", tool)
@@ -27,7 +27,7 @@ func TestPermissionRules_WriteAsks(t *testing.T) {
 	rules := DefaultRules()
 
 	// when/then - write tools should ask for permission
-	writeTools := []string{"Write", "Edit", "NotebookEdit"}
+	writeTools := []string{"Write", "Edit", "StructuredEdit", "ApplyPatch", "NotebookEdit"}
 	for _, tool := range writeTools {
 		decision := rules.Check(tool, "any input")
 		a.Equal(Ask, decision, "tool %s should ask", tool)
@@ -1,12 +1,13 @@
 package main
 
 import (
-	"context"
 	"io"
 	"log/slog"
 	"os"
 	"os/exec"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/creack/pty"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -14,22 +15,27 @@ import (
 
 // PTYSession represents an active PTY
 type PTYSession struct {
-	id     string
-	cmd    *exec.Cmd
-	pty    *os.File
-	cancel chan struct{}
+	id       string
+	cmd      *exec.Cmd
+	pty      *os.File
+	cancel   chan struct{}
+	output   *tailBuffer   // captured output, nil for user shells
+	readDone chan struct{} // closed when readLoop returns
+	exited   chan struct{} // closed once the process has been reaped
+	exitCode *int
+	signal   *string
 }
 
 // PTYManager manages multiple PTY sessions
 type PTYManager struct {
-	ctx      context.Context
+	emitter  EventEmitter
 	sessions map[string]*PTYSession
 	mu       sync.RWMutex
 }
 
-func NewPTYManager(ctx context.Context) *PTYManager {
+func NewPTYManager(emitter EventEmitter) *PTYManager {
 	return &PTYManager{
-		ctx:      ctx,
+		emitter:  emitter,
 		sessions: make(map[string]*PTYSession),
 	}
 }
@@ -37,7 +43,7 @@ func NewPTYManager(ctx context.Context) *PTYManager {
 // StartTerminalListeners registers event handlers for terminal operations
 func (a *App) StartTerminalListeners() {
 	if a.ptyManager == nil {
-		a.ptyManager = NewPTYManager(a.ctx)
+		a.ptyManager = NewPTYManager(a.emitter)
 	}
 
 	runtime.EventsOn(a.ctx, "terminal:start", func(data ...interface{}) {
@@ -96,23 +102,6 @@ func (a *App) StartTerminalListeners() {
 
 // Start creates a new PTY session
 func (m *PTYManager) Start(id string, cols, rows uint16) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Stop existing session if any
-	if s, ok := m.sessions[id]; ok {
-		select {
-		case <-s.cancel:
-			// Already closed
-		default:
-			close(s.cancel)
-		}
-		s.pty.Close()
-		s.cmd.Process.Kill()
-		s.cmd.Wait()
-		delete(m.sessions, id)
-	}
-
 	shell := os.Getenv("SHELL")
 	if shell == "" {
 		shell = "/bin/bash"
@@ -121,26 +110,86 @@ func (m *PTYManager) Start(id string, cols, rows uint16) error {
 	cmd := exec.Command(shell)
 	cmd.Env = os.Environ()
 
+	_, err := m.StartCommand(id, cmd, cols, rows, nil)
+	return err
+}
+
+// StartCommand runs cmd in a new PTY session, capturing output when output is non-nil
+func (m *PTYManager) StartCommand(id string, cmd *exec.Cmd, cols, rows uint16, output *tailBuffer) (*PTYSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Stop existing session if any
+	if s, ok := m.sessions[id]; ok {
+		s.stop()
+		delete(m.sessions, id)
+	}
+
 	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: cols, Rows: rows})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	session := &PTYSession{
-		id:     id,
-		cmd:    cmd,
-		pty:    ptmx,
-		cancel: make(chan struct{}),
+		id:       id,
+		cmd:      cmd,
+		pty:      ptmx,
+		cancel:   make(chan struct{}),
+		output:   output,
+		readDone: make(chan struct{}),
+		exited:   make(chan struct{}),
 	}
 	m.sessions[id] = session
 
 	// Read loop - emit output to frontend
 	go m.readLoop(session)
+	go session.reap()
+
+	return session, nil
+}
+
+// session returns the PTY session for id, or nil
+func (m *PTYManager) session(id string) *PTYSession {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions[id]
+}
 
-	return nil
+// reap waits for the process and records how it exited
+func (s *PTYSession) reap() {
+	s.cmd.Wait()
+	if state := s.cmd.ProcessState; state != nil {
+		if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			sig := ws.Signal().String()
+			s.signal = &sig
+		} else {
+			code := state.ExitCode()
+			s.exitCode = &code
+		}
+	}
+	// let readLoop drain the final output before reporting the exit
+	select {
+	case <-s.readDone:
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(s.exited)
+}
+
+// stop cancels the read loop, kills the process and waits for it to be reaped
+func (s *PTYSession) stop() {
+	select {
+	case <-s.cancel:
+		// Already closed
+	default:
+		close(s.cancel)
+	}
+	s.pty.Close()
+	s.cmd.Process.Kill()
+	<-s.exited
 }
 
 func (m *PTYManager) readLoop(session *PTYSession) {
+	defer close(session.readDone)
 	buf := make([]byte, 4096)
 	for {
 		select {
@@ -155,7 +204,10 @@ func (m *PTYManager) readLoop(session *PTYSession) {
 				return
 			}
 			if n > 0 {
-				runtime.EventsEmit(m.ctx, "terminal:"+session.id+":output", string(buf[:n]))
+				if session.output != nil {
+					session.output.Write(buf[:n])
+				}
+				m.emitter.Emit("terminal:"+session.id+":output", string(buf[:n]))
 			}
 		}
 	}
@@ -186,14 +238,7 @@ func (m *PTYManager) Stop(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if s, ok := m.sessions[id]; ok {
-		select {
-		case <-s.cancel:
-		default:
-			close(s.cancel)
-		}
-		s.pty.Close()
-		s.cmd.Process.Kill()
-		s.cmd.Wait()
+		s.stop()
 		delete(m.sessions, id)
 	}
 }
@@ -203,14 +248,7 @@ func (m *PTYManager) StopAll() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	for _, s := range m.sessions {
-		select {
-		case <-s.cancel:
-		default:
-			close(s.cancel)
-		}
-		s.pty.Close()
-		s.cmd.Process.Kill()
-		s.cmd.Wait()
+		s.stop()
 	}
 	m.sessions = make(map[string]*PTYSession)
 }
@@ -4,6 +4,11 @@ import (
 	"ccui/backend"
 	"ccui/backend/acp"
 	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"strings"
 	"testing"
 )
 
@@ -61,3 +66,49 @@ func TestOpenCodeAdapterToolResponse(t *testing.T) {
 		t.Fatal("expected structured patch")
 	}
 }
+
+// TestNoDuplicateBackendTypes guards the consolidation onto the backend and
+// backend/acp types: package main may alias them but not redeclare them
+func TestNoDuplicateBackendTypes(t *testing.T) {
+	shared := map[string]string{}
+	for _, dir := range []string{"backend", "backend/acp"} {
+		for name := range declaredTypes(t, dir, false) {
+			shared[name] = dir
+		}
+	}
+	for name := range declaredTypes(t, ".", true) {
+		if dir, ok := shared[name]; ok {
+			t.Errorf("package main redeclares %s.%s; use the shared type or an alias", dir, name)
+		}
+	}
+}
+
+// declaredTypes returns the exported non-test type names declared in dir,
+// skipping aliases when skipAliases is set
+func declaredTypes(t *testing.T, dir string, skipAliases bool) map[string]bool {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("parse %s: %v", dir, err)
+	}
+	names := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if ts.Name.IsExported() && !(skipAliases && ts.Assign.IsValid()) {
+						names[ts.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return names
+}